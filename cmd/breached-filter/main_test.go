@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"refina-auth/internal/utils/policy"
+)
+
+func TestBuildFromPwnedPasswordsDump(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "pwnedpasswords.txt")
+	out := filepath.Join(dir, "breached.bloom")
+
+	dump := strings.Join([]string{
+		"# komentar diabaikan",
+		policy.BreachedKey("seen-often") + ":42",
+		strings.ToLower(policy.BreachedKey("lowercase-hash")) + ":7",
+		policy.BreachedKey("seen-once") + ":1",
+		policy.BreachedKey("hash-without-count"),
+		"plain-password\r",
+		"",
+	}, "\n")
+	if err := os.WriteFile(in, []byte(dump), 0o600); err != nil {
+		t.Fatalf("write dump: %v", err)
+	}
+
+	if err := build(in, out, 0.001, 2); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	list, err := policy.NewBreachedList(out)
+	if err != nil {
+		t.Fatalf("NewBreachedList: %v", err)
+	}
+	for password, want := range map[string]bool{
+		"seen-often":         true,
+		"lowercase-hash":     true,
+		"hash-without-count": true,
+		"plain-password":     true,
+		"seen-once":          false,
+		"never-breached":     false,
+	} {
+		if got := list.Contains(password); got != want {
+			t.Fatalf("Contains(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestBuildRejectsEmptyInput(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(in, []byte("# tidak ada entri\n"), 0o600); err != nil {
+		t.Fatalf("write input: %v", err)
+	}
+
+	if err := build(in, filepath.Join(dir, "breached.bloom"), 0.001, 0); err == nil {
+		t.Fatal("build accepted an input without entries")
+	}
+}
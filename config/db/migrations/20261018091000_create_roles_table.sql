@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255)
+);
+CREATE INDEX idx_roles_deleted_at ON roles (deleted_at);
+
+CREATE TABLE role_permissions (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    role VARCHAR(100) NOT NULL REFERENCES roles (name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    UNIQUE (role, permission)
+);
+CREATE INDEX idx_role_permissions_deleted_at ON role_permissions (deleted_at);
+
+INSERT INTO roles (created_at, updated_at, name, description) VALUES
+    (NOW(), NOW(), 'admin', 'Full access to user management'),
+    (NOW(), NOW(), 'user', 'Regular Refina user');
+
+INSERT INTO role_permissions (created_at, updated_at, role, permission) VALUES
+    (NOW(), NOW(), 'admin', 'users:read'),
+    (NOW(), NOW(), 'admin', 'users:update'),
+    (NOW(), NOW(), 'admin', 'users:delete'),
+    (NOW(), NOW(), 'admin', 'roles:assign'),
+    (NOW(), NOW(), 'admin', 'roles:manage');
+
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles (name) ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'users'::regclass AND contype = 'p') THEN
+        ALTER TABLE users ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+    END IF;
+END $$;
+
+CREATE TABLE user_identities (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    email VARCHAR(100),
+    UNIQUE (provider, provider_user_id)
+);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+CREATE INDEX idx_user_identities_deleted_at ON user_identities (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
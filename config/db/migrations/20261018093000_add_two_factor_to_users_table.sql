@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN two_factor_secret TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN two_factor_enabled_at timestamp without time zone;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN IF EXISTS two_factor_enabled_at,
+    DROP COLUMN IF EXISTS two_factor_secret;
+-- +goose StatementEnd
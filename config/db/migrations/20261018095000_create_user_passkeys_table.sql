@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_passkeys (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(50) DEFAULT '' NOT NULL,
+    transports TEXT DEFAULT '' NOT NULL,
+    aaguid BYTEA,
+    sign_count BIGINT DEFAULT 0 NOT NULL,
+    clone_warning BOOLEAN DEFAULT FALSE NOT NULL,
+    user_verified BOOLEAN DEFAULT FALSE NOT NULL,
+    backup_eligible BOOLEAN DEFAULT FALSE NOT NULL,
+    backup_state BOOLEAN DEFAULT FALSE NOT NULL,
+    last_used_at timestamp with time zone
+);
+CREATE INDEX idx_user_passkeys_user_id ON user_passkeys (user_id);
+CREATE INDEX idx_user_passkeys_deleted_at ON user_passkeys (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_passkeys;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Encoded hash Argon2id memuat nama algoritma, parameter dan salt sehingga lebih panjang dari hash bcrypt
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(100);
+-- +goose StatementEnd
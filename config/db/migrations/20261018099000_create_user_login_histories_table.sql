@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_login_histories (
+    id uuid DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device VARCHAR(100) DEFAULT '' NOT NULL,
+    user_agent TEXT DEFAULT '' NOT NULL,
+    ip_address VARCHAR(45) DEFAULT '' NOT NULL,
+    login_method VARCHAR(20) NOT NULL,
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(50) DEFAULT '' NOT NULL
+);
+CREATE INDEX idx_user_login_histories_user_id_created_at ON user_login_histories (user_id, created_at DESC);
+CREATE INDEX idx_user_login_histories_deleted_at ON user_login_histories (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_login_histories;
+-- +goose StatementEnd
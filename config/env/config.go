@@ -1,7 +1,14 @@
 package env
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"refina-auth/internal/utils/data"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -9,9 +16,43 @@ import (
 
 type (
 	Server struct {
-		Mode         string `env:"MODE"`
-		Port         string `env:"PORT"`
-		JWTSecretKey string `env:"JWT_SECRET_KEY"`
+		Mode           string   `env:"MODE"`
+		Port           string   `env:"PORT"`
+		JWTSecretKey   string   `env:"JWT_SECRET_KEY"`
+		EncryptionKey  string   `env:"ENCRYPTION_KEY"`
+		OTPSecretKey   string   `env:"OTP_SECRET_KEY"`
+		TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	}
+
+	JWT struct {
+		Algorithm           string        `env:"JWT_ALGORITHM"`
+		KeyRotationInterval time.Duration `env:"JWT_KEY_ROTATION_INTERVAL"`
+		AccessTokenTTL      time.Duration `env:"ACCESS_TOKEN_TTL"`
+		RefreshTokenTTL     time.Duration `env:"REFRESH_TOKEN_TTL"`
+		TokenDelivery       string        `env:"TOKEN_DELIVERY"`
+	}
+
+	OTP struct {
+		Length int `env:"OTP_LENGTH"`
+	}
+
+	Password struct {
+		Hasher            string `env:"PASSWORD_HASHER"`
+		BcryptCost        int    `env:"PASSWORD_BCRYPT_COST"`
+		Argon2Memory      int    `env:"PASSWORD_ARGON2_MEMORY"`
+		Argon2Iterations  int    `env:"PASSWORD_ARGON2_ITERATIONS"`
+		Argon2Parallelism int    `env:"PASSWORD_ARGON2_PARALLELISM"`
+		MinLength         int    `env:"PASSWORD_MIN_LENGTH"`
+		MaxLength         int    `env:"PASSWORD_MAX_LENGTH"`
+		RequireLetter     bool   `env:"PASSWORD_REQUIRE_LETTER"`
+		RequireDigit      bool   `env:"PASSWORD_REQUIRE_DIGIT"`
+		RequireMixedCase  bool   `env:"PASSWORD_REQUIRE_MIXED_CASE"`
+		RequireSymbol     bool   `env:"PASSWORD_REQUIRE_SYMBOL"`
+		BanPersonalInfo   bool   `env:"PASSWORD_BAN_PERSONAL_INFO"`
+		MinStrength       int    `env:"PASSWORD_MIN_STRENGTH"`
+		HistoryDepth      int    `env:"PASSWORD_HISTORY_DEPTH"`
+		BreachedCheck     bool   `env:"PASSWORD_BREACHED_CHECK"`
+		BreachedFilter    string `env:"PASSWORD_BREACHED_FILTER"`
 	}
 
 	Client struct {
@@ -19,6 +60,12 @@ type (
 		Port string `env:"CLIENT_PORT"`
 	}
 
+	WebAuthn struct {
+		RPID          string   `env:"WEBAUTHN_RP_ID"`
+		RPDisplayName string   `env:"WEBAUTHN_RP_NAME"`
+		RPOrigins     []string `env:"WEBAUTHN_RP_ORIGINS"`
+	}
+
 	Database struct {
 		DBHost     string `env:"DB_HOST"`
 		DBPort     string `env:"DB_PORT"`
@@ -49,10 +96,29 @@ type (
 		MSClientSecretID string `env:"MICROSOFT_CLIENT_SECRET_ID"`
 	}
 
+	// OAuthProvider - definisi provider OAuth2/OIDC generik, misalnya GitLab, Discord atau Keycloak
+	OAuthProvider struct {
+		Name               string
+		Type               string   `env:"OAUTH_<NAME>_TYPE"`
+		ClientID           string   `env:"OAUTH_<NAME>_CLIENT_ID"`
+		ClientSecret       string   `env:"OAUTH_<NAME>_CLIENT_SECRET"`
+		Issuer             string   `env:"OAUTH_<NAME>_ISSUER"`
+		IssuerFormat       string   `env:"OAUTH_<NAME>_ISSUER_FORMAT"`
+		AuthURL            string   `env:"OAUTH_<NAME>_AUTH_URL"`
+		TokenURL           string   `env:"OAUTH_<NAME>_TOKEN_URL"`
+		UserInfoURL        string   `env:"OAUTH_<NAME>_USERINFO_URL"`
+		Scopes             []string `env:"OAUTH_<NAME>_SCOPES"`
+		ClaimID            string   `env:"OAUTH_<NAME>_CLAIM_ID"`
+		ClaimEmail         string   `env:"OAUTH_<NAME>_CLAIM_EMAIL"`
+		ClaimEmailVerified string   `env:"OAUTH_<NAME>_CLAIM_EMAIL_VERIFIED"`
+		ClaimName          string   `env:"OAUTH_<NAME>_CLAIM_NAME"`
+	}
+
 	OAuth struct {
 		Google    GoogleOAuth
 		Github    GithubOAuth
 		Microsoft MicrosoftOAuth
+		Providers []OAuthProvider `env:"OAUTH_PROVIDERS"`
 	}
 
 	GSMTP struct {
@@ -73,7 +139,11 @@ type (
 
 	Config struct {
 		Server   Server
+		JWT      JWT
+		OTP      OTP
+		Password Password
 		Client   Client
+		WebAuthn WebAuthn
 		Database Database
 		Redis    Redis
 		OAuth    OAuth
@@ -86,6 +156,7 @@ var Cfg Config
 
 func LoadNative() ([]string, error) {
 	var ok bool
+	var err error
 	var missing []string
 
 	if _, err := os.Stat("/app/.env"); err == nil {
@@ -104,6 +175,109 @@ func LoadNative() ([]string, error) {
 	if Cfg.Server.JWTSecretKey, ok = os.LookupEnv("JWT_SECRET_KEY"); !ok {
 		missing = append(missing, "JWT_SECRET_KEY env is not set")
 	}
+	if Cfg.Server.EncryptionKey, ok = os.LookupEnv("ENCRYPTION_KEY"); !ok {
+		missing = append(missing, "ENCRYPTION_KEY env is not set")
+	}
+	if Cfg.Server.OTPSecretKey, ok = os.LookupEnv("OTP_SECRET_KEY"); !ok {
+		missing = append(missing, "OTP_SECRET_KEY env is not set")
+	}
+	if proxies, ok := os.LookupEnv("TRUSTED_PROXIES"); !ok {
+		missing = append(missing, "TRUSTED_PROXIES env is not set")
+	} else if proxies != "" {
+		Cfg.Server.TrustedProxies = strings.Split(proxies, ",")
+	}
+	// ! ______________________________________________________
+
+	// ! Load JWT configuration _______________________________
+	if Cfg.JWT.Algorithm, ok = os.LookupEnv("JWT_ALGORITHM"); !ok {
+		missing = append(missing, "JWT_ALGORITHM env is not set")
+		Cfg.JWT.Algorithm = data.JWT_ALGORITHM
+	}
+	Cfg.JWT.KeyRotationInterval = data.JWT_KEY_ROTATION_INTERVAL
+	if rotationInterval, ok := os.LookupEnv("JWT_KEY_ROTATION_INTERVAL"); !ok {
+		missing = append(missing, "JWT_KEY_ROTATION_INTERVAL env is not set")
+	} else if Cfg.JWT.KeyRotationInterval, err = time.ParseDuration(rotationInterval); err != nil {
+		return nil, err
+	}
+	Cfg.JWT.AccessTokenTTL = data.ACCESS_TOKEN_TTL
+	if accessTTL, ok := os.LookupEnv("ACCESS_TOKEN_TTL"); !ok {
+		missing = append(missing, "ACCESS_TOKEN_TTL env is not set")
+	} else if Cfg.JWT.AccessTokenTTL, err = time.ParseDuration(accessTTL); err != nil {
+		return nil, err
+	}
+	Cfg.JWT.RefreshTokenTTL = data.REFRESH_TOKEN_TTL
+	if refreshTTL, ok := os.LookupEnv("REFRESH_TOKEN_TTL"); !ok {
+		missing = append(missing, "REFRESH_TOKEN_TTL env is not set")
+	} else if Cfg.JWT.RefreshTokenTTL, err = time.ParseDuration(refreshTTL); err != nil {
+		return nil, err
+	}
+	if Cfg.JWT.TokenDelivery, ok = os.LookupEnv("TOKEN_DELIVERY"); !ok {
+		missing = append(missing, "TOKEN_DELIVERY env is not set")
+		Cfg.JWT.TokenDelivery = data.TOKEN_DELIVERY
+	} else if Cfg.JWT.TokenDelivery != data.TOKEN_DELIVERY_BODY && Cfg.JWT.TokenDelivery != data.TOKEN_DELIVERY_COOKIE {
+		return nil, errors.New("TOKEN_DELIVERY must be either body or cookie")
+	}
+	// ! ______________________________________________________
+
+	// ! Load OTP configuration _______________________________
+	Cfg.OTP.Length = data.OTP_LENGTH
+	if otpLength, ok := os.LookupEnv("OTP_LENGTH"); !ok {
+		missing = append(missing, "OTP_LENGTH env is not set")
+	} else if Cfg.OTP.Length, err = strconv.Atoi(otpLength); err != nil {
+		return nil, err
+	}
+	if Cfg.OTP.Length < data.OTP_MIN_LENGTH || Cfg.OTP.Length > data.OTP_MAX_LENGTH {
+		return nil, fmt.Errorf("OTP_LENGTH must be between %d and %d", data.OTP_MIN_LENGTH, data.OTP_MAX_LENGTH)
+	}
+	// ! ______________________________________________________
+
+	// ! Load Password configuration __________________________
+	if Cfg.Password.Hasher, ok = os.LookupEnv("PASSWORD_HASHER"); !ok {
+		missing = append(missing, "PASSWORD_HASHER env is not set")
+		Cfg.Password.Hasher = data.PASSWORD_HASHER
+	}
+	for _, param := range []struct {
+		env   string
+		value *int
+		def   int
+	}{
+		{"PASSWORD_BCRYPT_COST", &Cfg.Password.BcryptCost, data.PASSWORD_BCRYPT_COST},
+		{"PASSWORD_ARGON2_MEMORY", &Cfg.Password.Argon2Memory, data.PASSWORD_ARGON2_MEMORY},
+		{"PASSWORD_ARGON2_ITERATIONS", &Cfg.Password.Argon2Iterations, data.PASSWORD_ARGON2_ITERATIONS},
+		{"PASSWORD_ARGON2_PARALLELISM", &Cfg.Password.Argon2Parallelism, data.PASSWORD_ARGON2_PARALLELISM},
+		{"PASSWORD_MIN_LENGTH", &Cfg.Password.MinLength, data.PASSWORD_MIN_LENGTH},
+		{"PASSWORD_MAX_LENGTH", &Cfg.Password.MaxLength, data.PASSWORD_MAX_LENGTH},
+		{"PASSWORD_MIN_STRENGTH", &Cfg.Password.MinStrength, data.PASSWORD_MIN_STRENGTH},
+		{"PASSWORD_HISTORY_DEPTH", &Cfg.Password.HistoryDepth, data.PASSWORD_HISTORY_DEPTH},
+	} {
+		*param.value = param.def
+		if value, ok := os.LookupEnv(param.env); !ok {
+			missing = append(missing, param.env+" env is not set")
+		} else if *param.value, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", param.env, err)
+		}
+	}
+	for _, param := range []struct {
+		env   string
+		value *bool
+		def   bool
+	}{
+		{"PASSWORD_REQUIRE_LETTER", &Cfg.Password.RequireLetter, data.PASSWORD_REQUIRE_LETTER},
+		{"PASSWORD_REQUIRE_DIGIT", &Cfg.Password.RequireDigit, data.PASSWORD_REQUIRE_DIGIT},
+		{"PASSWORD_REQUIRE_MIXED_CASE", &Cfg.Password.RequireMixedCase, data.PASSWORD_REQUIRE_MIXED_CASE},
+		{"PASSWORD_REQUIRE_SYMBOL", &Cfg.Password.RequireSymbol, data.PASSWORD_REQUIRE_SYMBOL},
+		{"PASSWORD_BAN_PERSONAL_INFO", &Cfg.Password.BanPersonalInfo, data.PASSWORD_BAN_PERSONAL_INFO},
+		{"PASSWORD_BREACHED_CHECK", &Cfg.Password.BreachedCheck, data.PASSWORD_BREACHED_CHECK},
+	} {
+		*param.value = param.def
+		if value, ok := os.LookupEnv(param.env); !ok {
+			missing = append(missing, param.env+" env is not set")
+		} else if *param.value, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("%s: %w", param.env, err)
+		}
+	}
+	// KOSONG BERARTI MEMAKAI BLOOM FILTER BAWAAN YANG DI-EMBED KE BINARY
+	Cfg.Password.BreachedFilter = os.Getenv("PASSWORD_BREACHED_FILTER")
 	// ! ______________________________________________________
 
 	// ! Load Client configuration ____________________________
@@ -115,6 +289,23 @@ func LoadNative() ([]string, error) {
 	}
 	// ! ______________________________________________________
 
+	// ! Load WebAuthn configuration __________________________
+	if Cfg.WebAuthn.RPID, ok = os.LookupEnv("WEBAUTHN_RP_ID"); !ok {
+		missing = append(missing, "WEBAUTHN_RP_ID env is not set")
+		Cfg.WebAuthn.RPID = data.WEBAUTHN_RP_ID
+	}
+	if Cfg.WebAuthn.RPDisplayName, ok = os.LookupEnv("WEBAUTHN_RP_NAME"); !ok {
+		missing = append(missing, "WEBAUTHN_RP_NAME env is not set")
+		Cfg.WebAuthn.RPDisplayName = data.WEBAUTHN_RP_NAME
+	}
+	if origins, ok := os.LookupEnv("WEBAUTHN_RP_ORIGINS"); !ok {
+		missing = append(missing, "WEBAUTHN_RP_ORIGINS env is not set")
+		Cfg.WebAuthn.RPOrigins = []string{Cfg.Client.Url}
+	} else {
+		Cfg.WebAuthn.RPOrigins = strings.Split(origins, ",")
+	}
+	// ! ______________________________________________________
+
 	// ! Load Database configuration __________________________
 	if Cfg.Database.DBUser, ok = os.LookupEnv("DB_USER"); !ok {
 		missing = append(missing, "DB_USER env is not set")
@@ -175,6 +366,42 @@ func LoadNative() ([]string, error) {
 	}
 	// ! ______________________________________________________
 
+	// ! Load generic OAuth providers configuration ___________
+	Cfg.OAuth.Providers = nil
+	if providers, ok := os.LookupEnv("OAUTH_PROVIDERS"); ok {
+		for _, name := range strings.Split(providers, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+
+			prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+			provider := OAuthProvider{
+				Name:               strings.ToLower(name),
+				Type:               os.Getenv(prefix + "TYPE"),
+				ClientID:           os.Getenv(prefix + "CLIENT_ID"),
+				ClientSecret:       os.Getenv(prefix + "CLIENT_SECRET"),
+				Issuer:             os.Getenv(prefix + "ISSUER"),
+				IssuerFormat:       os.Getenv(prefix + "ISSUER_FORMAT"),
+				AuthURL:            os.Getenv(prefix + "AUTH_URL"),
+				TokenURL:           os.Getenv(prefix + "TOKEN_URL"),
+				UserInfoURL:        os.Getenv(prefix + "USERINFO_URL"),
+				ClaimID:            os.Getenv(prefix + "CLAIM_ID"),
+				ClaimEmail:         os.Getenv(prefix + "CLAIM_EMAIL"),
+				ClaimEmailVerified: os.Getenv(prefix + "CLAIM_EMAIL_VERIFIED"),
+				ClaimName:          os.Getenv(prefix + "CLAIM_NAME"),
+			}
+			if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+				provider.Scopes = strings.Split(scopes, ",")
+			}
+			if provider.ClientID == "" {
+				missing = append(missing, prefix+"CLIENT_ID env is not set")
+			}
+
+			Cfg.OAuth.Providers = append(Cfg.OAuth.Providers, provider)
+		}
+	}
+	// ! ______________________________________________________
+
 	// ! Load Gmail SMTP configuration ______________________________
 	if Cfg.GSMTP.GSHost, ok = os.LookupEnv("GOOGLE_SMTP_HOST"); !ok {
 		missing = append(missing, "GOOGLE_SMTP_HOST env is not set")
@@ -240,6 +467,98 @@ func LoadByViper() ([]string, error) {
 	if Cfg.Server.JWTSecretKey = config.GetString("JWT_SECRET_KEY"); Cfg.Server.JWTSecretKey == "" {
 		missing = append(missing, "JWT_SECRET_KEY env is not set")
 	}
+	if Cfg.Server.EncryptionKey = config.GetString("ENCRYPTION_KEY"); Cfg.Server.EncryptionKey == "" {
+		missing = append(missing, "ENCRYPTION_KEY env is not set")
+	}
+	if Cfg.Server.OTPSecretKey = config.GetString("OTP_SECRET_KEY"); Cfg.Server.OTPSecretKey == "" {
+		missing = append(missing, "OTP_SECRET_KEY env is not set")
+	}
+	if Cfg.Server.TrustedProxies = config.GetStringSlice("TRUSTED_PROXIES"); !config.IsSet("TRUSTED_PROXIES") {
+		missing = append(missing, "TRUSTED_PROXIES env is not set")
+	}
+	// ! ______________________________________________________
+
+	// ! Load JWT configuration _______________________________
+	if Cfg.JWT.Algorithm = config.GetString("JWT.ALGORITHM"); Cfg.JWT.Algorithm == "" {
+		missing = append(missing, "JWT.ALGORITHM env is not set")
+		Cfg.JWT.Algorithm = data.JWT_ALGORITHM
+	}
+	if Cfg.JWT.KeyRotationInterval = config.GetDuration("JWT.KEY_ROTATION_INTERVAL"); Cfg.JWT.KeyRotationInterval == 0 {
+		missing = append(missing, "JWT.KEY_ROTATION_INTERVAL env is not set")
+		Cfg.JWT.KeyRotationInterval = data.JWT_KEY_ROTATION_INTERVAL
+	}
+	if Cfg.JWT.AccessTokenTTL = config.GetDuration("JWT.ACCESS_TOKEN_TTL"); Cfg.JWT.AccessTokenTTL == 0 {
+		missing = append(missing, "JWT.ACCESS_TOKEN_TTL env is not set")
+		Cfg.JWT.AccessTokenTTL = data.ACCESS_TOKEN_TTL
+	}
+	if Cfg.JWT.RefreshTokenTTL = config.GetDuration("JWT.REFRESH_TOKEN_TTL"); Cfg.JWT.RefreshTokenTTL == 0 {
+		missing = append(missing, "JWT.REFRESH_TOKEN_TTL env is not set")
+		Cfg.JWT.RefreshTokenTTL = data.REFRESH_TOKEN_TTL
+	}
+	if Cfg.JWT.TokenDelivery = config.GetString("JWT.TOKEN_DELIVERY"); Cfg.JWT.TokenDelivery == "" {
+		missing = append(missing, "JWT.TOKEN_DELIVERY env is not set")
+		Cfg.JWT.TokenDelivery = data.TOKEN_DELIVERY
+	} else if Cfg.JWT.TokenDelivery != data.TOKEN_DELIVERY_BODY && Cfg.JWT.TokenDelivery != data.TOKEN_DELIVERY_COOKIE {
+		return nil, errors.New("JWT.TOKEN_DELIVERY must be either body or cookie")
+	}
+
+	// ! Load OTP configuration _______________________________
+	if Cfg.OTP.Length = config.GetInt("OTP.LENGTH"); Cfg.OTP.Length == 0 {
+		missing = append(missing, "OTP.LENGTH env is not set")
+		Cfg.OTP.Length = data.OTP_LENGTH
+	}
+	if Cfg.OTP.Length < data.OTP_MIN_LENGTH || Cfg.OTP.Length > data.OTP_MAX_LENGTH {
+		return nil, fmt.Errorf("OTP.LENGTH must be between %d and %d", data.OTP_MIN_LENGTH, data.OTP_MAX_LENGTH)
+	}
+	// ! ______________________________________________________
+
+	// ! Load Password configuration __________________________
+	if Cfg.Password.Hasher = config.GetString("PASSWORD.HASHER"); Cfg.Password.Hasher == "" {
+		missing = append(missing, "PASSWORD.HASHER env is not set")
+		Cfg.Password.Hasher = data.PASSWORD_HASHER
+	}
+	for _, param := range []struct {
+		key   string
+		value *int
+		def   int
+	}{
+		{"PASSWORD.BCRYPT_COST", &Cfg.Password.BcryptCost, data.PASSWORD_BCRYPT_COST},
+		{"PASSWORD.ARGON2_MEMORY", &Cfg.Password.Argon2Memory, data.PASSWORD_ARGON2_MEMORY},
+		{"PASSWORD.ARGON2_ITERATIONS", &Cfg.Password.Argon2Iterations, data.PASSWORD_ARGON2_ITERATIONS},
+		{"PASSWORD.ARGON2_PARALLELISM", &Cfg.Password.Argon2Parallelism, data.PASSWORD_ARGON2_PARALLELISM},
+		{"PASSWORD.MIN_LENGTH", &Cfg.Password.MinLength, data.PASSWORD_MIN_LENGTH},
+		{"PASSWORD.MAX_LENGTH", &Cfg.Password.MaxLength, data.PASSWORD_MAX_LENGTH},
+		{"PASSWORD.MIN_STRENGTH", &Cfg.Password.MinStrength, data.PASSWORD_MIN_STRENGTH},
+		{"PASSWORD.HISTORY_DEPTH", &Cfg.Password.HistoryDepth, data.PASSWORD_HISTORY_DEPTH},
+	} {
+		*param.value = param.def
+		if !config.IsSet(param.key) {
+			missing = append(missing, param.key+" env is not set")
+		} else {
+			*param.value = config.GetInt(param.key)
+		}
+	}
+	for _, param := range []struct {
+		key   string
+		value *bool
+		def   bool
+	}{
+		{"PASSWORD.REQUIRE_LETTER", &Cfg.Password.RequireLetter, data.PASSWORD_REQUIRE_LETTER},
+		{"PASSWORD.REQUIRE_DIGIT", &Cfg.Password.RequireDigit, data.PASSWORD_REQUIRE_DIGIT},
+		{"PASSWORD.REQUIRE_MIXED_CASE", &Cfg.Password.RequireMixedCase, data.PASSWORD_REQUIRE_MIXED_CASE},
+		{"PASSWORD.REQUIRE_SYMBOL", &Cfg.Password.RequireSymbol, data.PASSWORD_REQUIRE_SYMBOL},
+		{"PASSWORD.BAN_PERSONAL_INFO", &Cfg.Password.BanPersonalInfo, data.PASSWORD_BAN_PERSONAL_INFO},
+		{"PASSWORD.BREACHED_CHECK", &Cfg.Password.BreachedCheck, data.PASSWORD_BREACHED_CHECK},
+	} {
+		*param.value = param.def
+		if !config.IsSet(param.key) {
+			missing = append(missing, param.key+" env is not set")
+		} else {
+			*param.value = config.GetBool(param.key)
+		}
+	}
+	// KOSONG BERARTI MEMAKAI BLOOM FILTER BAWAAN YANG DI-EMBED KE BINARY
+	Cfg.Password.BreachedFilter = config.GetString("PASSWORD.BREACHED_FILTER")
 	// ! ______________________________________________________
 
 	// ! Load Client configuration ____________________________
@@ -251,6 +570,21 @@ func LoadByViper() ([]string, error) {
 	}
 	// ! ______________________________________________________
 
+	// ! Load WebAuthn configuration __________________________
+	if Cfg.WebAuthn.RPID = config.GetString("WEBAUTHN.RP_ID"); Cfg.WebAuthn.RPID == "" {
+		missing = append(missing, "WEBAUTHN.RP_ID env is not set")
+		Cfg.WebAuthn.RPID = data.WEBAUTHN_RP_ID
+	}
+	if Cfg.WebAuthn.RPDisplayName = config.GetString("WEBAUTHN.RP_NAME"); Cfg.WebAuthn.RPDisplayName == "" {
+		missing = append(missing, "WEBAUTHN.RP_NAME env is not set")
+		Cfg.WebAuthn.RPDisplayName = data.WEBAUTHN_RP_NAME
+	}
+	if Cfg.WebAuthn.RPOrigins = config.GetStringSlice("WEBAUTHN.RP_ORIGINS"); len(Cfg.WebAuthn.RPOrigins) == 0 {
+		missing = append(missing, "WEBAUTHN.RP_ORIGINS env is not set")
+		Cfg.WebAuthn.RPOrigins = []string{Cfg.Client.Url}
+	}
+	// ! ______________________________________________________
+
 	// ! Load Database configuration __________________________
 	if Cfg.Database.DBUser = config.GetString("DATABASE.POSTGRESQL.USER"); Cfg.Database.DBUser == "" {
 		missing = append(missing, "DATABASE.POSTGRESQL.USER env is not set")
@@ -311,6 +645,34 @@ func LoadByViper() ([]string, error) {
 	}
 	// ! ______________________________________________________
 
+	// ! Load generic OAuth providers configuration ___________
+	Cfg.OAuth.Providers = nil
+	for name := range config.GetStringMap("OAUTH.PROVIDERS") {
+		prefix := "OAUTH.PROVIDERS." + name + "."
+		provider := OAuthProvider{
+			Name:               strings.ToLower(name),
+			Type:               config.GetString(prefix + "TYPE"),
+			ClientID:           config.GetString(prefix + "CLIENT_ID"),
+			ClientSecret:       config.GetString(prefix + "CLIENT_SECRET"),
+			Issuer:             config.GetString(prefix + "ISSUER"),
+			IssuerFormat:       config.GetString(prefix + "ISSUER_FORMAT"),
+			AuthURL:            config.GetString(prefix + "AUTH_URL"),
+			TokenURL:           config.GetString(prefix + "TOKEN_URL"),
+			UserInfoURL:        config.GetString(prefix + "USERINFO_URL"),
+			Scopes:             config.GetStringSlice(prefix + "SCOPES"),
+			ClaimID:            config.GetString(prefix + "CLAIM_ID"),
+			ClaimEmail:         config.GetString(prefix + "CLAIM_EMAIL"),
+			ClaimEmailVerified: config.GetString(prefix + "CLAIM_EMAIL_VERIFIED"),
+			ClaimName:          config.GetString(prefix + "CLAIM_NAME"),
+		}
+		if provider.ClientID == "" {
+			missing = append(missing, prefix+"CLIENT_ID env is not set")
+		}
+
+		Cfg.OAuth.Providers = append(Cfg.OAuth.Providers, provider)
+	}
+	// ! ______________________________________________________
+
 	// ! Load Gmail SMTP configuration ______________________________
 	if Cfg.GSMTP.GSHost = config.GetString("SMTP.GOOGLE.HOST"); Cfg.GSMTP.GSHost == "" {
 		missing = append(missing, "SMTP.GOOGLE.HOST env is not set")
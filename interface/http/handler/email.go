@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type emailChangeHandler struct {
+	emailChangeService service.EmailChangeService
+}
+
+func NewEmailChangeHandler(emailChangeService service.EmailChangeService) *emailChangeHandler {
+	return &emailChangeHandler{
+		emailChangeService: emailChangeService,
+	}
+}
+
+func (email_handler *emailChangeHandler) RequestEmailChange(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var changeRequest dto.ChangeEmailRequest
+	if err := c.ShouldBindBodyWithJSON(&changeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := email_handler.emailChangeService.RequestEmailChange(claims.UserID, changeRequest.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "A confirmation link has been sent to the new email address",
+	})
+}
+
+func (email_handler *emailChangeHandler) ConfirmEmailChange(c *gin.Context) {
+	var tokenRequest dto.EmailTokenRequest
+	if err := c.ShouldBindBodyWithJSON(&tokenRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	user, err := email_handler.emailChangeService.ConfirmEmailChange(tokenRequest.Token)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Email has been changed",
+		"data":       user,
+	})
+}
+
+func (email_handler *emailChangeHandler) UndoEmailChange(c *gin.Context) {
+	var tokenRequest dto.EmailTokenRequest
+	if err := c.ShouldBindBodyWithJSON(&tokenRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := email_handler.emailChangeService.UndoEmailChange(tokenRequest.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	clearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Email change has been reverted and all sessions have been signed out, please reset your password",
+	})
+}
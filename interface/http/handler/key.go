@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/utils/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+type keyHandler struct {
+	keyService service.KeyService
+}
+
+func NewKeyHandler(keyService service.KeyService) *keyHandler {
+	return &keyHandler{
+		keyService: keyService,
+	}
+}
+
+// GetJWKS - mengembalikan public key dalam format JWK Set standar (RFC 7517) agar bisa dipakai service lain
+func (key_handler *keyHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(data.JWKS_CACHE_MAX_AGE.Seconds())))
+	c.JSON(http.StatusOK, key_handler.keyService.GetJWKS())
+}
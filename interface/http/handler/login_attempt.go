@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginAttemptHandler struct {
+	loginAttemptService service.LoginAttemptService
+}
+
+func NewLoginAttemptHandler(loginAttemptService service.LoginAttemptService) *loginAttemptHandler {
+	return &loginAttemptHandler{
+		loginAttemptService: loginAttemptService,
+	}
+}
+
+// UnlockAccount - membuka lockout akun lewat link yang dikirim ke email
+func (attempt_handler *loginAttemptHandler) UnlockAccount(c *gin.Context) {
+	var tokenRequest dto.EmailTokenRequest
+	if err := c.ShouldBindBodyWithJSON(&tokenRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := attempt_handler.loginAttemptService.UnlockAccount(tokenRequest.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Account unlocked, you can sign in again",
+	})
+}
+
+// AdminUnlockAccount - admin membuka lockout akun user pada parameter :id
+func (attempt_handler *loginAttemptHandler) AdminUnlockAccount(c *gin.Context) {
+	if err := attempt_handler.loginAttemptService.AdminUnlockAccount(c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Unlock user account",
+	})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginHistoryHandler struct {
+	loginHistoryService service.LoginHistoryService
+}
+
+func NewLoginHistoryHandler(loginHistoryService service.LoginHistoryService) *loginHistoryHandler {
+	return &loginHistoryHandler{
+		loginHistoryService: loginHistoryService,
+	}
+}
+
+// GetLoginHistory - percobaan login terakhir milik user, berhasil maupun gagal
+func (login_history_handler *loginHistoryHandler) GetLoginHistory(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	histories, err := login_history_handler.loginHistoryService.GetLoginHistory(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Get login history",
+		"data":       histories,
+	})
+}
+
+// ReportLogin - link "this wasn't me" dari email login perangkat baru
+func (login_history_handler *loginHistoryHandler) ReportLogin(c *gin.Context) {
+	var tokenRequest dto.EmailTokenRequest
+	if err := c.ShouldBindBodyWithJSON(&tokenRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := login_history_handler.loginHistoryService.ReportLogin(tokenRequest.Token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "All sessions have been signed out, please check your email to reset your password",
+	})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	dataconst "refina-auth/internal/utils/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+type magicLinkHandler struct {
+	magicLinkService service.MagicLinkService
+}
+
+func NewMagicLinkHandler(magicLinkService service.MagicLinkService) *magicLinkHandler {
+	return &magicLinkHandler{
+		magicLinkService: magicLinkService,
+	}
+}
+
+func (magic_link_handler *magicLinkHandler) RequestMagicLink(c *gin.Context) {
+	var magicLinkRequest dto.MagicLinkRequest
+	if err := c.ShouldBindBodyWithJSON(&magicLinkRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	binding, err := magic_link_handler.magicLinkService.RequestMagicLink(magicLinkRequest.Email, clientInfo(c))
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrMagicLinkCooldown) || errors.Is(err, service.ErrMagicLinkDailyLimit) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	// Link hanya bisa dipakai di browser yang memiliki cookie ini
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(dataconst.MAGIC_LINK_BINDING_COOKIE, binding, int(dataconst.MAGIC_LINK_TTL.Seconds()), "/", "", env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE, true)
+
+	// Response selalu sama agar tidak membocorkan apakah email terdaftar
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "If an account with that email exists, a sign in link has been sent",
+	})
+}
+
+func (magic_link_handler *magicLinkHandler) VerifyMagicLink(c *gin.Context) {
+	var tokenRequest dto.EmailTokenRequest
+	if err := c.ShouldBindBodyWithJSON(&tokenRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	binding, _ := c.Cookie(dataconst.MAGIC_LINK_BINDING_COOKIE)
+
+	login, err := magic_link_handler.magicLinkService.VerifyMagicLink(tokenRequest.Token, binding, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"statusCode": 401,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.SetCookie(dataconst.MAGIC_LINK_BINDING_COOKIE, "", -1, "/", "", env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE, true)
+
+	respondWithLogin(c, "Login with magic link", login)
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type passkeyHandler struct {
+	passkeyService service.PasskeyService
+}
+
+func NewPasskeyHandler(passkeyService service.PasskeyService) *passkeyHandler {
+	return &passkeyHandler{
+		passkeyService: passkeyService,
+	}
+}
+
+// SendStepUpOTP - mengirim OTP ke email user untuk konfirmasi identitas sebelum menambah passkey
+func (passkey_handler *passkeyHandler) SendStepUpOTP(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := passkey_handler.passkeyService.SendStepUpOTP(claims.UserID); err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrOTPResendCooldown) || errors.Is(err, service.ErrOTPDailyLimit) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Verification code sent to your email",
+	})
+}
+
+// BeginRegistration - mengembalikan options untuk navigator.credentials.create() setelah identitas user dikonfirmasi
+// dengan kode TOTP, password saat ini, atau OTP email
+func (passkey_handler *passkeyHandler) BeginRegistration(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var stepUpRequest dto.PasskeyStepUpRequest
+	if err := c.ShouldBindBodyWithJSON(&stepUpRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	options, err := passkey_handler.passkeyService.BeginRegistration(claims.UserID, claims.SessionID, stepUpRequest, clientInfo(c))
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrTwoFactorLocked) || errors.Is(err, service.ErrLoginThrottled) || errors.Is(err, service.ErrAccountLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Passkey registration options",
+		"data":       options,
+	})
+}
+
+func (passkey_handler *passkeyHandler) FinishRegistration(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var registerRequest dto.PasskeyRegisterRequest
+	if err := c.ShouldBindBodyWithJSON(&registerRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	passkey, err := passkey_handler.passkeyService.FinishRegistration(claims.UserID, claims.SessionID, registerRequest, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"statusCode": 201,
+		"status":     true,
+		"message":    "Passkey registered",
+		"data":       passkey,
+	})
+}
+
+// BeginLogin - mengembalikan options untuk navigator.credentials.get() beserta session id
+func (passkey_handler *passkeyHandler) BeginLogin(c *gin.Context) {
+	options, err := passkey_handler.passkeyService.BeginLogin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Passkey login options",
+		"data":       options,
+	})
+}
+
+func (passkey_handler *passkeyHandler) FinishLogin(c *gin.Context) {
+	var loginRequest dto.PasskeyLoginRequest
+	if err := c.ShouldBindBodyWithJSON(&loginRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	token, err := passkey_handler.passkeyService.FinishLogin(loginRequest, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"statusCode": 401,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	respondWithTokens(c, "Login with passkey", token)
+}
+
+func (passkey_handler *passkeyHandler) GetPasskeys(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	passkeys, err := passkey_handler.passkeyService.GetPasskeys(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Get passkeys",
+		"data":       passkeys,
+	})
+}
+
+func (passkey_handler *passkeyHandler) RenamePasskey(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var renameRequest dto.RenamePasskeyRequest
+	if err := c.ShouldBindBodyWithJSON(&renameRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	passkey, err := passkey_handler.passkeyService.RenamePasskey(claims.UserID, c.Param("id"), renameRequest.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Rename passkey",
+		"data":       passkey,
+	})
+}
+
+func (passkey_handler *passkeyHandler) DeletePasskey(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := passkey_handler.passkeyService.DeletePasskey(claims.UserID, c.Param("id")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Delete passkey",
+	})
+}
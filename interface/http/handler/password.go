@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/utils/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+type passwordHandler struct {
+	passwordService service.PasswordService
+}
+
+func NewPasswordHandler(passwordService service.PasswordService) *passwordHandler {
+	return &passwordHandler{
+		passwordService: passwordService,
+	}
+}
+
+func (password_handler *passwordHandler) ForgotPassword(c *gin.Context) {
+	var forgotRequest dto.ForgotPasswordRequest
+	if err := c.ShouldBindBodyWithJSON(&forgotRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := password_handler.passwordService.ForgotPassword(forgotRequest.Email); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	// Response selalu sama agar tidak membocorkan apakah email terdaftar
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "If an account with that email exists, a password reset link has been sent",
+	})
+}
+
+func (password_handler *passwordHandler) ResetPassword(c *gin.Context) {
+	var resetRequest dto.ResetPasswordRequest
+	if err := c.ShouldBindBodyWithJSON(&resetRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := password_handler.passwordService.ResetPassword(resetRequest.Token, resetRequest.Password); err != nil {
+		respondWithPasswordError(c, err)
+		return
+	}
+
+	clearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Password has been reset, please sign in again",
+	})
+}
+
+func (password_handler *passwordHandler) ChangePassword(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var changeRequest dto.ChangePasswordRequest
+	if err := c.ShouldBindBodyWithJSON(&changeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	token, err := password_handler.passwordService.ChangePassword(claims.UserID, changeRequest.CurrentPassword, changeRequest.NewPassword, clientInfo(c))
+	if err != nil {
+		respondWithPasswordError(c, err)
+		return
+	}
+
+	respondWithTokens(c, "Password has been changed", token)
+}
+
+// respondWithPasswordError - pelanggaran password policy dikembalikan lengkap dengan code agar client bisa menampilkan semuanya
+func respondWithPasswordError(c *gin.Context, err error) {
+	var violationErr *policy.ViolationError
+	if errors.As(err, &violationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    "Password does not meet the requirements",
+			"errors":     violationErr.Violations,
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"statusCode": 400,
+		"status":     false,
+		"message":    err.Error(),
+	})
+}
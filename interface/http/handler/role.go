@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type roleHandler struct {
+	roleService service.RoleService
+}
+
+func NewRoleHandler(roleService service.RoleService) *roleHandler {
+	return &roleHandler{
+		roleService: roleService,
+	}
+}
+
+func (role_handler *roleHandler) GetAllRoles(c *gin.Context) {
+	roles, err := role_handler.roleService.GetAllRoles()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Get all roles data",
+		"data":       roles,
+	})
+}
+
+func (role_handler *roleHandler) AssignRole(c *gin.Context) {
+	var roleRequest dto.UpdateRoleRequest
+	if err := c.ShouldBindBodyWithJSON(&roleRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	user, err := role_handler.roleService.AssignRole(c.Param("id"), roleRequest.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Update user role",
+		"data":       user,
+	})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionHandler struct {
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(sessionService service.SessionService) *sessionHandler {
+	return &sessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// GetSessions - daftar perangkat tempat user sedang login
+func (session_handler *sessionHandler) GetSessions(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	sessions, err := session_handler.sessionService.GetSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Get active sessions",
+		"data":       sessions,
+	})
+}
+
+func (session_handler *sessionHandler) RevokeSession(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := session_handler.sessionService.RevokeSession(claims.UserID, c.Param("id"), claims.SessionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Session revoked",
+	})
+}
+
+// RevokeOtherSessions - logout dari seluruh perangkat lain, session saat ini tetap berlaku
+func (session_handler *sessionHandler) RevokeOtherSessions(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := session_handler.sessionService.RevokeOtherSessions(claims.UserID, claims.SessionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Signed out of all other sessions",
+	})
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"net/http"
+
+	"refina-auth/config/env"
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	dataconst "refina-auth/internal/utils/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tokenHandler struct {
+	tokenService service.TokenService
+}
+
+func NewTokenHandler(tokenService service.TokenService) *tokenHandler {
+	return &tokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+func (token_handler *tokenHandler) RefreshToken(c *gin.Context) {
+	var refreshRequest dto.RefreshTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindBodyWithJSON(&refreshRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": 400,
+				"status":     false,
+				"message":    err.Error(),
+			})
+			return
+		}
+	}
+
+	// Fallback ke cookie refresh_token jika TOKEN_DELIVERY=cookie
+	if refreshRequest.RefreshToken == "" {
+		refreshRequest.RefreshToken, _ = c.Cookie(dataconst.REFRESH_TOKEN_COOKIE)
+	}
+
+	token, err := token_handler.tokenService.RefreshTokens(refreshRequest.RefreshToken, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"statusCode": 401,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	respondWithTokens(c, "Refresh token", token)
+}
+
+func (token_handler *tokenHandler) Logout(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var logoutRequest dto.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindBodyWithJSON(&logoutRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"statusCode": 400,
+				"status":     false,
+				"message":    err.Error(),
+			})
+			return
+		}
+	}
+
+	if logoutRequest.RefreshToken == "" {
+		logoutRequest.RefreshToken, _ = c.Cookie(dataconst.REFRESH_TOKEN_COOKIE)
+	}
+
+	if err := token_handler.tokenService.Logout(claims, logoutRequest.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	clearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Logout user",
+	})
+}
+
+func (token_handler *tokenHandler) LogoutAll(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	if err := token_handler.tokenService.LogoutAll(claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"statusCode": 500,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	clearTokenCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Logout user from all devices",
+	})
+}
+
+// respondWithTokens - mengirim token sesuai TOKEN_DELIVERY. Mode body mengembalikan token di response JSON,
+// mode cookie menyimpan token di cookie HttpOnly sehingga tidak bisa dibaca oleh JavaScript.
+func respondWithTokens(c *gin.Context, message string, token *dto.TokenResponse) {
+	var data interface{} = token
+	if env.Cfg.JWT.TokenDelivery == dataconst.TOKEN_DELIVERY_COOKIE {
+		setTokenCookies(c, token)
+		data = gin.H{
+			"token_type": token.TokenType,
+			"expires_in": token.ExpiresIn,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    message,
+		"data":       data,
+	})
+}
+
+// respondWithLogin - mengirim token jika login selesai, atau challenge 2FA jika user masih harus memasukkan kode TOTP
+func respondWithLogin(c *gin.Context, message string, login *dto.LoginResponse) {
+	if login.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"statusCode": 200,
+			"status":     true,
+			"message":    "Two-factor authentication required",
+			"data":       login.Challenge,
+		})
+		return
+	}
+
+	respondWithTokens(c, message, login.Token)
+}
+
+func setTokenCookies(c *gin.Context, token *dto.TokenResponse) {
+	secure := env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(dataconst.ACCESS_TOKEN_COOKIE, token.AccessToken, int(env.Cfg.JWT.AccessTokenTTL.Seconds()), "/", "", secure, true)
+	c.SetCookie(dataconst.REFRESH_TOKEN_COOKIE, token.RefreshToken, int(env.Cfg.JWT.RefreshTokenTTL.Seconds()), "/", "", secure, true)
+}
+
+func clearTokenCookies(c *gin.Context) {
+	secure := env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(dataconst.ACCESS_TOKEN_COOKIE, "", -1, "/", "", secure, true)
+	c.SetCookie(dataconst.REFRESH_TOKEN_COOKIE, "", -1, "/", "", secure, true)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	dataconst "refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var testToken = &dto.TokenResponse{
+	AccessToken:  "access-token",
+	RefreshToken: "refresh-token",
+	TokenType:    "Bearer",
+	ExpiresIn:    900,
+}
+
+func TestRespondWithTokensBodyDelivery(t *testing.T) {
+	testutil.Config(t)
+	env.Cfg.JWT.TokenDelivery = dataconst.TOKEN_DELIVERY_BODY
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	respondWithTokens(c, "Login user data", testToken)
+
+	if !strings.Contains(recorder.Body.String(), `"access_token":"access-token"`) {
+		t.Fatalf("body delivery did not return the token: %s", recorder.Body.String())
+	}
+	if cookies := recorder.Result().Cookies(); len(cookies) != 0 {
+		t.Fatalf("body delivery set cookies: %v", cookies)
+	}
+}
+
+func TestRespondWithTokensCookieDelivery(t *testing.T) {
+	testutil.Config(t)
+	env.Cfg.JWT.TokenDelivery = dataconst.TOKEN_DELIVERY_COOKIE
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	respondWithTokens(c, "Login user data", testToken)
+
+	// TOKEN HANYA DIKIRIM LEWAT COOKIE HTTPONLY, TIDAK DI BODY
+	if strings.Contains(recorder.Body.String(), "access-token") || strings.Contains(recorder.Body.String(), "refresh-token") {
+		t.Fatalf("cookie delivery leaked the token in the body: %s", recorder.Body.String())
+	}
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range recorder.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	for name, value := range map[string]string{
+		dataconst.ACCESS_TOKEN_COOKIE:  "access-token",
+		dataconst.REFRESH_TOKEN_COOKIE: "refresh-token",
+	} {
+		cookie, ok := cookies[name]
+		if !ok || cookie.Value != value {
+			t.Fatalf("cookie %s = %v, want %q", name, cookie, value)
+		}
+		if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("cookie %s is not HttpOnly with SameSite=Lax", name)
+		}
+	}
+}
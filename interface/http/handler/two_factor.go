@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"refina-auth/interface/http/middleware"
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+type twoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService) *twoFactorHandler {
+	return &twoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enroll - membuat secret TOTP baru dan mengembalikan URI otpauth:// beserta QR code untuk di-scan authenticator app
+func (two_factor_handler *twoFactorHandler) Enroll(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	enrollment, err := two_factor_handler.twoFactorService.Enroll(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Scan the QR code with your authenticator app, then confirm with the generated code",
+		"data":       enrollment,
+	})
+}
+
+func (two_factor_handler *twoFactorHandler) Confirm(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var codeRequest dto.TwoFactorCodeRequest
+	if err := c.ShouldBindBodyWithJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	recoveryCodes, err := two_factor_handler.twoFactorService.Confirm(claims.UserID, claims.SessionID, codeRequest.Code)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrTwoFactorLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	// Recovery code hanya ditampilkan sekali, user harus menyimpannya sekarang
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Two-factor authentication enabled, store these recovery codes in a safe place",
+		"data":       dto.RecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+	})
+}
+
+func (two_factor_handler *twoFactorHandler) Disable(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var codeRequest dto.TwoFactorCodeRequest
+	if err := c.ShouldBindBodyWithJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	if err := two_factor_handler.twoFactorService.Disable(claims.UserID, claims.SessionID, codeRequest.Code); err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrTwoFactorLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Two-factor authentication disabled",
+	})
+}
+
+func (two_factor_handler *twoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	var codeRequest dto.TwoFactorCodeRequest
+	if err := c.ShouldBindBodyWithJSON(&codeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	recoveryCodes, err := two_factor_handler.twoFactorService.RegenerateRecoveryCodes(claims.UserID, codeRequest.Code)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrTwoFactorLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Recovery codes regenerated, previous codes are no longer valid",
+		"data":       dto.RecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+	})
+}
+
+// Verify - menyelesaikan login yang membutuhkan 2FA dengan challenge token dan kode TOTP
+func (two_factor_handler *twoFactorHandler) Verify(c *gin.Context) {
+	var verifyRequest dto.TwoFactorVerifyRequest
+	if err := c.ShouldBindBodyWithJSON(&verifyRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	token, err := two_factor_handler.twoFactorService.VerifyChallenge(verifyRequest.ChallengeToken, verifyRequest.Code, clientInfo(c))
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, service.ErrTwoFactorLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	respondWithTokens(c, "Two-factor authentication verified", token)
+}
+
+// Recovery - menyelesaikan login yang membutuhkan 2FA dengan recovery code sekali pakai
+func (two_factor_handler *twoFactorHandler) Recovery(c *gin.Context) {
+	var recoveryRequest dto.TwoFactorRecoveryRequest
+	if err := c.ShouldBindBodyWithJSON(&recoveryRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	token, err := two_factor_handler.twoFactorService.VerifyRecoveryCode(recoveryRequest.ChallengeToken, recoveryRequest.RecoveryCode, clientInfo(c))
+	if err != nil {
+		statusCode := http.StatusUnauthorized
+		if errors.Is(err, service.ErrTwoFactorLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
+	}
+
+	respondWithTokens(c, "Signed in with recovery code", token)
+}
@@ -1,33 +1,33 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
-	"time"
-
-	"golang.org/x/oauth2"
+	"net/url"
 
 	"refina-auth/config/env"
+	"refina-auth/interface/http/middleware"
 	"refina-auth/internal/service"
 	"refina-auth/internal/types/dto"
-	helper "refina-auth/internal/utils"
+	"refina-auth/internal/types/model"
 	dataconst "refina-auth/internal/utils/data"
 
 	"github.com/gin-gonic/gin"
 )
 
 type usersHandler struct {
-	usersService service.UsersService
-	otpService   service.OTPService
+	usersService    service.UsersService
+	otpService      service.OTPService
+	identityService service.IdentityService
+	oauthService    service.OAuthService
 }
 
-func NewUsersHandler(usersService service.UsersService, otpService service.OTPService) *usersHandler {
+func NewUsersHandler(usersService service.UsersService, otpService service.OTPService, identityService service.IdentityService, oauthService service.OAuthService) *usersHandler {
 	return &usersHandler{
-		usersService: usersService,
-		otpService:   otpService,
+		usersService:    usersService,
+		otpService:      otpService,
+		identityService: identityService,
+		oauthService:    oauthService,
 	}
 }
 
@@ -45,11 +45,7 @@ func (user_handler *usersHandler) Register(c *gin.Context) {
 
 	user, err := user_handler.usersService.Register(userRequest)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"statusCode": 400,
-			"status":     false,
-			"message":    err.Error(),
-		})
+		respondWithPasswordError(c, err)
 		return
 	}
 
@@ -73,97 +69,70 @@ func (user_handler *usersHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := user_handler.usersService.Login(userRequest)
+	login, err := user_handler.usersService.Login(userRequest, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"statusCode": 400,
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrLoginThrottled) || errors.Is(err, service.ErrAccountLocked) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
 			"status":     false,
 			"message":    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"statusCode": 200,
-		"status":     true,
-		"message":    "Login user data",
-		"data":       token,
-	})
+	respondWithLogin(c, "Login user data", login)
 }
 
-func (user_handler *usersHandler) OAuthHandler(state string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var (
-			config *oauth2.Config
-			err    error
-		)
-		switch state {
-		case "google":
-			config, _, err = helper.GetGoogleOAuthConfig()
-		case "github":
-			config, _, err = helper.GetGithubOAuthConfig()
-		case "microsoft":
-			config, _, err = helper.GetMicrosoftOAuthConfig()
-		}
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"statusCode": 500,
-				"status":     false,
-				"message":    err.Error(),
-			})
-			return
-		}
-
-		url := config.AuthCodeURL(state, oauth2.AccessTypeOffline) // BESERTA REFRESH TOKEN
-		// c.Redirect(http.StatusFound, url) // VIA BACKEND
-		c.JSON(http.StatusOK, gin.H{"url": url}) // VIA FRONTEND
+// ExchangeCode - menukar authorization code sekali pakai dari redirect callback OAuth dengan token
+func (user_handler *usersHandler) ExchangeCode(c *gin.Context) {
+	var exchangeRequest dto.ExchangeCodeRequest
+	if err := c.ShouldBindBodyWithJSON(&exchangeRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
+			"status":     false,
+			"message":    err.Error(),
+		})
+		return
 	}
-}
 
-func (user_handler *usersHandler) CallbackGoogle(c *gin.Context) {
-	// Ambil konfigurasi OAuth Google
-	googleConfig, redirect_url, err := helper.GetGoogleOAuthConfig()
+	login, err := user_handler.usersService.ExchangeAuthCode(exchangeRequest.Code, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": 500,
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"statusCode": 401,
 			"status":     false,
 			"message":    err.Error(),
 		})
 		return
 	}
 
-	// Ambil authorization code dari query parameter
-	code := c.Query("code")
-	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code not found"})
-		return
-	}
+	respondWithLogin(c, "Exchange authorization code", login)
+}
 
-	// Tukar authorization code dengan access token
-	token, err := googleConfig.Exchange(context.Background(), code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
-		return
+// clientInfo - IP dan user agent perangkat yang melakukan request
+func clientInfo(c *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
 	}
+}
 
-	// Gunakan access token untuk mengambil informasi pengguna
-	client := googleConfig.Client(context.Background(), token)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
-		return
-	}
-	defer resp.Body.Close()
+// OAuthHandler - membuat URL login untuk provider pada parameter :provider
+func (user_handler *usersHandler) OAuthHandler(c *gin.Context) {
+	user_handler.startOAuth(c, model.OAuthModeLogin, "")
+}
 
-	// Parse data pengguna
-	var userInfo map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user info"})
-		return
-	}
+// OAuthLinkHandler - membuat URL OAuth untuk menghubungkan provider ke akun yang sedang login
+func (user_handler *usersHandler) OAuthLinkHandler(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+	user_handler.startOAuth(c, model.OAuthModeLink, claims.UserID)
+}
 
-	tokenJWT, err := user_handler.usersService.OAuthLogin(userInfo["name"].(string), userInfo["email"].(string))
+// startOAuth - membuat state acak sekali pakai yang terikat ke browser (cookie) dan PKCE challenge
+func (user_handler *usersHandler) startOAuth(c *gin.Context, mode string, userID string) {
+	url, binding, err := user_handler.oauthService.StartAuth(c.Request.Context(), c.Param("provider"), mode, userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"statusCode": 400,
@@ -173,91 +142,38 @@ func (user_handler *usersHandler) CallbackGoogle(c *gin.Context) {
 		return
 	}
 
-	if env.Cfg.Server.Mode == dataconst.STAGING_MODE || env.Cfg.Server.Mode == dataconst.PRODUCTION_MODE {
-		c.Redirect(http.StatusFound, redirect_url+"/login?token="+*tokenJWT)
-	}
-	c.SetCookie("token", *tokenJWT, 60*60*24, "/", "localhost", false, false)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(dataconst.OAUTH_BINDING_COOKIE, binding, int(dataconst.OAUTH_STATE_TTL.Seconds()), "/", "", env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE, true)
 
-	c.Redirect(http.StatusFound, redirect_url)
+	// c.Redirect(http.StatusFound, url) // VIA BACKEND
+	c.JSON(http.StatusOK, gin.H{"url": url}) // VIA FRONTEND
 }
 
-func (user_handler *usersHandler) CallbackGithub(c *gin.Context) {
-	// Ambil konfigurasi OAuth Google
-	githubConfig, redirect_url, err := helper.GetGithubOAuthConfig()
+func (user_handler *usersHandler) GetIdentities(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
+
+	identities, err := user_handler.identityService.GetIdentities(claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": 500,
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
 			"status":     false,
 			"message":    err.Error(),
 		})
 		return
 	}
 
-	// Ambil authorization code dari query parameter
-	code := c.Query("code")
-	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code not found"})
-		return
-	}
-
-	// Tukar authorization code dengan access token
-	token, err := githubConfig.Exchange(context.Background(), code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
-		return
-	}
-
-	// Gunakan access token untuk mengambil informasi pengguna
-	client := githubConfig.Client(context.Background(), token)
-	// Ambil data pengguna
-	resp, err := client.Get("https://api.github.com/user")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
-		return
-	}
-	defer resp.Body.Close()
-
-	// Ambil email pengguna
-	emailResp, err := client.Get("https://api.github.com/user/emails")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user email"})
-		return
-	}
-	defer emailResp.Body.Close()
-
-	// Baca data dari io.ReadCloser (resp.Body)
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read user info"})
-		return
-	}
-
-	var githubUser dataconst.GitHubUser
-	if err := json.Unmarshal(data, &githubUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user info"})
-		return
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Get linked identities",
+		"data":       identities,
+	})
+}
 
-	// Parse email data
-	var emails []map[string]interface{}
-	if err := json.NewDecoder(emailResp.Body).Decode(&emails); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse email data"})
-		return
-	}
+func (user_handler *usersHandler) UnlinkIdentity(c *gin.Context) {
+	claims, _ := middleware.GetClaims(c)
 
-	// Pilih email utama (primary)
-	var primaryEmail string
-	for _, email := range emails {
-		if isPrimary, ok := email["primary"].(bool); ok && isPrimary {
-			if emailAddress, ok := email["email"].(string); ok {
-				primaryEmail = emailAddress
-				break
-			}
-		}
-	}
-
-	tokenJWT, err := user_handler.usersService.OAuthLogin(githubUser.Name, primaryEmail)
-	if err != nil {
+	if err := user_handler.identityService.UnlinkIdentity(claims.UserID, c.Param("id")); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"statusCode": 400,
 			"status":     false,
@@ -266,73 +182,50 @@ func (user_handler *usersHandler) CallbackGithub(c *gin.Context) {
 		return
 	}
 
-	if env.Cfg.Server.Mode == dataconst.STAGING_MODE || env.Cfg.Server.Mode == dataconst.PRODUCTION_MODE {
-		c.Redirect(http.StatusFound, redirect_url+"/login?token="+*tokenJWT)
-	}
-	c.SetCookie("token", *tokenJWT, 60*60*24, "/", "localhost", false, false)
-
-	c.Redirect(http.StatusFound, redirect_url)
+	c.JSON(http.StatusOK, gin.H{
+		"statusCode": 200,
+		"status":     true,
+		"message":    "Unlink identity",
+	})
 }
 
-func (user_handler *usersHandler) CallbackMicrosoft(c *gin.Context) {
-	// Ambil konfigurasi OAuth Google
-	microsoftConfig, redirect_url, err := helper.GetMicrosoftOAuthConfig()
+func (user_handler *usersHandler) Callback(c *gin.Context) {
+	binding, _ := c.Cookie(dataconst.OAUTH_BINDING_COOKIE)
+	c.SetCookie(dataconst.OAUTH_BINDING_COOKIE, "", -1, "/", "", env.Cfg.Server.Mode != dataconst.DEVELOPMENT_MODE, true)
+
+	// Validasi state, tukar authorization code dan ambil profil user dari provider
+	oauthState, profile, err := user_handler.oauthService.HandleCallback(c.Request.Context(), c.Param("provider"), c.Query("state"), binding, c.Query("code"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": 500,
+		c.JSON(http.StatusBadRequest, gin.H{
+			"statusCode": 400,
 			"status":     false,
 			"message":    err.Error(),
 		})
 		return
 	}
 
-	// Ambil authorization code dari query parameter
-	code := c.Query("code")
-	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code not found"})
-		return
-	}
-
-	// Tukar authorization code dengan access token
-	token, err := microsoftConfig.Exchange(context.Background(), code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
-		return
-	}
-
-	// Gunakan access token untuk mengambil informasi pengguna
-	client := microsoftConfig.Client(context.Background(), token)
-	// Ambil data pengguna
-	resp, err := client.Get("https://graph.microsoft.com/v1.0/me")
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
-		return
-	}
-	defer resp.Body.Close()
+	user_handler.finishOAuth(c, oauthState, profile, env.Cfg.Client.Url)
+}
 
-	// Parse data pengguna
-	var userInfo map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse user info"})
+// finishOAuth - menyelesaikan callback OAuth, baik untuk login maupun menghubungkan akun ke user yang sedang login
+func (user_handler *usersHandler) finishOAuth(c *gin.Context, oauthState model.OAuthState, profile dto.OAuthProfile, redirect_url string) {
+	if oauthState.Mode == model.OAuthModeLink {
+		if err := user_handler.identityService.LinkIdentity(oauthState.UserID, profile); err != nil {
+			c.Redirect(http.StatusFound, redirect_url+"?link_error="+url.QueryEscape(err.Error()))
+			return
+		}
+		c.Redirect(http.StatusFound, redirect_url+"?linked="+profile.Provider)
 		return
 	}
 
-	tokenJWT, err := user_handler.usersService.OAuthLogin(userInfo["displayName"].(string), userInfo["mail"].(string))
+	// Token tidak pernah dikirim lewat URL, frontend menukar code sekali pakai ini di POST /auth/exchange
+	code, err := user_handler.usersService.OAuthLogin(profile, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"statusCode": 400,
-			"status":     false,
-			"message":    err.Error(),
-		})
+		c.Redirect(http.StatusFound, redirect_url+"/login?error="+url.QueryEscape(err.Error()))
 		return
 	}
 
-	if env.Cfg.Server.Mode == dataconst.STAGING_MODE || env.Cfg.Server.Mode == dataconst.PRODUCTION_MODE {
-		c.Redirect(http.StatusFound, redirect_url+"/login?token="+*tokenJWT)
-	}
-	c.SetCookie("token", *tokenJWT, 60*60*24, "/", "localhost", false, false)
-
-	c.Redirect(http.StatusFound, redirect_url)
+	c.Redirect(http.StatusFound, redirect_url+"/login?code="+url.QueryEscape(code))
 }
 
 func (user_handler *usersHandler) GetAllUsers(c *gin.Context) {
@@ -438,25 +331,21 @@ func (user_handler *usersHandler) SendOTP(c *gin.Context) {
 		})
 		return
 	}
-	OTP.OTP = helper.GenerateOTP()
 
-	// Simpan OTP ke Redis
-	if err := user_handler.otpService.SetOTP(OTP.Email, OTP.OTP, 5*time.Minute); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"statusCode": 500,
+	// Kirim OTP verifikasi email, dibatasi oleh cooldown dan batas harian
+	if err := user_handler.otpService.SendOTP(model.OTPPurposeVerifyEmail, OTP.Email); err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, service.ErrOTPResendCooldown) || errors.Is(err, service.ErrOTPDailyLimit) {
+			statusCode = http.StatusTooManyRequests
+		}
+		c.JSON(statusCode, gin.H{
+			"statusCode": statusCode,
 			"status":     false,
-			"message":    "Failed to save OTP",
+			"message":    err.Error(),
 		})
 		return
 	}
 
-	// Kirimkan OTP ke email
-	SMTPProvider := helper.NewZohoSMTP(env.Cfg.ZSMTP)
-	if err := helper.NewSMTPClient(SMTPProvider).SendSingleEmail(OTP.Email, "OTP Verification", "otp-email-template.html", OTP); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": fmt.Sprintf("Failed to send email, error: %v", err)})
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"status":     true,
 		"statusCode": 200,
@@ -476,7 +365,7 @@ func (user_handler *usersHandler) VerifyOTP(c *gin.Context) {
 		return
 	}
 
-	valid, err := user_handler.otpService.ValidateOTP(OTP.Email, OTP.OTP)
+	valid, err := user_handler.otpService.ValidateOTP(model.OTPPurposeVerifyEmail, OTP.Email, OTP.OTP)
 	if err != nil || !valid {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"statusCode": 401,
@@ -486,7 +375,7 @@ func (user_handler *usersHandler) VerifyOTP(c *gin.Context) {
 		return
 	}
 
-	user, err := user_handler.usersService.VerifyUser(OTP.Email)
+	login, err := user_handler.usersService.VerifyUser(OTP.Email, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"statusCode": 500,
@@ -496,10 +385,5 @@ func (user_handler *usersHandler) VerifyOTP(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":     true,
-		"statusCode": 200,
-		"message":    "OTP verified successfully",
-		"data":       user,
-	})
+	respondWithLogin(c, "OTP verified successfully", login)
 }
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubUsersService - OAuthLogin selalu mengembalikan authorization code yang sama
+type stubUsersService struct {
+	service.UsersService
+}
+
+func (user_serv *stubUsersService) OAuthLogin(profile dto.OAuthProfile, client dto.ClientInfo) (string, error) {
+	return "one-time-code", nil
+}
+
+func TestFinishOAuthRedirectsWithCodeOnly(t *testing.T) {
+	testutil.Config(t)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/auth/callback/google", nil)
+
+	userHandler := NewUsersHandler(&stubUsersService{}, nil, nil, nil)
+	userHandler.finishOAuth(c, model.OAuthState{Mode: model.OAuthModeLogin}, dto.OAuthProfile{Provider: "google"}, "https://app.example")
+
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("status = %d, want 302", recorder.Code)
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parse location: %v", err)
+	}
+	if location.Path != "/login" || location.Query().Get("code") != "one-time-code" {
+		t.Fatalf("redirect = %s, want /login?code=one-time-code", location)
+	}
+	if location.Query().Has("token") {
+		t.Fatalf("redirect leaked a token: %s", location)
+	}
+	if cookies := recorder.Result().Cookies(); len(cookies) != 0 {
+		t.Fatalf("callback set token cookies before the code exchange: %v", cookies)
+	}
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	dataconst "refina-auth/internal/utils/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+const claimsContextKey = "claims"
+
+// AuthMiddleware - memvalidasi access token dari header Authorization (atau cookie token jika TOKEN_DELIVERY=cookie)
+// dan menolak token yang sudah dicabut
+func AuthMiddleware(tokenService service.TokenService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			tokenString, _ = c.Cookie(dataconst.ACCESS_TOKEN_COOKIE)
+		}
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"statusCode": 401,
+				"status":     false,
+				"message":    "authorization token is required",
+			})
+			return
+		}
+
+		claims, err := tokenService.VerifyAccessToken(tokenString)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"statusCode": 401,
+				"status":     false,
+				"message":    err.Error(),
+			})
+			return
+		}
+
+		c.Set(claimsContextKey, claims)
+		c.Next()
+	}
+}
+
+// RequireRole - hanya mengizinkan user dengan salah satu role yang disebutkan
+func RequireRole(roles ...model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok || !slices.Contains(roles, model.Role(claims.Role)) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission - hanya mengizinkan user yang role-nya memiliki permission tersebut
+func RequirePermission(roleService service.RoleService, permission model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasPermission(c, roleService, permission) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSelfOrPermission - mengizinkan user mengakses data miliknya sendiri (param :id),
+// atau data user lain jika role-nya memiliki permission tersebut
+func RequireSelfOrPermission(roleService service.RoleService, permission model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			forbidden(c)
+			return
+		}
+
+		if claims.UserID != c.Param("id") && !hasPermission(c, roleService, permission) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasPermission(c *gin.Context, roleService service.RoleService, permission model.Permission) bool {
+	claims, ok := GetClaims(c)
+	if !ok {
+		return false
+	}
+
+	allowed, err := roleService.HasPermission(claims.Role, permission)
+	return err == nil && allowed
+}
+
+func forbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"statusCode": 403,
+		"status":     false,
+		"message":    "you do not have permission to access this resource",
+	})
+}
+
+// GetClaims - mengambil claims JWT yang sudah divalidasi oleh AuthMiddleware
+func GetClaims(c *gin.Context) (*dto.JWTClaims, bool) {
+	value, exists := c.Get(claimsContextKey)
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(*dto.JWTClaims)
+	return claims, ok
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"refina-auth/internal/service"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	dataconst "refina-auth/internal/utils/data"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubTokenService - hanya token yang terdaftar di claims yang dianggap valid
+type stubTokenService struct {
+	service.TokenService
+	claims map[string]*dto.JWTClaims
+}
+
+func (token_serv *stubTokenService) VerifyAccessToken(accessToken string) (*dto.JWTClaims, error) {
+	claims, ok := token_serv.claims[accessToken]
+	if !ok {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	return claims, nil
+}
+
+// stubRoleService - permission per role diambil dari map
+type stubRoleService struct {
+	service.RoleService
+	permissions map[string][]model.Permission
+}
+
+func (role_serv *stubRoleService) HasPermission(role string, permission model.Permission) (bool, error) {
+	for _, rolePermission := range role_serv.permissions[role] {
+		if rolePermission == permission {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	handlers = append(handlers, func(c *gin.Context) {
+		claims, _ := GetClaims(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": claims.UserID})
+	})
+	router.GET("/users/:id", handlers...)
+
+	return router
+}
+
+func serve(router *gin.Engine, path string, setup func(req *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if setup != nil {
+		setup(req)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+var testTokenService = &stubTokenService{claims: map[string]*dto.JWTClaims{
+	"user-token":  {UserID: "user-1", Role: string(model.User)},
+	"admin-token": {UserID: "admin-1", Role: string(model.Admin)},
+}}
+
+func TestAuthMiddleware(t *testing.T) {
+	router := newTestRouter(AuthMiddleware(testTokenService))
+
+	tests := []struct {
+		name   string
+		setup  func(req *http.Request)
+		status int
+	}{
+		{"missing token", nil, http.StatusUnauthorized},
+		{"bearer token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer user-token") }, http.StatusOK},
+		{"cookie token", func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: dataconst.ACCESS_TOKEN_COOKIE, Value: "user-token"})
+		}, http.StatusOK},
+		{"invalid token", func(req *http.Request) { req.Header.Set("Authorization", "Bearer revoked") }, http.StatusUnauthorized},
+		{"header without bearer prefix", func(req *http.Request) { req.Header.Set("Authorization", "user-token") }, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(router, "/users/user-1", test.setup)
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d (%s)", recorder.Code, test.status, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireSelfOrPermission(t *testing.T) {
+	roleService := &stubRoleService{permissions: map[string][]model.Permission{
+		string(model.Admin): {model.UsersRead},
+	}}
+	router := newTestRouter(AuthMiddleware(testTokenService), RequireSelfOrPermission(roleService, model.UsersRead))
+
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		status int
+	}{
+		{"own record", "user-token", "/users/user-1", http.StatusOK},
+		{"another user's record", "user-token", "/users/user-2", http.StatusForbidden},
+		{"admin reads another user", "admin-token", "/users/user-2", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := serve(router, test.path, func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			})
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d (%s)", recorder.Code, test.status, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	roleService := &stubRoleService{permissions: map[string][]model.Permission{
+		string(model.Admin): {model.RolesManage},
+	}}
+	router := newTestRouter(AuthMiddleware(testTokenService), RequirePermission(roleService, model.RolesManage))
+
+	if recorder := serve(router, "/users/roles", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer user-token")
+	}); recorder.Code != http.StatusForbidden {
+		t.Fatalf("user without permission: status = %d, want 403", recorder.Code)
+	}
+	if recorder := serve(router, "/users/roles", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer admin-token")
+	}); recorder.Code != http.StatusOK {
+		t.Fatalf("user with permission: status = %d, want 200", recorder.Code)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	router := newTestRouter(AuthMiddleware(testTokenService), RequireRole(model.Admin))
+
+	if recorder := serve(router, "/users/roles", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer user-token")
+	}); recorder.Code != http.StatusForbidden {
+		t.Fatalf("user role: status = %d, want 403", recorder.Code)
+	}
+	if recorder := serve(router, "/users/roles", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer admin-token")
+	}); recorder.Code != http.StatusOK {
+		t.Fatalf("admin role: status = %d, want 200", recorder.Code)
+	}
+}
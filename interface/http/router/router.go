@@ -2,6 +2,8 @@ package router
 
 import (
 	"refina-auth/config/db"
+	"refina-auth/config/env"
+	"refina-auth/config/log"
 	"refina-auth/config/redis"
 	"refina-auth/interface/http/middleware"
 	"refina-auth/interface/http/routes"
@@ -10,7 +12,7 @@ import (
 )
 
 func SetupRouter() *gin.Engine {
-	router := gin.Default()
+	router := newEngine()
 
 	router.Use(middleware.CORSMiddleware(), middleware.GinMiddleware())
 
@@ -24,3 +26,15 @@ func SetupRouter() *gin.Engine {
 
 	return router
 }
+
+// newEngine membuat engine gin yang hanya mempercayai X-Forwarded-For dari proxy di TRUSTED_PROXIES
+func newEngine() *gin.Engine {
+	router := gin.Default()
+
+	// TANPA DAFTAR PROXY, X-Forwarded-For BISA DIISI BEBAS OLEH CLIENT UNTUK MENGHINDARI THROTTLE PER IP
+	if err := router.SetTrustedProxies(env.Cfg.Server.TrustedProxies); err != nil {
+		log.Log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	return router
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIPOnlyTrustsConfiguredProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		want    string
+	}{
+		{"no trusted proxies", nil, "10.0.0.5:4321", "10.0.0.5"},
+		{"request from trusted proxy", []string{"10.0.0.0/8"}, "10.0.0.5:4321", "203.0.113.10"},
+		{"request from untrusted address", []string{"10.0.0.0/8"}, "198.51.100.7:4321", "198.51.100.7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Config(t)
+			env.Cfg.Server.TrustedProxies = test.proxies
+
+			router := newEngine()
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			request.RemoteAddr = test.remote
+			request.Header.Set("X-Forwarded-For", "203.0.113.10")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Body.String() != test.want {
+				t.Fatalf("ClientIP = %q, want %q", recorder.Body.String(), test.want)
+			}
+		})
+	}
+}
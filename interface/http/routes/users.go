@@ -1,9 +1,17 @@
 package routes
 
 import (
+	"refina-auth/config/env"
+	"refina-auth/config/log"
 	"refina-auth/interface/http/handler"
+	"refina-auth/interface/http/middleware"
 	"refina-auth/internal/repository"
 	"refina-auth/internal/service"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/hasher"
+	"refina-auth/internal/utils/oauth"
+	"refina-auth/internal/utils/policy"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -11,26 +19,146 @@ import (
 )
 
 func UserRoutes(version *gin.Engine, db *gorm.DB, redis *redis.Client) {
+	SigningKey_repo := repository.NewSigningKeyRepository(db)
+	Key_serv, err := service.NewKeyService(SigningKey_repo)
+	if err != nil {
+		log.Log.Fatalf("Failed to setup JWT key store: %v", err)
+	}
+	Key_serv.StartKeyRotation()
+
+	SMTP_client := helper.NewSMTPClient(helper.NewZohoSMTP(env.Cfg.ZSMTP))
+
+	Password_hasher, err := hasher.New(env.Cfg.Password)
+	if err != nil {
+		log.Log.Fatalf("Failed to setup password hasher: %v", err)
+	}
+	Password_policy, err := policy.New(env.Cfg.Password)
+	if err != nil {
+		log.Log.Fatalf("Failed to setup password policy: %v", err)
+	}
+
 	User_repo := repository.NewUsersRepository(db)
-	User_serv := service.NewUsersService(User_repo)
+	Identity_repo := repository.NewIdentityRepository(db)
+	Token_repo := repository.NewTokenRepository(redis)
+	Session_repo := repository.NewSessionRepository(db)
+	Token_serv := service.NewTokenService(Token_repo, User_repo, Session_repo, Key_serv)
+	Session_serv := service.NewSessionService(Session_repo, Token_serv)
+	PasswordReset_repo := repository.NewPasswordResetRepository(redis)
+	PasswordHistory_repo := repository.NewPasswordHistoryRepository(db)
+	Password_serv := service.NewPasswordService(User_repo, PasswordReset_repo, PasswordHistory_repo, Token_serv, SMTP_client, Password_hasher, Password_policy)
+	LoginHistory_repo := repository.NewLoginHistoryRepository(db)
+	LoginReport_repo := repository.NewLoginReportRepository(redis)
+	Passkey_repo := repository.NewPasskeyRepository(db)
+	LoginHistory_serv := service.NewLoginHistoryService(User_repo, LoginHistory_repo, LoginReport_repo, Identity_repo, Passkey_repo, Token_serv, Password_serv, SMTP_client)
+	TwoFactor_repo := repository.NewTwoFactorRepository(redis)
+	RecoveryCode_repo := repository.NewRecoveryCodeRepository(db)
+	TwoFactor_serv := service.NewTwoFactorService(User_repo, TwoFactor_repo, RecoveryCode_repo, Token_serv, Session_serv, LoginHistory_serv, SMTP_client)
+	LoginAttempt_repo := repository.NewLoginAttemptRepository(redis)
+	LoginAttempt_serv := service.NewLoginAttemptService(User_repo, LoginAttempt_repo, SMTP_client)
+	User_serv := service.NewUsersService(User_repo, Identity_repo, Token_serv, TwoFactor_serv, LoginAttempt_serv, LoginHistory_serv, Password_hasher, Password_policy)
+
+	Role_repo := repository.NewRoleRepository(db)
+	Role_serv := service.NewRoleService(Role_repo, User_repo, Token_serv)
 
 	OTP_repo := repository.NewOTPRepository(redis)
-	OTP_serv := service.NewOTPService(OTP_repo)
+	OTP_serv := service.NewOTPService(OTP_repo, SMTP_client)
+
+	EmailChange_repo := repository.NewEmailChangeRepository(redis)
+	EmailChange_serv := service.NewEmailChangeService(User_repo, EmailChange_repo, Token_serv, SMTP_client)
+	MagicLink_repo := repository.NewMagicLinkRepository(redis)
+	MagicLink_serv := service.NewMagicLinkService(User_repo, MagicLink_repo, TwoFactor_serv, LoginHistory_serv, SMTP_client)
+
+	OAuth_providers, err := oauth.NewRegistry(env.Cfg.OAuth.Providers)
+	if err != nil {
+		log.Log.Fatalf("Failed to setup OAuth providers: %v", err)
+	}
+	OAuthState_repo := repository.NewOAuthStateRepository(redis)
+	OAuth_serv := service.NewOAuthService(OAuthState_repo, OAuth_providers)
+	Identity_serv := service.NewIdentityService(Identity_repo, User_repo, Passkey_repo)
+	WebAuthnSession_repo := repository.NewWebAuthnSessionRepository(redis)
+	Passkey_serv, err := service.NewPasskeyService(User_repo, Identity_repo, Passkey_repo, WebAuthnSession_repo, Token_serv, TwoFactor_serv, OTP_serv, LoginAttempt_serv, LoginHistory_serv, Password_hasher, SMTP_client)
+	if err != nil {
+		log.Log.Fatalf("Failed to setup WebAuthn: %v", err)
+	}
 
-	User_handler := handler.NewUsersHandler(User_serv, OTP_serv)
+	User_handler := handler.NewUsersHandler(User_serv, OTP_serv, Identity_serv, OAuth_serv)
+	Token_handler := handler.NewTokenHandler(Token_serv)
+	Session_handler := handler.NewSessionHandler(Session_serv)
+	Key_handler := handler.NewKeyHandler(Key_serv)
+	Role_handler := handler.NewRoleHandler(Role_serv)
+	Password_handler := handler.NewPasswordHandler(Password_serv)
+	EmailChange_handler := handler.NewEmailChangeHandler(EmailChange_serv)
+	TwoFactor_handler := handler.NewTwoFactorHandler(TwoFactor_serv)
+	Passkey_handler := handler.NewPasskeyHandler(Passkey_serv)
+	MagicLink_handler := handler.NewMagicLinkHandler(MagicLink_serv)
+	LoginAttempt_handler := handler.NewLoginAttemptHandler(LoginAttempt_serv)
+	LoginHistory_handler := handler.NewLoginHistoryHandler(LoginHistory_serv)
+
+	version.GET("/.well-known/jwks.json", Key_handler.GetJWKS)
 
 	auth := version.Group("/auth")
 	{
 		auth.POST("login", User_handler.Login)
 		auth.POST("register", User_handler.Register)
+		auth.POST("refresh", Token_handler.RefreshToken)
+		auth.POST("exchange", User_handler.ExchangeCode)
+		auth.POST("password/forgot", Password_handler.ForgotPassword)
+		auth.POST("password/reset", Password_handler.ResetPassword)
+		auth.POST("password/change", middleware.AuthMiddleware(Token_serv), Password_handler.ChangePassword)
+		auth.POST("email/change", middleware.AuthMiddleware(Token_serv), EmailChange_handler.RequestEmailChange)
+		auth.POST("email/confirm", EmailChange_handler.ConfirmEmailChange)
+		auth.POST("email/undo", EmailChange_handler.UndoEmailChange)
+		auth.POST("magic-link", MagicLink_handler.RequestMagicLink)
+		auth.POST("magic-link/verify", MagicLink_handler.VerifyMagicLink)
+		auth.POST("unlock", LoginAttempt_handler.UnlockAccount)
+		auth.POST("logout", middleware.AuthMiddleware(Token_serv), Token_handler.Logout)
+		auth.POST("logout/all", middleware.AuthMiddleware(Token_serv), Token_handler.LogoutAll)
+		auth.GET("sessions", middleware.AuthMiddleware(Token_serv), Session_handler.GetSessions)
+		auth.DELETE("sessions/:id", middleware.AuthMiddleware(Token_serv), Session_handler.RevokeSession)
+		auth.POST("sessions/revoke-others", middleware.AuthMiddleware(Token_serv), Session_handler.RevokeOtherSessions)
+		auth.GET("login-history", middleware.AuthMiddleware(Token_serv), LoginHistory_handler.GetLoginHistory)
+		auth.POST("login-history/report", LoginHistory_handler.ReportLogin)
 		auth.POST("send/otp", User_handler.SendOTP)
 		auth.POST("verify/otp", User_handler.VerifyOTP)
 
-		auth.GET("google/oauth", User_handler.OAuthHandler("google"))
-		auth.GET("callback/google", User_handler.CallbackGoogle)
-		auth.GET("github/oauth", User_handler.OAuthHandler("github"))
-		auth.GET("callback/github", User_handler.CallbackGithub)
-		auth.GET("microsoft/oauth", User_handler.OAuthHandler("microsoft"))
-		auth.GET("callback/microsoft", User_handler.CallbackMicrosoft)
+		auth.POST("2fa/enroll", middleware.AuthMiddleware(Token_serv), TwoFactor_handler.Enroll)
+		auth.POST("2fa/confirm", middleware.AuthMiddleware(Token_serv), TwoFactor_handler.Confirm)
+		auth.POST("2fa/disable", middleware.AuthMiddleware(Token_serv), TwoFactor_handler.Disable)
+		auth.POST("2fa/recovery-codes", middleware.AuthMiddleware(Token_serv), TwoFactor_handler.RegenerateRecoveryCodes)
+		auth.POST("2fa/verify", TwoFactor_handler.Verify)
+		auth.POST("2fa/recovery", TwoFactor_handler.Recovery)
+
+		auth.POST("passkeys/register/otp", middleware.AuthMiddleware(Token_serv), Passkey_handler.SendStepUpOTP)
+		auth.POST("passkeys/register/begin", middleware.AuthMiddleware(Token_serv), Passkey_handler.BeginRegistration)
+		auth.POST("passkeys/register/finish", middleware.AuthMiddleware(Token_serv), Passkey_handler.FinishRegistration)
+		auth.POST("passkeys/login/begin", Passkey_handler.BeginLogin)
+		auth.POST("passkeys/login/finish", Passkey_handler.FinishLogin)
+		auth.GET("passkeys", middleware.AuthMiddleware(Token_serv), Passkey_handler.GetPasskeys)
+		auth.PATCH("passkeys/:id", middleware.AuthMiddleware(Token_serv), Passkey_handler.RenamePasskey)
+		auth.DELETE("passkeys/:id", middleware.AuthMiddleware(Token_serv), Passkey_handler.DeletePasskey)
+
+		auth.GET(":provider/oauth", User_handler.OAuthHandler)
+		auth.GET("callback/:provider", User_handler.Callback)
+
+		auth.GET("identities", middleware.AuthMiddleware(Token_serv), User_handler.GetIdentities)
+		auth.DELETE("identities/:id", middleware.AuthMiddleware(Token_serv), User_handler.UnlinkIdentity)
+		auth.GET(":provider/link", middleware.AuthMiddleware(Token_serv), User_handler.OAuthLinkHandler)
+	}
+
+	users := version.Group("/users", middleware.AuthMiddleware(Token_serv))
+	{
+		users.GET("", middleware.RequirePermission(Role_serv, model.UsersRead), User_handler.GetAllUsers)
+		users.GET(":id", middleware.RequireSelfOrPermission(Role_serv, model.UsersRead), User_handler.GetUserByID)
+		users.PUT(":id", middleware.RequireSelfOrPermission(Role_serv, model.UsersUpdate), User_handler.UpdateUser)
+		users.DELETE(":id", middleware.RequireSelfOrPermission(Role_serv, model.UsersDelete), User_handler.DeleteUser)
+		users.DELETE(":id/lock", middleware.RequirePermission(Role_serv, model.UsersUpdate), LoginAttempt_handler.AdminUnlockAccount)
+	}
+
+	// PENGELOLAAN ROLE SELALU KHUSUS ADMIN, SEHINGGA PERMISSION YANG TERLANJUR DIBERIKAN KE ROLE LAIN
+	// TIDAK BISA DIPAKAI UNTUK MENAIKKAN ROLE SENDIRI
+	roles := version.Group("/roles", middleware.AuthMiddleware(Token_serv), middleware.RequireRole(model.Admin), middleware.RequirePermission(Role_serv, model.RolesManage))
+	{
+		roles.GET("", Role_handler.GetAllRoles)
+		roles.PUT("users/:id", middleware.RequirePermission(Role_serv, model.RolesAssign), Role_handler.AssignRole)
 	}
 }
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type EmailChangeRepository interface {
+	SavePendingChange(tokenHash string, emailChange model.EmailChange, duration time.Duration) error
+	ConsumePendingChange(tokenHash string) (model.EmailChange, error)
+	SaveUndoToken(tokenHash string, emailChange model.EmailChange, duration time.Duration) error
+	ConsumeUndoToken(tokenHash string) (model.EmailChange, error)
+}
+
+type emailChangeRepository struct {
+	redis *redis.Client
+}
+
+func NewEmailChangeRepository(redis *redis.Client) EmailChangeRepository {
+	return &emailChangeRepository{redis}
+}
+
+func pendingEmailChangeKey(tokenHash string) string {
+	return "email_change:" + tokenHash
+}
+
+func userPendingEmailChangeKey(userID string) string {
+	return "email_change_user:" + userID
+}
+
+func undoEmailChangeKey(tokenHash string) string {
+	return "email_change_undo:" + tokenHash
+}
+
+// SavePendingChange menyimpan perubahan email yang menunggu konfirmasi dan membatalkan permintaan
+// sebelumnya milik user yang sama
+func (email_repo *emailChangeRepository) SavePendingChange(tokenHash string, emailChange model.EmailChange, duration time.Duration) error {
+	ctx := context.Background()
+
+	value, err := json.Marshal(emailChange)
+	if err != nil {
+		return err
+	}
+
+	previousHash, err := email_repo.redis.GetSet(ctx, userPendingEmailChangeKey(emailChange.UserID), tokenHash).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := email_repo.redis.TxPipeline()
+	if previousHash != "" {
+		pipe.Del(ctx, pendingEmailChangeKey(previousHash))
+	}
+	pipe.Expire(ctx, userPendingEmailChangeKey(emailChange.UserID), duration)
+	pipe.Set(ctx, pendingEmailChangeKey(tokenHash), value, duration)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+func (email_repo *emailChangeRepository) ConsumePendingChange(tokenHash string) (model.EmailChange, error) {
+	emailChange, err := email_repo.consume(pendingEmailChangeKey(tokenHash))
+	if err != nil {
+		return model.EmailChange{}, err
+	}
+	email_repo.redis.Del(context.Background(), userPendingEmailChangeKey(emailChange.UserID))
+
+	return emailChange, nil
+}
+
+func (email_repo *emailChangeRepository) SaveUndoToken(tokenHash string, emailChange model.EmailChange, duration time.Duration) error {
+	value, err := json.Marshal(emailChange)
+	if err != nil {
+		return err
+	}
+
+	return email_repo.redis.Set(context.Background(), undoEmailChangeKey(tokenHash), value, duration).Err()
+}
+
+func (email_repo *emailChangeRepository) ConsumeUndoToken(tokenHash string) (model.EmailChange, error) {
+	return email_repo.consume(undoEmailChangeKey(tokenHash))
+}
+
+// consume mengambil sekaligus menghapus token agar hanya bisa dipakai sekali
+func (email_repo *emailChangeRepository) consume(key string) (model.EmailChange, error) {
+	value, err := email_repo.redis.GetDel(context.Background(), key).Bytes()
+	if err == redis.Nil {
+		return model.EmailChange{}, errors.New("email change token not found")
+	}
+	if err != nil {
+		return model.EmailChange{}, err
+	}
+
+	var emailChange model.EmailChange
+	if err := json.Unmarshal(value, &emailChange); err != nil {
+		return model.EmailChange{}, err
+	}
+
+	return emailChange, nil
+}
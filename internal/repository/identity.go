@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	GetIdentity(provider string, providerUserID string) (model.UserIdentities, error)
+	GetIdentityByID(id string) (model.UserIdentities, error)
+	GetIdentitiesByUserID(userID string) ([]model.UserIdentities, error)
+	CreateIdentity(identity model.UserIdentities) (model.UserIdentities, error)
+	UpdateIdentity(identity model.UserIdentities) (model.UserIdentities, error)
+	DeleteIdentity(identity model.UserIdentities) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db}
+}
+
+func (identity_repo *identityRepository) GetIdentity(provider string, providerUserID string) (model.UserIdentities, error) {
+	var identity model.UserIdentities
+	err := identity_repo.db.First(&identity, "provider = ? AND provider_user_id = ?", provider, providerUserID).Error
+	if err != nil {
+		return model.UserIdentities{}, errors.New("identity not found")
+	}
+
+	return identity, nil
+}
+
+func (identity_repo *identityRepository) GetIdentityByID(id string) (model.UserIdentities, error) {
+	var identity model.UserIdentities
+	err := identity_repo.db.First(&identity, "id = ?", id).Error
+	if err != nil {
+		return model.UserIdentities{}, errors.New("identity not found")
+	}
+
+	return identity, nil
+}
+
+func (identity_repo *identityRepository) GetIdentitiesByUserID(userID string) ([]model.UserIdentities, error) {
+	var identities []model.UserIdentities
+	err := identity_repo.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error
+	if err != nil {
+		return nil, errors.New("failed to get identities")
+	}
+
+	return identities, nil
+}
+
+func (identity_repo *identityRepository) CreateIdentity(identity model.UserIdentities) (model.UserIdentities, error) {
+	err := identity_repo.db.Create(&identity).Error
+	if err != nil {
+		return model.UserIdentities{}, errors.New("failed to create identity")
+	}
+
+	return identity, nil
+}
+
+func (identity_repo *identityRepository) UpdateIdentity(identity model.UserIdentities) (model.UserIdentities, error) {
+	err := identity_repo.db.Save(&identity).Error
+	if err != nil {
+		return model.UserIdentities{}, errors.New("failed to update identity")
+	}
+
+	return identity, nil
+}
+
+// DeleteIdentity menghapus permanen agar identity yang sama bisa dihubungkan kembali nanti
+func (identity_repo *identityRepository) DeleteIdentity(identity model.UserIdentities) error {
+	err := identity_repo.db.Unscoped().Delete(&identity).Error
+	if err != nil {
+		return errors.New("failed to delete identity")
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginAttemptRepository - counter login gagal, backoff dan lockout disimpan di Redis sehingga
+// tetap berlaku setelah restart dan dibagi oleh seluruh replica API
+type LoginAttemptRepository interface {
+	ReserveAttempt(scope string, id string, backoff LoginBackoff) (int64, time.Duration, error)
+	ReleaseAttempt(scope string, id string) error
+	GetFailures(scope string, id string) (int64, error)
+	ResetFailures(scope string, id string) error
+	LockAccount(email string, duration time.Duration) error
+	GetLock(email string) (time.Duration, error)
+	UnlockAccount(email string) error
+	SaveUnlockToken(tokenHash string, email string, duration time.Duration) error
+	ConsumeUnlockToken(tokenHash string) (string, error)
+}
+
+type loginAttemptRepository struct {
+	redis *redis.Client
+}
+
+// LoginBackoff - setelah Threshold kali gagal dalam Window, setiap percobaan menggandakan waktu tunggu
+// mulai dari Base sampai Max
+type LoginBackoff struct {
+	Window    time.Duration
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+func NewLoginAttemptRepository(redis *redis.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{redis}
+}
+
+// reserveAttemptScript menolak percobaan selama masih dalam masa backoff, jika tidak percobaan langsung dihitung
+// sebagai gagal dan backoff berikutnya dipasang dalam script yang sama. Request paralel tidak bisa lolos
+// di antara pengecekan dan pencatatan kegagalan.
+var reserveAttemptScript = redis.NewScript(`
+local delay = redis.call("PTTL", KEYS[2])
+if delay > 0 then
+	return {0, delay}
+end
+local failures = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[1])
+local over = failures - tonumber(ARGV[2])
+if over > 0 then
+	local backoff = tonumber(ARGV[4])
+	if over <= 32 then
+		backoff = math.min(tonumber(ARGV[3]) * math.pow(2, over - 1), backoff)
+	end
+	redis.call("SET", KEYS[2], 1, "PX", math.floor(backoff))
+end
+return {failures, 0}
+`)
+
+// releaseAttemptScript mengurangi counter tanpa membuat key baru atau nilai negatif saat key sudah expired
+var releaseAttemptScript = redis.NewScript(`
+local failures = tonumber(redis.call("GET", KEYS[1]) or "0")
+if failures > 0 then
+	redis.call("DECR", KEYS[1])
+end
+return failures
+`)
+
+func loginFailuresKey(scope string, id string) string {
+	return "login_failures:" + scope + ":" + id
+}
+
+func loginDelayKey(scope string, id string) string {
+	return "login_delay:" + scope + ":" + id
+}
+
+func loginLockKey(email string) string {
+	return "login_lock:" + email
+}
+
+func loginUnlockKey(tokenHash string) string {
+	return "login_unlock:" + tokenHash
+}
+
+// ReserveAttempt mencatat percobaan login sebelum password dicek dan mengembalikan jumlah kegagalan dalam window.
+// Jika masih dalam masa backoff percobaan tidak dicatat dan sisa waktu tunggu dikembalikan.
+// Window diperpanjang setiap kali terjadi percobaan.
+func (attempt_repo *loginAttemptRepository) ReserveAttempt(scope string, id string, backoff LoginBackoff) (int64, time.Duration, error) {
+	result, err := reserveAttemptScript.Run(context.Background(), attempt_repo.redis,
+		[]string{loginFailuresKey(scope, id), loginDelayKey(scope, id)},
+		backoff.Window.Milliseconds(), backoff.Threshold, backoff.Base.Milliseconds(), backoff.Max.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return result[0], time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// ReleaseAttempt membatalkan percobaan yang sudah dicatat ReserveAttempt karena ternyata berhasil
+func (attempt_repo *loginAttemptRepository) ReleaseAttempt(scope string, id string) error {
+	return releaseAttemptScript.Run(context.Background(), attempt_repo.redis, []string{loginFailuresKey(scope, id)}).Err()
+}
+
+func (attempt_repo *loginAttemptRepository) GetFailures(scope string, id string) (int64, error) {
+	failures, err := attempt_repo.redis.Get(context.Background(), loginFailuresKey(scope, id)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+
+	return failures, err
+}
+
+func (attempt_repo *loginAttemptRepository) ResetFailures(scope string, id string) error {
+	return attempt_repo.redis.Del(context.Background(), loginFailuresKey(scope, id), loginDelayKey(scope, id)).Err()
+}
+
+func (attempt_repo *loginAttemptRepository) LockAccount(email string, duration time.Duration) error {
+	return attempt_repo.redis.Set(context.Background(), loginLockKey(email), 1, duration).Err()
+}
+
+// GetLock mengembalikan sisa waktu lockout akun, 0 jika akun tidak terkunci
+func (attempt_repo *loginAttemptRepository) GetLock(email string) (time.Duration, error) {
+	return remainingTTL(attempt_repo.redis.PTTL(context.Background(), loginLockKey(email)).Result())
+}
+
+func (attempt_repo *loginAttemptRepository) UnlockAccount(email string) error {
+	return attempt_repo.redis.Del(context.Background(), loginLockKey(email)).Err()
+}
+
+func (attempt_repo *loginAttemptRepository) SaveUnlockToken(tokenHash string, email string, duration time.Duration) error {
+	return attempt_repo.redis.Set(context.Background(), loginUnlockKey(tokenHash), email, duration).Err()
+}
+
+// ConsumeUnlockToken mengambil sekaligus menghapus token unlock agar hanya bisa dipakai sekali
+func (attempt_repo *loginAttemptRepository) ConsumeUnlockToken(tokenHash string) (string, error) {
+	email, err := attempt_repo.redis.GetDel(context.Background(), loginUnlockKey(tokenHash)).Result()
+	if err == redis.Nil {
+		return "", errors.New("unlock token not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return email, nil
+}
+
+// remainingTTL - PTTL mengembalikan nilai negatif untuk key yang tidak ada atau tanpa expiry
+func remainingTTL(ttl time.Duration, err error) (time.Duration, error) {
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
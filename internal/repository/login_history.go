@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type LoginHistoryRepository interface {
+	CreateLoginHistory(history model.UserLoginHistories) (model.UserLoginHistories, error)
+	GetLoginHistory(userID string, limit int) ([]model.UserLoginHistories, error)
+	GetLoginHistoryByID(id string) (model.UserLoginHistories, error)
+	HasSuccessfulLogin(userID string) (bool, error)
+	HasSuccessfulLoginWithUserAgent(userID string, userAgent string) (bool, error)
+	HasSuccessfulLoginFromIP(userID string, ipAddress string) (bool, error)
+}
+
+type loginHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginHistoryRepository(db *gorm.DB) LoginHistoryRepository {
+	return &loginHistoryRepository{db}
+}
+
+func (login_history_repo *loginHistoryRepository) CreateLoginHistory(history model.UserLoginHistories) (model.UserLoginHistories, error) {
+	err := login_history_repo.db.Create(&history).Error
+	if err != nil {
+		return model.UserLoginHistories{}, errors.New("failed to save login history")
+	}
+
+	return history, nil
+}
+
+func (login_history_repo *loginHistoryRepository) GetLoginHistory(userID string, limit int) ([]model.UserLoginHistories, error) {
+	var histories []model.UserLoginHistories
+	err := login_history_repo.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&histories).Error
+	if err != nil {
+		return nil, errors.New("failed to get login history")
+	}
+
+	return histories, nil
+}
+
+func (login_history_repo *loginHistoryRepository) GetLoginHistoryByID(id string) (model.UserLoginHistories, error) {
+	var history model.UserLoginHistories
+	if err := login_history_repo.db.Where("id = ?", id).First(&history).Error; err != nil {
+		return model.UserLoginHistories{}, errors.New("login history not found")
+	}
+
+	return history, nil
+}
+
+// HasSuccessfulLogin - false untuk login pertama user sehingga tidak ada alert perangkat baru setelah registrasi
+func (login_history_repo *loginHistoryRepository) HasSuccessfulLogin(userID string) (bool, error) {
+	return hasLoginHistory(login_history_repo.successfulLogins(userID))
+}
+
+func (login_history_repo *loginHistoryRepository) HasSuccessfulLoginWithUserAgent(userID string, userAgent string) (bool, error) {
+	return hasLoginHistory(login_history_repo.successfulLogins(userID).Where("user_agent = ?", userAgent))
+}
+
+func (login_history_repo *loginHistoryRepository) HasSuccessfulLoginFromIP(userID string, ipAddress string) (bool, error) {
+	return hasLoginHistory(login_history_repo.successfulLogins(userID).Where("ip_address = ?", ipAddress))
+}
+
+func (login_history_repo *loginHistoryRepository) successfulLogins(userID string) *gorm.DB {
+	return login_history_repo.db.Model(&model.UserLoginHistories{}).Where("user_id = ? AND success = ?", userID, true)
+}
+
+func hasLoginHistory(query *gorm.DB) (bool, error) {
+	var count int64
+	if err := query.Limit(1).Count(&count).Error; err != nil {
+		return false, errors.New("failed to check login history")
+	}
+
+	return count > 0, nil
+}
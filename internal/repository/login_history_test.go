@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"testing"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/google/uuid"
+)
+
+func TestHasSuccessfulLoginIgnoresFailuresAndOtherUsers(t *testing.T) {
+	db := newTestDB(t, &model.UserLoginHistories{})
+	historyRepo := NewLoginHistoryRepository(db)
+	userID, otherUserID := uuid.New(), uuid.New()
+
+	for _, history := range []model.UserLoginHistories{
+		{UserID: userID, UserAgent: "laptop", IPAddress: "203.0.113.10", Success: false},
+		{UserID: otherUserID, UserAgent: "phone", IPAddress: "198.51.100.23", Success: true},
+	} {
+		if _, err := historyRepo.CreateLoginHistory(history); err != nil {
+			t.Fatalf("CreateLoginHistory: %v", err)
+		}
+	}
+
+	// LOGIN GAGAL DAN LOGIN USER LAIN TIDAK MEMBUAT PERANGKAT DIANGGAP DIKENAL
+	if hasLogin, err := historyRepo.HasSuccessfulLogin(userID.String()); err != nil || hasLogin {
+		t.Fatalf("HasSuccessfulLogin = %v, %v, want false", hasLogin, err)
+	}
+	if known, err := historyRepo.HasSuccessfulLoginWithUserAgent(userID.String(), "phone"); err != nil || known {
+		t.Fatalf("HasSuccessfulLoginWithUserAgent = %v, %v, want false", known, err)
+	}
+
+	if _, err := historyRepo.CreateLoginHistory(model.UserLoginHistories{UserID: userID, UserAgent: "laptop", IPAddress: "203.0.113.10", Success: true}); err != nil {
+		t.Fatalf("CreateLoginHistory: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		check func() (bool, error)
+		want  bool
+	}{
+		{"any login", func() (bool, error) { return historyRepo.HasSuccessfulLogin(userID.String()) }, true},
+		{"known user agent", func() (bool, error) { return historyRepo.HasSuccessfulLoginWithUserAgent(userID.String(), "laptop") }, true},
+		{"unknown user agent", func() (bool, error) { return historyRepo.HasSuccessfulLoginWithUserAgent(userID.String(), "phone") }, false},
+		{"known ip", func() (bool, error) { return historyRepo.HasSuccessfulLoginFromIP(userID.String(), "203.0.113.10") }, true},
+		{"unknown ip", func() (bool, error) { return historyRepo.HasSuccessfulLoginFromIP(userID.String(), "198.51.100.23") }, false},
+	}
+	for _, test := range tests {
+		if got, err := test.check(); err != nil || got != test.want {
+			t.Errorf("%s = %v, %v, want %v", test.name, got, err, test.want)
+		}
+	}
+}
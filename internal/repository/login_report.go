@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type LoginReportRepository interface {
+	SaveReportToken(tokenHash string, report model.LoginReport, duration time.Duration) error
+	GetReportToken(tokenHash string) (model.LoginReport, error)
+	ConsumeReportToken(tokenHash string) error
+}
+
+type loginReportRepository struct {
+	redis *redis.Client
+}
+
+func NewLoginReportRepository(redis *redis.Client) LoginReportRepository {
+	return &loginReportRepository{redis}
+}
+
+func loginReportKey(tokenHash string) string {
+	return "login_report:" + tokenHash
+}
+
+func (report_repo *loginReportRepository) SaveReportToken(tokenHash string, report model.LoginReport, duration time.Duration) error {
+	ctx := context.Background()
+
+	pipe := report_repo.redis.TxPipeline()
+	pipe.HSet(ctx, loginReportKey(tokenHash), map[string]interface{}{
+		"user_id":          report.UserID,
+		"login_history_id": report.LoginHistoryID,
+	})
+	pipe.Expire(ctx, loginReportKey(tokenHash), duration)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// GetReportToken mengambil data token "this wasn't me" tanpa menghapusnya, sehingga link masih bisa dipakai
+// lagi jika salah satu langkah pengamanan akun gagal
+func (report_repo *loginReportRepository) GetReportToken(tokenHash string) (model.LoginReport, error) {
+	values, err := report_repo.redis.HGetAll(context.Background(), loginReportKey(tokenHash)).Result()
+	if err != nil {
+		return model.LoginReport{}, err
+	}
+	if len(values) == 0 {
+		return model.LoginReport{}, errors.New("report token not found")
+	}
+
+	return model.LoginReport{
+		UserID:         values["user_id"],
+		LoginHistoryID: values["login_history_id"],
+	}, nil
+}
+
+// ConsumeReportToken menghapus token setelah akun diamankan agar hanya bisa dipakai sekali
+func (report_repo *loginReportRepository) ConsumeReportToken(tokenHash string) error {
+	deleted, err := report_repo.redis.Del(context.Background(), loginReportKey(tokenHash)).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return errors.New("report token not found")
+	}
+
+	return nil
+}
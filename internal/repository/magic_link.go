@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type MagicLinkRepository interface {
+	SaveMagicLink(tokenHash string, magicLink model.MagicLink, duration time.Duration) error
+	ConsumeMagicLink(tokenHash string, bindingHash string) (model.MagicLink, bool, error)
+	AcquireCooldown(scope string, id string, duration time.Duration) (bool, error)
+	IncrementDailyCount(email string) (int64, error)
+}
+
+type magicLinkRepository struct {
+	redis *redis.Client
+}
+
+func NewMagicLinkRepository(redis *redis.Client) MagicLinkRepository {
+	return &magicLinkRepository{redis}
+}
+
+// consumeMagicLinkScript mencocokkan binding browser lalu menghapus link secara atomik. Link tidak dihapus saat
+// binding tidak cocok, sehingga link yang dibuka di browser lain (misalnya oleh link scanner) tidak membakar link user.
+var consumeMagicLinkScript = redis.NewScript(`
+local values = redis.call("HMGET", KEYS[1], "user_id", "browser_binding")
+if not values[1] then
+	return {-1, ""}
+end
+if values[2] ~= ARGV[1] then
+	return {0, values[1]}
+end
+redis.call("DEL", KEYS[1])
+return {1, values[1]}
+`)
+
+func magicLinkKey(tokenHash string) string {
+	return "magic_link:" + tokenHash
+}
+
+func userMagicLinkKey(userID string) string {
+	return "magic_link_user:" + userID
+}
+
+func magicLinkCooldownKey(scope string, id string) string {
+	return "magic_link_cooldown:" + scope + ":" + id
+}
+
+func magicLinkDailyKey(email string) string {
+	return "magic_link_daily:" + time.Now().UTC().Format("2006-01-02") + ":" + email
+}
+
+// SaveMagicLink menyimpan hash token dan menghapus link sebelumnya milik user yang sama,
+// sehingga hanya link terakhir yang berlaku
+func (magic_link_repo *magicLinkRepository) SaveMagicLink(tokenHash string, magicLink model.MagicLink, duration time.Duration) error {
+	ctx := context.Background()
+
+	previousHash, err := magic_link_repo.redis.GetSet(ctx, userMagicLinkKey(magicLink.UserID), tokenHash).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := magic_link_repo.redis.TxPipeline()
+	if previousHash != "" {
+		pipe.Del(ctx, magicLinkKey(previousHash))
+	}
+	pipe.Expire(ctx, userMagicLinkKey(magicLink.UserID), duration)
+	pipe.HSet(ctx, magicLinkKey(tokenHash), map[string]interface{}{
+		"user_id":         magicLink.UserID,
+		"browser_binding": magicLink.BrowserBinding,
+	})
+	pipe.Expire(ctx, magicLinkKey(tokenHash), duration)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// ConsumeMagicLink menghapus link jika binding browser cocok agar hanya bisa dipakai sekali. Nilai bool false
+// berarti link ada tetapi diminta dari browser lain, link tersebut tetap berlaku.
+func (magic_link_repo *magicLinkRepository) ConsumeMagicLink(tokenHash string, bindingHash string) (model.MagicLink, bool, error) {
+	ctx := context.Background()
+
+	result, err := consumeMagicLinkScript.Run(ctx, magic_link_repo.redis, []string{magicLinkKey(tokenHash)}, bindingHash).Slice()
+	if err != nil {
+		return model.MagicLink{}, false, err
+	}
+	status, _ := result[0].(int64)
+	userID, _ := result[1].(string)
+	if status == -1 {
+		return model.MagicLink{}, false, errors.New("magic link not found")
+	}
+	if status == 0 {
+		return model.MagicLink{UserID: userID}, false, nil
+	}
+	magic_link_repo.redis.Del(ctx, userMagicLinkKey(userID))
+
+	return model.MagicLink{UserID: userID, BrowserBinding: bindingHash}, true, nil
+}
+
+// AcquireCooldown mengembalikan false jika link baru saja diminta untuk email atau IP yang sama
+func (magic_link_repo *magicLinkRepository) AcquireCooldown(scope string, id string, duration time.Duration) (bool, error) {
+	return magic_link_repo.redis.SetNX(context.Background(), magicLinkCooldownKey(scope, id), 1, duration).Result()
+}
+
+// IncrementDailyCount menambah jumlah link yang diminta untuk email pada hari ini (UTC)
+func (magic_link_repo *magicLinkRepository) IncrementDailyCount(email string) (int64, error) {
+	ctx := context.Background()
+
+	pipe := magic_link_repo.redis.TxPipeline()
+	count := pipe.Incr(ctx, magicLinkDailyKey(email))
+	pipe.Expire(ctx, magicLinkDailyKey(email), 24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
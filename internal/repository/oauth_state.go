@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type OAuthStateRepository interface {
+	SaveState(state string, oauthState model.OAuthState, duration time.Duration) error
+	ConsumeState(state string) (model.OAuthState, error)
+}
+
+type oauthStateRepository struct {
+	redis *redis.Client
+}
+
+func NewOAuthStateRepository(redis *redis.Client) OAuthStateRepository {
+	return &oauthStateRepository{redis}
+}
+
+func oauthStateKey(state string) string {
+	return "oauth_state:" + state
+}
+
+func (state_repo *oauthStateRepository) SaveState(state string, oauthState model.OAuthState, duration time.Duration) error {
+	value, err := json.Marshal(oauthState)
+	if err != nil {
+		return err
+	}
+
+	return state_repo.redis.Set(context.Background(), oauthStateKey(state), value, duration).Err()
+}
+
+// ConsumeState mengambil sekaligus menghapus state agar hanya bisa dipakai sekali
+func (state_repo *oauthStateRepository) ConsumeState(state string) (model.OAuthState, error) {
+	value, err := state_repo.redis.GetDel(context.Background(), oauthStateKey(state)).Bytes()
+	if err == redis.Nil {
+		return model.OAuthState{}, errors.New("oauth state not found")
+	}
+	if err != nil {
+		return model.OAuthState{}, err
+	}
+
+	var oauthState model.OAuthState
+	if err := json.Unmarshal(value, &oauthState); err != nil {
+		return model.OAuthState{}, err
+	}
+
+	return oauthState, nil
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"time"
 
+	"refina-auth/internal/types/model"
+
 	"github.com/go-redis/redis/v8"
 )
 
 type OTPRepository interface {
-	SetOTP(email string, otp string, duration time.Duration) error
-	ValidateOTP(email string, otp string) (bool, error)
+	SetOTP(purpose model.OTPPurpose, email string, otpHash string, duration time.Duration) error
+	ValidateOTP(purpose model.OTPPurpose, email string, otpHash string, maxAttempts int) (bool, error)
+	AcquireResendCooldown(purpose model.OTPPurpose, email string, duration time.Duration) (bool, error)
+	IncrementDailyCount(email string) (int64, error)
 }
 
 type otpRepository struct {
@@ -20,15 +24,76 @@ func NewOTPRepository(redis *redis.Client) OTPRepository {
 	return &otpRepository{redis}
 }
 
-func (otp_repo *otpRepository) SetOTP(email string, otp string, duration time.Duration) error {
-	return otp_repo.redis.Set(context.Background(), email, otp, duration).Err()
+// validateOTPScript membandingkan hash OTP secara atomik. OTP dihapus saat berhasil divalidasi,
+// dan juga dihapus (burned) saat jumlah percobaan gagal mencapai batas.
+var validateOTPScript = redis.NewScript(`
+local otp_hash = redis.call("HGET", KEYS[1], "hash")
+if not otp_hash then
+	return -1
+end
+if otp_hash == ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return 1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts >= tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func otpKey(purpose model.OTPPurpose, email string) string {
+	return "otp:" + string(purpose) + ":" + email
 }
 
-func (otp_repo *otpRepository) ValidateOTP(email string, otp string) (bool, error) {
-	savedOTP, err := otp_repo.redis.Get(context.Background(), email).Result()
+func otpCooldownKey(purpose model.OTPPurpose, email string) string {
+	return "otp_cooldown:" + string(purpose) + ":" + email
+}
+
+func otpDailyKey(email string) string {
+	return "otp_daily:" + time.Now().UTC().Format("2006-01-02") + ":" + email
+}
+
+func (otp_repo *otpRepository) SetOTP(purpose model.OTPPurpose, email string, otpHash string, duration time.Duration) error {
+	ctx := context.Background()
+
+	// OTP BARU MENGGANTIKAN OTP LAMA BESERTA JUMLAH PERCOBAANNYA
+	pipe := otp_repo.redis.TxPipeline()
+	pipe.Del(ctx, otpKey(purpose, email))
+	pipe.HSet(ctx, otpKey(purpose, email), map[string]interface{}{
+		"hash":     otpHash,
+		"attempts": 0,
+	})
+	pipe.Expire(ctx, otpKey(purpose, email), duration)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (otp_repo *otpRepository) ValidateOTP(purpose model.OTPPurpose, email string, otpHash string, maxAttempts int) (bool, error) {
+	result, err := validateOTPScript.Run(context.Background(), otp_repo.redis, []string{otpKey(purpose, email)}, otpHash, maxAttempts).Int64()
 	if err != nil {
 		return false, err
 	}
 
-	return savedOTP == otp, nil
+	return result == 1, nil
+}
+
+// AcquireResendCooldown mengembalikan false jika OTP untuk email dan purpose yang sama baru saja dikirim
+func (otp_repo *otpRepository) AcquireResendCooldown(purpose model.OTPPurpose, email string, duration time.Duration) (bool, error) {
+	return otp_repo.redis.SetNX(context.Background(), otpCooldownKey(purpose, email), 1, duration).Result()
+}
+
+// IncrementDailyCount menambah jumlah OTP yang dikirim ke email pada hari ini (UTC)
+func (otp_repo *otpRepository) IncrementDailyCount(email string) (int64, error) {
+	ctx := context.Background()
+
+	pipe := otp_repo.redis.TxPipeline()
+	count := pipe.Incr(ctx, otpDailyKey(email))
+	pipe.Expire(ctx, otpDailyKey(email), 24*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
 }
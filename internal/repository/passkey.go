@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type PasskeyRepository interface {
+	GetPasskeyByID(id string) (model.UserPasskeys, error)
+	GetPasskeyByCredentialID(credentialID []byte) (model.UserPasskeys, error)
+	GetPasskeysByUserID(userID string) ([]model.UserPasskeys, error)
+	CreatePasskey(passkey model.UserPasskeys) (model.UserPasskeys, error)
+	UpdatePasskey(passkey model.UserPasskeys) (model.UserPasskeys, error)
+	DeletePasskey(passkey model.UserPasskeys) error
+}
+
+type passkeyRepository struct {
+	db *gorm.DB
+}
+
+func NewPasskeyRepository(db *gorm.DB) PasskeyRepository {
+	return &passkeyRepository{db}
+}
+
+func (passkey_repo *passkeyRepository) GetPasskeyByID(id string) (model.UserPasskeys, error) {
+	var passkey model.UserPasskeys
+	err := passkey_repo.db.First(&passkey, "id = ?", id).Error
+	if err != nil {
+		return model.UserPasskeys{}, errors.New("passkey not found")
+	}
+
+	return passkey, nil
+}
+
+func (passkey_repo *passkeyRepository) GetPasskeyByCredentialID(credentialID []byte) (model.UserPasskeys, error) {
+	var passkey model.UserPasskeys
+	err := passkey_repo.db.First(&passkey, "credential_id = ?", credentialID).Error
+	if err != nil {
+		return model.UserPasskeys{}, errors.New("passkey not found")
+	}
+
+	return passkey, nil
+}
+
+func (passkey_repo *passkeyRepository) GetPasskeysByUserID(userID string) ([]model.UserPasskeys, error) {
+	var passkeys []model.UserPasskeys
+	err := passkey_repo.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&passkeys).Error
+	if err != nil {
+		return nil, errors.New("failed to get passkeys")
+	}
+
+	return passkeys, nil
+}
+
+func (passkey_repo *passkeyRepository) CreatePasskey(passkey model.UserPasskeys) (model.UserPasskeys, error) {
+	err := passkey_repo.db.Create(&passkey).Error
+	if err != nil {
+		return model.UserPasskeys{}, errors.New("failed to create passkey")
+	}
+
+	return passkey, nil
+}
+
+func (passkey_repo *passkeyRepository) UpdatePasskey(passkey model.UserPasskeys) (model.UserPasskeys, error) {
+	err := passkey_repo.db.Save(&passkey).Error
+	if err != nil {
+		return model.UserPasskeys{}, errors.New("failed to update passkey")
+	}
+
+	return passkey, nil
+}
+
+// DeletePasskey menghapus permanen karena credential id unik dan authenticator yang sama bisa didaftarkan ulang
+func (passkey_repo *passkeyRepository) DeletePasskey(passkey model.UserPasskeys) error {
+	err := passkey_repo.db.Unscoped().Delete(&passkey).Error
+	if err != nil {
+		return errors.New("failed to delete passkey")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	GetPasswordHistory(userID string, limit int) ([]model.UserPasswordHistories, error)
+	AddPasswordHistory(history model.UserPasswordHistories, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db}
+}
+
+// GetPasswordHistory mengambil hash password lama user, dari yang paling baru
+func (history_repo *passwordHistoryRepository) GetPasswordHistory(userID string, limit int) ([]model.UserPasswordHistories, error) {
+	var histories []model.UserPasswordHistories
+	if limit <= 0 {
+		return histories, nil
+	}
+
+	if err := history_repo.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&histories).Error; err != nil {
+		return nil, errors.New("failed to get password history")
+	}
+
+	return histories, nil
+}
+
+// AddPasswordHistory menyimpan hash password lama lalu menghapus entri yang melebihi jumlah keep dalam satu transaksi
+func (history_repo *passwordHistoryRepository) AddPasswordHistory(history model.UserPasswordHistories, keep int) error {
+	err := history_repo.db.Transaction(func(tx *gorm.DB) error {
+		prune := tx.Unscoped().Where("user_id = ?", history.UserID)
+
+		// KEEP 0 BERARTI HISTORY DINONAKTIFKAN, SELURUH ENTRI LAMA DIHAPUS
+		if keep > 0 {
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+
+			latest := tx.Model(&model.UserPasswordHistories{}).
+				Select("id").
+				Where("user_id = ?", history.UserID).
+				Order("created_at DESC").
+				Limit(keep)
+			prune = prune.Where("id NOT IN (?)", latest)
+		}
+
+		return prune.Delete(&model.UserPasswordHistories{}).Error
+	})
+	if err != nil {
+		return errors.New("failed to save password history")
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/google/uuid"
+)
+
+func addPasswordHistories(t *testing.T, historyRepo PasswordHistoryRepository, userID uuid.UUID, count int, keep int) {
+	t.Helper()
+
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < count; i++ {
+		history := model.UserPasswordHistories{UserID: userID, PasswordHash: fmt.Sprintf("hash-%d", i)}
+		history.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := historyRepo.AddPasswordHistory(history, keep); err != nil {
+			t.Fatalf("AddPasswordHistory: %v", err)
+		}
+	}
+}
+
+func TestAddPasswordHistoryPrunesOldEntries(t *testing.T) {
+	db := newTestDB(t, &model.UserPasswordHistories{})
+	historyRepo := NewPasswordHistoryRepository(db)
+	userID, otherUserID := uuid.New(), uuid.New()
+
+	addPasswordHistories(t, historyRepo, otherUserID, 2, 4)
+	addPasswordHistories(t, historyRepo, userID, 6, 4)
+
+	histories, err := historyRepo.GetPasswordHistory(userID.String(), 10)
+	if err != nil {
+		t.Fatalf("GetPasswordHistory: %v", err)
+	}
+	var hashes []string
+	for _, history := range histories {
+		hashes = append(hashes, history.PasswordHash)
+	}
+	if fmt.Sprint(hashes) != "[hash-5 hash-4 hash-3 hash-2]" {
+		t.Fatalf("history = %v, want the 4 newest entries, newest first", hashes)
+	}
+
+	// ENTRI YANG DIPANGKAS BENAR-BENAR DIHAPUS, BUKAN SOFT DELETE
+	var stored int64
+	if err := db.Unscoped().Model(&model.UserPasswordHistories{}).Where("user_id = ?", userID).Count(&stored).Error; err != nil || stored != 4 {
+		t.Fatalf("stored entries = %d, %v, want 4", stored, err)
+	}
+
+	// HISTORY USER LAIN TIDAK IKUT DIPANGKAS
+	if histories, err := historyRepo.GetPasswordHistory(otherUserID.String(), 10); err != nil || len(histories) != 2 {
+		t.Fatalf("other user history = %d, %v, want 2", len(histories), err)
+	}
+
+	if histories, err := historyRepo.GetPasswordHistory(userID.String(), 2); err != nil || len(histories) != 2 || histories[0].PasswordHash != "hash-5" {
+		t.Fatalf("GetPasswordHistory(limit 2) = %+v, %v", histories, err)
+	}
+}
+
+func TestAddPasswordHistoryDisabledClearsEntries(t *testing.T) {
+	historyRepo := NewPasswordHistoryRepository(newTestDB(t, &model.UserPasswordHistories{}))
+	userID := uuid.New()
+
+	addPasswordHistories(t, historyRepo, userID, 3, 4)
+	addPasswordHistories(t, historyRepo, userID, 1, 0)
+
+	if histories, err := historyRepo.GetPasswordHistory(userID.String(), 10); err != nil || len(histories) != 0 {
+		t.Fatalf("history = %d, %v, want empty when history is disabled", len(histories), err)
+	}
+	if histories, err := historyRepo.GetPasswordHistory(userID.String(), 0); err != nil || len(histories) != 0 {
+		t.Fatalf("GetPasswordHistory(limit 0) = %d, %v, want empty", len(histories), err)
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type PasswordResetRepository interface {
+	SaveResetToken(tokenHash string, userID string, duration time.Duration) error
+	GetResetToken(tokenHash string) (string, error)
+	ConsumeResetToken(tokenHash string) (string, error)
+}
+
+type passwordResetRepository struct {
+	redis *redis.Client
+}
+
+func NewPasswordResetRepository(redis *redis.Client) PasswordResetRepository {
+	return &passwordResetRepository{redis}
+}
+
+func resetTokenKey(tokenHash string) string {
+	return "password_reset:" + tokenHash
+}
+
+func userResetTokenKey(userID string) string {
+	return "password_reset_user:" + userID
+}
+
+// SaveResetToken menyimpan hash token reset dan menghapus token sebelumnya milik user yang sama,
+// sehingga hanya link reset terakhir yang berlaku
+func (reset_repo *passwordResetRepository) SaveResetToken(tokenHash string, userID string, duration time.Duration) error {
+	ctx := context.Background()
+
+	previousHash, err := reset_repo.redis.GetSet(ctx, userResetTokenKey(userID), tokenHash).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := reset_repo.redis.TxPipeline()
+	if previousHash != "" {
+		pipe.Del(ctx, resetTokenKey(previousHash))
+	}
+	pipe.Expire(ctx, userResetTokenKey(userID), duration)
+	pipe.Set(ctx, resetTokenKey(tokenHash), userID, duration)
+	_, err = pipe.Exec(ctx)
+
+	return err
+}
+
+// GetResetToken mengambil user id pemilik token tanpa menghapusnya, dipakai untuk validasi sebelum token dipakai
+func (reset_repo *passwordResetRepository) GetResetToken(tokenHash string) (string, error) {
+	userID, err := reset_repo.redis.Get(context.Background(), resetTokenKey(tokenHash)).Result()
+	if err == redis.Nil {
+		return "", errors.New("reset token not found")
+	}
+
+	return userID, err
+}
+
+// ConsumeResetToken mengambil sekaligus menghapus token reset agar hanya bisa dipakai sekali
+func (reset_repo *passwordResetRepository) ConsumeResetToken(tokenHash string) (string, error) {
+	ctx := context.Background()
+
+	userID, err := reset_repo.redis.GetDel(ctx, resetTokenKey(tokenHash)).Result()
+	if err == redis.Nil {
+		return "", errors.New("reset token not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	reset_repo.redis.Del(ctx, userResetTokenKey(userID))
+
+	return userID, nil
+}
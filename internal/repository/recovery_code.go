@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceRecoveryCodes(userID string, codes []model.UserRecoveryCodes) error
+	UseRecoveryCode(userID string, codeHash string) error
+	CountUnusedRecoveryCodes(userID string) (int64, error)
+	DeleteRecoveryCodes(userID string) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db}
+}
+
+// ReplaceRecoveryCodes menghapus seluruh recovery code lama milik user lalu menyimpan yang baru dalam satu transaksi
+func (recovery_repo *recoveryCodeRepository) ReplaceRecoveryCodes(userID string, codes []model.UserRecoveryCodes) error {
+	err := recovery_repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.UserRecoveryCodes{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return errors.New("failed to save recovery codes")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode menandai recovery code sudah dipakai. Update bersyarat used_at IS NULL memastikan
+// kode yang sama tidak bisa dipakai dua kali walaupun request dikirim bersamaan.
+func (recovery_repo *recoveryCodeRepository) UseRecoveryCode(userID string, codeHash string) error {
+	result := recovery_repo.db.Model(&model.UserRecoveryCodes{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return errors.New("failed to use recovery code")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("recovery code not found")
+	}
+
+	return nil
+}
+
+func (recovery_repo *recoveryCodeRepository) CountUnusedRecoveryCodes(userID string) (int64, error) {
+	var count int64
+	err := recovery_repo.db.Model(&model.UserRecoveryCodes{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	if err != nil {
+		return 0, errors.New("failed to count recovery codes")
+	}
+
+	return count, nil
+}
+
+func (recovery_repo *recoveryCodeRepository) DeleteRecoveryCodes(userID string) error {
+	err := recovery_repo.db.Unscoped().Where("user_id = ?", userID).Delete(&model.UserRecoveryCodes{}).Error
+	if err != nil {
+		return errors.New("failed to delete recovery codes")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"testing"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/google/uuid"
+)
+
+func testRecoveryCodes(userID uuid.UUID, hashes ...string) []model.UserRecoveryCodes {
+	codes := make([]model.UserRecoveryCodes, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, model.UserRecoveryCodes{UserID: userID, CodeHash: hash})
+	}
+
+	return codes
+}
+
+func TestUseRecoveryCodeOnce(t *testing.T) {
+	recoveryRepo := NewRecoveryCodeRepository(newTestDB(t, &model.UserRecoveryCodes{}))
+	userID := uuid.New()
+
+	if err := recoveryRepo.ReplaceRecoveryCodes(userID.String(), testRecoveryCodes(userID, "hash-1", "hash-2")); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes: %v", err)
+	}
+
+	if err := recoveryRepo.UseRecoveryCode(userID.String(), "hash-1"); err != nil {
+		t.Fatalf("UseRecoveryCode: %v", err)
+	}
+	if err := recoveryRepo.UseRecoveryCode(userID.String(), "hash-1"); err == nil {
+		t.Fatal("recovery code was used twice")
+	}
+	// RECOVERY CODE HANYA BERLAKU UNTUK PEMILIKNYA
+	if err := recoveryRepo.UseRecoveryCode(uuid.NewString(), "hash-2"); err == nil {
+		t.Fatal("recovery code of another user was accepted")
+	}
+
+	if count, err := recoveryRepo.CountUnusedRecoveryCodes(userID.String()); err != nil || count != 1 {
+		t.Fatalf("CountUnusedRecoveryCodes = %d, %v; want 1", count, err)
+	}
+}
+
+func TestReplaceRecoveryCodesInvalidatesPreviousCodes(t *testing.T) {
+	recoveryRepo := NewRecoveryCodeRepository(newTestDB(t, &model.UserRecoveryCodes{}))
+	userID := uuid.New()
+	otherUserID := uuid.New()
+
+	if err := recoveryRepo.ReplaceRecoveryCodes(userID.String(), testRecoveryCodes(userID, "old-1", "old-2")); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes: %v", err)
+	}
+	if err := recoveryRepo.ReplaceRecoveryCodes(otherUserID.String(), testRecoveryCodes(otherUserID, "other-1")); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes: %v", err)
+	}
+	if err := recoveryRepo.ReplaceRecoveryCodes(userID.String(), testRecoveryCodes(userID, "new-1")); err != nil {
+		t.Fatalf("ReplaceRecoveryCodes: %v", err)
+	}
+
+	if err := recoveryRepo.UseRecoveryCode(userID.String(), "old-1"); err == nil {
+		t.Fatal("replaced recovery code was accepted")
+	}
+	if err := recoveryRepo.UseRecoveryCode(userID.String(), "new-1"); err != nil {
+		t.Fatalf("UseRecoveryCode(new-1): %v", err)
+	}
+
+	if err := recoveryRepo.DeleteRecoveryCodes(userID.String()); err != nil {
+		t.Fatalf("DeleteRecoveryCodes: %v", err)
+	}
+	if count, _ := recoveryRepo.CountUnusedRecoveryCodes(otherUserID.String()); count != 1 {
+		t.Fatalf("recovery codes of another user were touched, %d left", count)
+	}
+}
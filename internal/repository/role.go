@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	GetAllRoles() ([]model.Roles, error)
+	GetRoleByName(name string) (model.Roles, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db}
+}
+
+func (role_repo *roleRepository) GetAllRoles() ([]model.Roles, error) {
+	var roles []model.Roles
+	err := role_repo.db.Preload("Permissions").Find(&roles).Error
+	if err != nil {
+		return nil, errors.New("failed to get roles")
+	}
+
+	return roles, nil
+}
+
+func (role_repo *roleRepository) GetRoleByName(name string) (model.Roles, error) {
+	var role model.Roles
+	err := role_repo.db.Preload("Permissions").First(&role, "name = ?", name).Error
+	if err != nil {
+		return model.Roles{}, errors.New("role not found")
+	}
+
+	return role, nil
+}
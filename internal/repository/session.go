@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	CreateSession(session model.UserSessions) (model.UserSessions, error)
+	GetSessionByID(id string) (model.UserSessions, error)
+	GetActiveSessions(userID string) ([]model.UserSessions, error)
+	TouchSession(id string, ipAddress string, lastSeenAt time.Time, expiresAt time.Time) error
+	RevokeSession(id string) error
+	RevokeUserSessions(userID string) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db}
+}
+
+func (session_repo *sessionRepository) CreateSession(session model.UserSessions) (model.UserSessions, error) {
+	err := session_repo.db.Create(&session).Error
+	if err != nil {
+		return model.UserSessions{}, errors.New("failed to create session")
+	}
+
+	return session, nil
+}
+
+func (session_repo *sessionRepository) GetSessionByID(id string) (model.UserSessions, error) {
+	var session model.UserSessions
+	err := session_repo.db.First(&session, "id = ?", id).Error
+	if err != nil {
+		return model.UserSessions{}, errors.New("session not found")
+	}
+
+	return session, nil
+}
+
+// GetActiveSessions mengambil session yang belum dicabut dan refresh token-nya belum expired, dari yang terakhir aktif
+func (session_repo *sessionRepository) GetActiveSessions(userID string) ([]model.UserSessions, error) {
+	var sessions []model.UserSessions
+	err := session_repo.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, errors.New("failed to get sessions")
+	}
+
+	return sessions, nil
+}
+
+// TouchSession memperbarui waktu terakhir aktif dan IP session setiap kali refresh token dipakai
+func (session_repo *sessionRepository) TouchSession(id string, ipAddress string, lastSeenAt time.Time, expiresAt time.Time) error {
+	err := session_repo.db.Model(&model.UserSessions{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"ip_address":   ipAddress,
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		}).Error
+	if err != nil {
+		return errors.New("failed to update session")
+	}
+
+	return nil
+}
+
+func (session_repo *sessionRepository) RevokeSession(id string) error {
+	err := session_repo.db.Model(&model.UserSessions{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return errors.New("failed to revoke session")
+	}
+
+	return nil
+}
+
+func (session_repo *sessionRepository) RevokeUserSessions(userID string) error {
+	err := session_repo.db.Model(&model.UserSessions{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+
+	return nil
+}
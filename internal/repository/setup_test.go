@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const sqliteDriver = "sqlite3_refina_test"
+
+var registerDriver sync.Once
+
+var sqliteDDL = strings.NewReplacer(
+	"DEFAULT uuid_generate_v4()", "DEFAULT (uuid_generate_v4())",
+	"timestamptz", "timestamp",
+)
+
+// newTestRedis menjalankan miniredis untuk satu test
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// newTestDB membuat database SQLite sementara dan membuat tabel untuk models. Fungsi uuid_generate_v4 dan
+// pg_advisory_xact_lock milik postgres diganti dengan fungsi Go agar default kolom dan query repository tetap jalan.
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	registerDriver.Do(func() {
+		sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				if err := conn.RegisterFunc("uuid_generate_v4", uuid.NewString, false); err != nil {
+					return err
+				}
+				return conn.RegisterFunc("pg_advisory_xact_lock", func(int64) int64 { return 0 }, false)
+			},
+		})
+	})
+
+	db, err := gorm.Open(sqlite.New(sqlite.Config{
+		DriverName: sqliteDriver,
+		DSN:        "file:" + filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_txlock=immediate",
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// SQLITE HANYA MENERIMA FUNGSI SEBAGAI DEFAULT KOLOM JIKA DIBUNGKUS KURUNG, DAN DRIVER HANYA MENGEMBALIKAN
+	// time.Time UNTUK KOLOM BERTIPE timestamp
+	if err := db.Callback().Raw().Before("gorm:raw").Register("test:sqlite_ddl", func(tx *gorm.DB) {
+		query := tx.Statement.SQL.String()
+		if rewritten := sqliteDDL.Replace(query); rewritten != query {
+			tx.Statement.SQL.Reset()
+			tx.Statement.SQL.WriteString(rewritten)
+		}
+	}); err != nil {
+		t.Fatalf("register sqlite callback: %v", err)
+	}
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"gorm.io/gorm"
+)
+
+// signingKeyRotationLock - id advisory lock postgres agar hanya satu replica yang merotasi key
+const signingKeyRotationLock = 7236105
+
+type SigningKeyRepository interface {
+	GetVerificationKeys(rotatedSince time.Time) ([]model.SigningKeys, error)
+	RotateSigningKey(newKey model.SigningKeys, rotateBefore time.Time) (bool, error)
+	DeleteRotatedKeys(rotatedBefore time.Time) error
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db}
+}
+
+func (key_repo *signingKeyRepository) GetVerificationKeys(rotatedSince time.Time) ([]model.SigningKeys, error) {
+	var keys []model.SigningKeys
+	err := key_repo.db.
+		Where("rotated_at IS NULL OR rotated_at > ?", rotatedSince).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, errors.New("failed to get signing keys")
+	}
+
+	return keys, nil
+}
+
+// RotateSigningKey menyimpan key baru dan menandai key aktif sebelumnya sebagai rotated mulai newKey.ActivatesAt,
+// sehingga key lama tetap dipakai untuk sign sampai key baru aktif.
+// Rotasi dilewati (false) jika sudah ada key aktif dengan algoritma yang sama yang dibuat setelah rotateBefore,
+// misalnya karena replica lain sudah melakukan rotasi lebih dulu.
+func (key_repo *signingKeyRepository) RotateSigningKey(newKey model.SigningKeys, rotateBefore time.Time) (bool, error) {
+	rotated := false
+	err := key_repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyRotationLock).Error; err != nil {
+			return err
+		}
+
+		var freshKeys int64
+		if err := tx.Model(&model.SigningKeys{}).
+			Where("rotated_at IS NULL AND algorithm = ? AND created_at > ?", newKey.Algorithm, rotateBefore).
+			Count(&freshKeys).Error; err != nil {
+			return err
+		}
+		if freshKeys > 0 {
+			return nil
+		}
+
+		if err := tx.Model(&model.SigningKeys{}).
+			Where("rotated_at IS NULL").
+			Update("rotated_at", newKey.ActivatesAt).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&newKey).Error; err != nil {
+			return err
+		}
+
+		rotated = true
+		return nil
+	})
+	if err != nil {
+		return false, errors.New("failed to rotate signing key")
+	}
+
+	return rotated, nil
+}
+
+func (key_repo *signingKeyRepository) DeleteRotatedKeys(rotatedBefore time.Time) error {
+	err := key_repo.db.Unscoped().
+		Where("rotated_at IS NOT NULL AND rotated_at <= ?", rotatedBefore).
+		Delete(&model.SigningKeys{}).Error
+	if err != nil {
+		return errors.New("failed to delete rotated signing keys")
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type TokenRepository interface {
+	SaveRefreshToken(tokenHash string, token model.RefreshToken, duration time.Duration) error
+	GetRefreshToken(tokenHash string) (model.RefreshToken, error)
+	UseRefreshToken(tokenHash string) (bool, error)
+	RevokeTokenFamily(familyID string) error
+	RevokeUserTokenFamilies(userID string) error
+	RevokeAccessToken(jti string, duration time.Duration) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	RevokeUserAccessTokens(userID string, revokedAt time.Time, duration time.Duration) error
+	GetUserTokensRevokedAt(userID string) (int64, error)
+	RevokeSessionAccessTokens(sessionID string, duration time.Duration) error
+	IsSessionRevoked(sessionID string) (bool, error)
+	SaveAuthCode(codeHash string, userID string, duration time.Duration) error
+	ConsumeAuthCode(codeHash string) (string, error)
+}
+
+type tokenRepository struct {
+	redis *redis.Client
+}
+
+func NewTokenRepository(redis *redis.Client) TokenRepository {
+	return &tokenRepository{redis}
+}
+
+// useRefreshTokenScript hanya menaikkan counter "used" jika token masih ada,
+// sehingga token yang sudah expired tidak terbuat ulang tanpa TTL
+var useRefreshTokenScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+return redis.call("HINCRBY", KEYS[1], "used", 1)
+`)
+
+func refreshTokenKey(tokenHash string) string {
+	return "refresh_token:" + tokenHash
+}
+
+func tokenFamilyKey(familyID string) string {
+	return "refresh_family:" + familyID
+}
+
+func userFamiliesKey(userID string) string {
+	return "refresh_user:" + userID
+}
+
+func revokedAccessTokenKey(jti string) string {
+	return "denylist:jti:" + jti
+}
+
+func revokedUserKey(userID string) string {
+	return "denylist:user:" + userID
+}
+
+func revokedSessionKey(sessionID string) string {
+	return "denylist:sid:" + sessionID
+}
+
+func authCodeKey(codeHash string) string {
+	return "auth_code:" + codeHash
+}
+
+func (token_repo *tokenRepository) SaveRefreshToken(tokenHash string, token model.RefreshToken, duration time.Duration) error {
+	ctx := context.Background()
+
+	pipe := token_repo.redis.TxPipeline()
+	pipe.HSet(ctx, refreshTokenKey(tokenHash), map[string]interface{}{
+		"user_id":   token.UserID,
+		"family_id": token.FamilyID,
+		"used":      0,
+	})
+	pipe.Expire(ctx, refreshTokenKey(tokenHash), duration)
+	pipe.SAdd(ctx, tokenFamilyKey(token.FamilyID), tokenHash)
+	pipe.Expire(ctx, tokenFamilyKey(token.FamilyID), duration)
+	pipe.SAdd(ctx, userFamiliesKey(token.UserID), token.FamilyID)
+	pipe.Expire(ctx, userFamiliesKey(token.UserID), duration)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (token_repo *tokenRepository) GetRefreshToken(tokenHash string) (model.RefreshToken, error) {
+	values, err := token_repo.redis.HGetAll(context.Background(), refreshTokenKey(tokenHash)).Result()
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+	if len(values) == 0 {
+		return model.RefreshToken{}, errors.New("refresh token not found")
+	}
+
+	return model.RefreshToken{
+		UserID:   values["user_id"],
+		FamilyID: values["family_id"],
+		Used:     values["used"] != "0",
+	}, nil
+}
+
+// UseRefreshToken menandai refresh token sebagai sudah dipakai secara atomik.
+// Mengembalikan false jika token tersebut sudah pernah dipakai sebelumnya.
+func (token_repo *tokenRepository) UseRefreshToken(tokenHash string) (bool, error) {
+	used, err := useRefreshTokenScript.Run(context.Background(), token_repo.redis, []string{refreshTokenKey(tokenHash)}).Int64()
+	if err != nil {
+		return false, err
+	}
+	if used < 0 {
+		return false, errors.New("refresh token not found")
+	}
+
+	return used == 1, nil
+}
+
+func (token_repo *tokenRepository) RevokeTokenFamily(familyID string) error {
+	ctx := context.Background()
+
+	tokenHashes, err := token_repo.redis.SMembers(ctx, tokenFamilyKey(familyID)).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{tokenFamilyKey(familyID)}
+	for _, tokenHash := range tokenHashes {
+		keys = append(keys, refreshTokenKey(tokenHash))
+	}
+
+	return token_repo.redis.Del(ctx, keys...).Err()
+}
+
+func (token_repo *tokenRepository) RevokeUserTokenFamilies(userID string) error {
+	ctx := context.Background()
+
+	familyIDs, err := token_repo.redis.SMembers(ctx, userFamiliesKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, familyID := range familyIDs {
+		if err := token_repo.RevokeTokenFamily(familyID); err != nil {
+			return err
+		}
+	}
+
+	return token_repo.redis.Del(ctx, userFamiliesKey(userID)).Err()
+}
+
+func (token_repo *tokenRepository) RevokeAccessToken(jti string, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	return token_repo.redis.Set(context.Background(), revokedAccessTokenKey(jti), 1, duration).Err()
+}
+
+func (token_repo *tokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	exists, err := token_repo.redis.Exists(context.Background(), revokedAccessTokenKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return exists > 0, nil
+}
+
+func (token_repo *tokenRepository) RevokeUserAccessTokens(userID string, revokedAt time.Time, duration time.Duration) error {
+	return token_repo.redis.Set(context.Background(), revokedUserKey(userID), revokedAt.UnixMicro(), duration).Err()
+}
+
+// GetUserTokensRevokedAt mengembalikan unix timestamp (mikrodetik) terakhir kali seluruh token user dicabut,
+// atau 0 jika tidak ada pencabutan yang masih berlaku.
+func (token_repo *tokenRepository) GetUserTokensRevokedAt(userID string) (int64, error) {
+	revokedAt, err := token_repo.redis.Get(context.Background(), revokedUserKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return revokedAt, nil
+}
+
+// RevokeSessionAccessTokens mencabut seluruh access token yang diterbitkan untuk satu session, cukup disimpan
+// selama umur access token karena refresh token family session tersebut sudah dihapus
+func (token_repo *tokenRepository) RevokeSessionAccessTokens(sessionID string, duration time.Duration) error {
+	return token_repo.redis.Set(context.Background(), revokedSessionKey(sessionID), 1, duration).Err()
+}
+
+func (token_repo *tokenRepository) IsSessionRevoked(sessionID string) (bool, error) {
+	exists, err := token_repo.redis.Exists(context.Background(), revokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return exists > 0, nil
+}
+
+func (token_repo *tokenRepository) SaveAuthCode(codeHash string, userID string, duration time.Duration) error {
+	return token_repo.redis.Set(context.Background(), authCodeKey(codeHash), userID, duration).Err()
+}
+
+// ConsumeAuthCode mengambil sekaligus menghapus authorization code agar hanya bisa ditukar sekali
+func (token_repo *tokenRepository) ConsumeAuthCode(codeHash string) (string, error) {
+	userID, err := token_repo.redis.GetDel(context.Background(), authCodeKey(codeHash)).Result()
+	if err == redis.Nil {
+		return "", errors.New("authorization code not found")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"refina-auth/internal/types/model"
+)
+
+func TestUseRefreshTokenMarksTokenOnce(t *testing.T) {
+	redisClient := newTestRedis(t)
+	tokenRepo := NewTokenRepository(redisClient)
+
+	if err := tokenRepo.SaveRefreshToken("hash", model.RefreshToken{UserID: "user", FamilyID: "family"}, time.Hour); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+
+	firstUse, err := tokenRepo.UseRefreshToken("hash")
+	if err != nil || !firstUse {
+		t.Fatalf("first UseRefreshToken = %v, %v; want true, nil", firstUse, err)
+	}
+	secondUse, err := tokenRepo.UseRefreshToken("hash")
+	if err != nil || secondUse {
+		t.Fatalf("second UseRefreshToken = %v, %v; want false, nil", secondUse, err)
+	}
+}
+
+func TestUseRefreshTokenDoesNotRecreateExpiredToken(t *testing.T) {
+	redisClient := newTestRedis(t)
+	tokenRepo := NewTokenRepository(redisClient)
+
+	if _, err := tokenRepo.UseRefreshToken("missing"); err == nil {
+		t.Fatal("UseRefreshToken on a missing token returned no error")
+	}
+
+	exists, err := redisClient.Exists(context.Background(), refreshTokenKey("missing")).Result()
+	if err != nil {
+		t.Fatalf("Exists: %v", err)
+	}
+	if exists != 0 {
+		t.Fatal("UseRefreshToken recreated a missing token without TTL")
+	}
+}
+
+func TestRevokeUserTokenFamilies(t *testing.T) {
+	redisClient := newTestRedis(t)
+	tokenRepo := NewTokenRepository(redisClient)
+
+	for hash, family := range map[string]string{"a": "family-1", "b": "family-1", "c": "family-2"} {
+		if err := tokenRepo.SaveRefreshToken(hash, model.RefreshToken{UserID: "user", FamilyID: family}, time.Hour); err != nil {
+			t.Fatalf("SaveRefreshToken: %v", err)
+		}
+	}
+	if err := tokenRepo.SaveRefreshToken("other", model.RefreshToken{UserID: "other-user", FamilyID: "family-3"}, time.Hour); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+
+	if err := tokenRepo.RevokeUserTokenFamilies("user"); err != nil {
+		t.Fatalf("RevokeUserTokenFamilies: %v", err)
+	}
+
+	for _, hash := range []string{"a", "b", "c"} {
+		if _, err := tokenRepo.GetRefreshToken(hash); err == nil {
+			t.Fatalf("refresh token %q survived RevokeUserTokenFamilies", hash)
+		}
+	}
+	if _, err := tokenRepo.GetRefreshToken("other"); err != nil {
+		t.Fatalf("refresh token of another user was revoked: %v", err)
+	}
+}
+
+func TestGetUserTokensRevokedAtReturnsMicroseconds(t *testing.T) {
+	redisClient := newTestRedis(t)
+	tokenRepo := NewTokenRepository(redisClient)
+
+	revokedAt := time.UnixMicro(1760000000123456)
+	if err := tokenRepo.RevokeUserAccessTokens("user", revokedAt, time.Hour); err != nil {
+		t.Fatalf("RevokeUserAccessTokens: %v", err)
+	}
+	got, err := tokenRepo.GetUserTokensRevokedAt("user")
+	if err != nil || got != revokedAt.UnixMicro() {
+		t.Fatalf("GetUserTokensRevokedAt = %d, %v; want %d", got, err, revokedAt.UnixMicro())
+	}
+
+	got, err = tokenRepo.GetUserTokensRevokedAt("missing")
+	if err != nil || got != 0 {
+		t.Fatalf("GetUserTokensRevokedAt(missing) = %d, %v; want 0", got, err)
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"refina-auth/internal/types/model"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type TwoFactorRepository interface {
+	SaveChallenge(challengeHash string, challenge model.TwoFactorChallenge, duration time.Duration) error
+	GetChallenge(challengeHash string) (model.TwoFactorChallenge, error)
+	FailChallenge(challengeHash string, maxAttempts int) error
+	DeleteChallenge(challengeHash string) error
+	MarkCodeUsed(userID string, counter int64, duration time.Duration) (bool, error)
+	IncrementFailedAttempts(userID string, duration time.Duration) (int64, error)
+	ResetFailedAttempts(userID string) error
+}
+
+type twoFactorRepository struct {
+	redis *redis.Client
+}
+
+func NewTwoFactorRepository(redis *redis.Client) TwoFactorRepository {
+	return &twoFactorRepository{redis}
+}
+
+// failChallengeScript menaikkan jumlah percobaan gagal dan menghapus challenge saat mencapai batas
+var failChallengeScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts >= tonumber(ARGV[1]) then
+	redis.call("DEL", KEYS[1])
+end
+return attempts
+`)
+
+// incrementAttemptsScript menaikkan counter dan memasang TTL hanya pada percobaan pertama,
+// sehingga window tidak diperpanjang oleh percobaan berikutnya
+var incrementAttemptsScript = redis.NewScript(`
+local attempts = redis.call("INCR", KEYS[1])
+if attempts == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return attempts
+`)
+
+func twoFactorChallengeKey(challengeHash string) string {
+	return "2fa_challenge:" + challengeHash
+}
+
+func twoFactorAttemptsKey(userID string) string {
+	return "2fa_attempts:" + userID
+}
+
+func usedTOTPKey(userID string, counter int64) string {
+	return "2fa_used:" + userID + ":" + strconv.FormatInt(counter, 10)
+}
+
+func (two_factor_repo *twoFactorRepository) SaveChallenge(challengeHash string, challenge model.TwoFactorChallenge, duration time.Duration) error {
+	ctx := context.Background()
+
+	pipe := two_factor_repo.redis.TxPipeline()
+	pipe.HSet(ctx, twoFactorChallengeKey(challengeHash), map[string]interface{}{
+		"user_id":      challenge.UserID,
+		"login_method": challenge.LoginMethod,
+		"attempts":     0,
+	})
+	pipe.Expire(ctx, twoFactorChallengeKey(challengeHash), duration)
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (two_factor_repo *twoFactorRepository) GetChallenge(challengeHash string) (model.TwoFactorChallenge, error) {
+	values, err := two_factor_repo.redis.HGetAll(context.Background(), twoFactorChallengeKey(challengeHash)).Result()
+	if err != nil {
+		return model.TwoFactorChallenge{}, err
+	}
+	if len(values) == 0 {
+		return model.TwoFactorChallenge{}, errors.New("2fa challenge not found")
+	}
+
+	return model.TwoFactorChallenge{
+		UserID:      values["user_id"],
+		LoginMethod: values["login_method"],
+	}, nil
+}
+
+func (two_factor_repo *twoFactorRepository) FailChallenge(challengeHash string, maxAttempts int) error {
+	return failChallengeScript.Run(context.Background(), two_factor_repo.redis, []string{twoFactorChallengeKey(challengeHash)}, maxAttempts).Err()
+}
+
+func (two_factor_repo *twoFactorRepository) DeleteChallenge(challengeHash string) error {
+	return two_factor_repo.redis.Del(context.Background(), twoFactorChallengeKey(challengeHash)).Err()
+}
+
+// MarkCodeUsed mencatat time step TOTP yang sudah dipakai user, mengembalikan false jika sudah pernah dipakai (replay)
+func (two_factor_repo *twoFactorRepository) MarkCodeUsed(userID string, counter int64, duration time.Duration) (bool, error) {
+	return two_factor_repo.redis.SetNX(context.Background(), usedTOTPKey(userID, counter), 1, duration).Result()
+}
+
+// IncrementFailedAttempts menaikkan jumlah percobaan kode 2FA user dan mengembalikan jumlahnya. Dipanggil sebelum
+// kode divalidasi sehingga request paralel tidak bisa melewati batas.
+func (two_factor_repo *twoFactorRepository) IncrementFailedAttempts(userID string, duration time.Duration) (int64, error) {
+	return incrementAttemptsScript.Run(context.Background(), two_factor_repo.redis, []string{twoFactorAttemptsKey(userID)}, duration.Milliseconds()).Int64()
+}
+
+func (two_factor_repo *twoFactorRepository) ResetFailedAttempts(userID string) error {
+	return two_factor_repo.redis.Del(context.Background(), twoFactorAttemptsKey(userID)).Err()
+}
@@ -12,6 +12,7 @@ type UsersRepository interface {
 	GetAllUsers() ([]model.Users, error)
 	GetUserByID(id string) (model.Users, error)
 	GetUserByEmail(email string) (model.Users, error)
+	GetUserByNormalizedEmail(email string) (model.Users, error)
 	CreateUser(user model.Users) (model.Users, error)
 	UpdateUser(user model.Users) (model.Users, error)
 	DeleteUser(user model.Users) (model.Users, error)
@@ -55,6 +56,17 @@ func (user_repo *usersRepository) GetUserByEmail(email string) (model.Users, err
 	return user, nil
 }
 
+// GetUserByNormalizedEmail - mencari user tanpa membedakan huruf besar/kecil, email harus sudah dinormalisasi
+func (user_repo *usersRepository) GetUserByNormalizedEmail(email string) (model.Users, error) {
+	var user model.Users
+	err := user_repo.db.First(&user, "LOWER(email) = ?", email).Error
+	if err != nil {
+		return model.Users{}, errors.New("user not found")
+	}
+
+	return user, nil
+}
+
 func (user_repo *usersRepository) CreateUser(user model.Users) (model.Users, error) {
 	err := user_repo.db.Create(&user).Error
 	if err != nil {
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+type WebAuthnSessionRepository interface {
+	SaveSession(key string, session webauthn.SessionData, duration time.Duration) error
+	ConsumeSession(key string) (webauthn.SessionData, error)
+}
+
+type webAuthnSessionRepository struct {
+	redis *redis.Client
+}
+
+func NewWebAuthnSessionRepository(redis *redis.Client) WebAuthnSessionRepository {
+	return &webAuthnSessionRepository{redis}
+}
+
+func webAuthnSessionKey(key string) string {
+	return "webauthn_session:" + key
+}
+
+func (session_repo *webAuthnSessionRepository) SaveSession(key string, session webauthn.SessionData, duration time.Duration) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return session_repo.redis.Set(context.Background(), webAuthnSessionKey(key), value, duration).Err()
+}
+
+// ConsumeSession mengambil sekaligus menghapus challenge agar setiap ceremony hanya bisa diselesaikan sekali
+func (session_repo *webAuthnSessionRepository) ConsumeSession(key string) (webauthn.SessionData, error) {
+	value, err := session_repo.redis.GetDel(context.Background(), webAuthnSessionKey(key)).Bytes()
+	if err == redis.Nil {
+		return webauthn.SessionData{}, errors.New("webauthn session not found")
+	}
+	if err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(value, &session); err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	return session, nil
+}
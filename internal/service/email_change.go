@@ -0,0 +1,181 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+)
+
+type EmailChangeService interface {
+	RequestEmailChange(userID string, newEmail string) error
+	ConfirmEmailChange(token string) (dto.UsersResponse, error)
+	UndoEmailChange(token string) error
+}
+
+type emailChangeService struct {
+	userRepository        repository.UsersRepository
+	emailChangeRepository repository.EmailChangeRepository
+	tokenService          TokenService
+	smtpClient            helper.SMTPClientInterface
+}
+
+func NewEmailChangeService(userRepository repository.UsersRepository, emailChangeRepository repository.EmailChangeRepository, tokenService TokenService, smtpClient helper.SMTPClientInterface) EmailChangeService {
+	return &emailChangeService{
+		userRepository:        userRepository,
+		emailChangeRepository: emailChangeRepository,
+		tokenService:          tokenService,
+		smtpClient:            smtpClient,
+	}
+}
+
+// RequestEmailChange menyimpan email baru sebagai pending dan mengirim link konfirmasi ke email baru.
+// Email user belum berubah sampai link tersebut dikonfirmasi.
+func (email_serv *emailChangeService) RequestEmailChange(userID string, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+
+	// VALIDASI UNTUK FORMAT EMAIL SUDAH BENAR
+	if !helper.EmailValidator(newEmail) {
+		return errors.New("please enter a valid email address")
+	}
+
+	user, err := email_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return errors.New("new email must be different from the current email")
+	}
+
+	// MENGECEK APAKAH EMAIL SUDAH DIGUNAKAN
+	if _, err := email_serv.userRepository.GetUserByEmail(newEmail); err == nil {
+		return errors.New("email already in use by another user")
+	}
+
+	token, err := secrets.Token()
+	if err != nil {
+		return err
+	}
+
+	if err := email_serv.emailChangeRepository.SavePendingChange(helper.HashToken(token), model.EmailChange{
+		UserID:   userID,
+		OldEmail: user.Email,
+		NewEmail: newEmail,
+	}, data.EMAIL_CHANGE_TTL); err != nil {
+		return err
+	}
+
+	return email_serv.smtpClient.SendSingleEmail(newEmail, "Confirm Your New Email", "email-change-confirm-email-template.html", data.EmailChangeEmail{
+		Name:      user.Name,
+		OldEmail:  user.Email,
+		NewEmail:  newEmail,
+		URL:       env.Cfg.Client.Url + "/confirm-email?token=" + token,
+		ExpiresIn: int(data.EMAIL_CHANGE_TTL.Minutes()),
+	})
+}
+
+func (email_serv *emailChangeService) ConfirmEmailChange(token string) (dto.UsersResponse, error) {
+	// TOKEN DIHAPUS SAAT DIAMBIL SEHINGGA HANYA BISA DIPAKAI SEKALI
+	emailChange, err := email_serv.emailChangeRepository.ConsumePendingChange(helper.HashToken(token))
+	if err != nil {
+		return dto.UsersResponse{}, errors.New("confirmation link is invalid or expired")
+	}
+
+	user, err := email_serv.userRepository.GetUserByID(emailChange.UserID)
+	if err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	// EMAIL SUDAH BERUBAH SEJAK PERMINTAAN DIBUAT
+	if user.Email != emailChange.OldEmail {
+		return dto.UsersResponse{}, errors.New("confirmation link is no longer valid")
+	}
+
+	// MENGECEK ULANG APAKAH EMAIL BARU SUDAH DIGUNAKAN SELAMA MENUNGGU KONFIRMASI
+	if _, err := email_serv.userRepository.GetUserByEmail(emailChange.NewEmail); err == nil {
+		return dto.UsersResponse{}, errors.New("email already in use by another user")
+	}
+
+	user.Email = emailChange.NewEmail
+	user.EmailVerfiedAt = sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	}
+	userUpdated, err := email_serv.userRepository.UpdateUser(user)
+	if err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	// MENCABUT ACCESS TOKEN LAMA AGAR CLAIM EMAIL LAMA TIDAK BERLAKU LAGI
+	if err := email_serv.tokenService.RevokeAccessTokens(emailChange.UserID); err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	// MENGIRIM NOTIFIKASI KE EMAIL LAMA BESERTA LINK UNTUK MEMBATALKAN PERUBAHAN
+	email_serv.sendChangeNotice(userUpdated.Name, emailChange)
+
+	return helper.ConvertToResponseType(userUpdated).(dto.UsersResponse), nil
+}
+
+func (email_serv *emailChangeService) sendChangeNotice(name string, emailChange model.EmailChange) {
+	undoToken, err := secrets.Token()
+	if err != nil {
+		log.Error("Failed to generate email change undo token: " + err.Error())
+		return
+	}
+
+	if err := email_serv.emailChangeRepository.SaveUndoToken(helper.HashToken(undoToken), emailChange, data.EMAIL_CHANGE_UNDO_TTL); err != nil {
+		log.Error("Failed to save email change undo token: " + err.Error())
+		return
+	}
+
+	if err := email_serv.smtpClient.SendSingleEmail(emailChange.OldEmail, "Your Email Has Been Changed", "email-change-notice-email-template.html", data.EmailChangeEmail{
+		Name:      name,
+		OldEmail:  emailChange.OldEmail,
+		NewEmail:  emailChange.NewEmail,
+		URL:       env.Cfg.Client.Url + "/undo-email-change?token=" + undoToken,
+		ExpiresIn: int(data.EMAIL_CHANGE_UNDO_TTL.Hours() / 24),
+	}); err != nil {
+		log.Error("Failed to send email change notice: "+err.Error(), map[string]interface{}{"user_id": emailChange.UserID})
+	}
+}
+
+// UndoEmailChange mengembalikan email lama dan mencabut seluruh session, karena perubahan yang tidak
+// dikenali pemilik akun kemungkinan berasal dari akun yang sudah diambil alih
+func (email_serv *emailChangeService) UndoEmailChange(token string) error {
+	emailChange, err := email_serv.emailChangeRepository.ConsumeUndoToken(helper.HashToken(token))
+	if err != nil {
+		return errors.New("undo link is invalid or expired")
+	}
+
+	user, err := email_serv.userRepository.GetUserByID(emailChange.UserID)
+	if err != nil {
+		return err
+	}
+
+	if user.Email != emailChange.NewEmail {
+		return errors.New("email has been changed again and can no longer be restored with this link")
+	}
+
+	// MENGECEK APAKAH EMAIL LAMA SUDAH DIPAKAI USER LAIN
+	if existingUser, err := email_serv.userRepository.GetUserByEmail(emailChange.OldEmail); err == nil && existingUser.ID != user.ID {
+		return errors.New("email already in use by another user")
+	}
+
+	user.Email = emailChange.OldEmail
+	if _, err := email_serv.userRepository.UpdateUser(user); err != nil {
+		return err
+	}
+
+	return email_serv.tokenService.LogoutAll(emailChange.UserID)
+}
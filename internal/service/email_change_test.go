@@ -0,0 +1,165 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/utils/data"
+)
+
+func newTestEmailChangeService(env *testEnv) EmailChangeService {
+	return NewEmailChangeService(env.userRepo, repository.NewEmailChangeRepository(env.redis), env.tokenService, env.mailer)
+}
+
+// emailToken mengambil token dari link di email berikutnya dan memastikan email dikirim ke alamat yang benar
+func (env *testEnv) emailToken(t *testing.T, to string, file string) string {
+	t.Helper()
+
+	sent := env.mailer.Next(t)
+	if sent.To != to || sent.File != file {
+		t.Fatalf("email = %+v, want %s to %s", sent, file, to)
+	}
+	link, err := url.Parse(sent.Data.(data.EmailChangeEmail).URL)
+	if err != nil {
+		t.Fatalf("parse email link: %v", err)
+	}
+
+	return link.Query().Get("token")
+}
+
+func (env *testEnv) currentEmail(t *testing.T, userID string) string {
+	t.Helper()
+
+	user, err := env.userRepo.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+
+	return user.Email
+}
+
+func TestEmailChangeStaysPendingUntilConfirmed(t *testing.T) {
+	env := newTestEnv(t)
+	emailChangeService := newTestEmailChangeService(env)
+	user := env.createUser(t, "old@example.com")
+	env.createUser(t, "taken@example.com")
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	for _, newEmail := range []string{"not-an-email", "OLD@example.com", "taken@example.com"} {
+		if err := emailChangeService.RequestEmailChange(user.ID.String(), newEmail); err == nil {
+			t.Fatalf("RequestEmailChange accepted %q", newEmail)
+		}
+	}
+	env.mailer.None(t)
+
+	if err := emailChangeService.RequestEmailChange(user.ID.String(), "new@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	token := env.emailToken(t, "new@example.com", "email-change-confirm-email-template.html")
+
+	// EMAIL BELUM BERUBAH SEBELUM DIKONFIRMASI
+	if email := env.currentEmail(t, user.ID.String()); email != "old@example.com" {
+		t.Fatalf("email = %q before confirmation", email)
+	}
+
+	updated, err := emailChangeService.ConfirmEmailChange(token)
+	if err != nil {
+		t.Fatalf("ConfirmEmailChange: %v", err)
+	}
+	if updated.Email != "new@example.com" || env.currentEmail(t, user.ID.String()) != "new@example.com" {
+		t.Fatalf("email = %q after confirmation", updated.Email)
+	}
+
+	// EMAIL LAMA MENERIMA NOTIFIKASI BESERTA LINK UNDO
+	if undoToken := env.emailToken(t, "old@example.com", "email-change-notice-email-template.html"); undoToken == "" {
+		t.Fatal("notice has no undo link")
+	}
+
+	// ACCESS TOKEN DENGAN CLAIM EMAIL LAMA DICABUT
+	if _, err := env.tokenService.VerifyAccessToken(tokens.AccessToken); err == nil {
+		t.Fatal("access token with the old email claim is still valid")
+	}
+
+	if _, err := emailChangeService.ConfirmEmailChange(token); err == nil {
+		t.Fatal("confirmation link was accepted twice")
+	}
+}
+
+func TestEmailChangeNewRequestReplacesPrevious(t *testing.T) {
+	env := newTestEnv(t)
+	emailChangeService := newTestEmailChangeService(env)
+	user := env.createUser(t, "old@example.com")
+
+	if err := emailChangeService.RequestEmailChange(user.ID.String(), "first@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	firstToken := env.emailToken(t, "first@example.com", "email-change-confirm-email-template.html")
+	if err := emailChangeService.RequestEmailChange(user.ID.String(), "second@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	env.emailToken(t, "second@example.com", "email-change-confirm-email-template.html")
+
+	if _, err := emailChangeService.ConfirmEmailChange(firstToken); err == nil {
+		t.Fatal("superseded confirmation link was accepted")
+	}
+}
+
+func TestConfirmEmailChangeRejectsEmailTakenWhilePending(t *testing.T) {
+	env := newTestEnv(t)
+	emailChangeService := newTestEmailChangeService(env)
+	user := env.createUser(t, "old@example.com")
+
+	if err := emailChangeService.RequestEmailChange(user.ID.String(), "new@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	token := env.emailToken(t, "new@example.com", "email-change-confirm-email-template.html")
+	env.createUser(t, "new@example.com")
+
+	if _, err := emailChangeService.ConfirmEmailChange(token); err == nil {
+		t.Fatal("email change to an address taken while pending was confirmed")
+	}
+	if email := env.currentEmail(t, user.ID.String()); email != "old@example.com" {
+		t.Fatalf("email = %q, want it unchanged", email)
+	}
+}
+
+func TestUndoEmailChangeRestoresEmailAndRevokesSessions(t *testing.T) {
+	env := newTestEnv(t)
+	emailChangeService := newTestEmailChangeService(env)
+	user := env.createUser(t, "old@example.com")
+
+	if err := emailChangeService.RequestEmailChange(user.ID.String(), "attacker@example.com"); err != nil {
+		t.Fatalf("RequestEmailChange: %v", err)
+	}
+	token := env.emailToken(t, "attacker@example.com", "email-change-confirm-email-template.html")
+	if _, err := emailChangeService.ConfirmEmailChange(token); err != nil {
+		t.Fatalf("ConfirmEmailChange: %v", err)
+	}
+	undoToken := env.emailToken(t, "old@example.com", "email-change-notice-email-template.html")
+
+	// SESSION YANG DIBUAT SETELAH PERUBAHAN EMAIL (MISALNYA MILIK PENYERANG) IKUT DICABUT
+	user, _ = env.userRepo.GetUserByID(user.ID.String())
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	if err := emailChangeService.UndoEmailChange(undoToken); err != nil {
+		t.Fatalf("UndoEmailChange: %v", err)
+	}
+	if email := env.currentEmail(t, user.ID.String()); email != "old@example.com" {
+		t.Fatalf("email = %q after undo", email)
+	}
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("session survived an email change undo")
+	}
+
+	if err := emailChangeService.UndoEmailChange(undoToken); err == nil {
+		t.Fatal("undo link was accepted twice")
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+)
+
+type IdentityService interface {
+	GetIdentities(userID string) ([]dto.IdentityResponse, error)
+	LinkIdentity(userID string, profile dto.OAuthProfile) error
+	UnlinkIdentity(userID string, identityID string) error
+}
+
+type identityService struct {
+	identityRepository repository.IdentityRepository
+	userRepository     repository.UsersRepository
+	passkeyRepository  repository.PasskeyRepository
+}
+
+func NewIdentityService(identityRepository repository.IdentityRepository, userRepository repository.UsersRepository, passkeyRepository repository.PasskeyRepository) IdentityService {
+	return &identityService{
+		identityRepository: identityRepository,
+		userRepository:     userRepository,
+		passkeyRepository:  passkeyRepository,
+	}
+}
+
+func (identity_serv *identityService) GetIdentities(userID string) ([]dto.IdentityResponse, error) {
+	identities, err := identity_serv.identityRepository.GetIdentitiesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identitiesResponse := []dto.IdentityResponse{}
+	for _, identity := range identities {
+		identitiesResponse = append(identitiesResponse, dto.IdentityResponse{
+			ID:        identity.ID.String(),
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+
+	return identitiesResponse, nil
+}
+
+func (identity_serv *identityService) LinkIdentity(userID string, profile dto.OAuthProfile) error {
+	if profile.ProviderUserID == "" {
+		return errors.New("provider did not return a user id")
+	}
+
+	user, err := identity_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	// MENGECEK APAKAH IDENTITY SUDAH TERHUBUNG KE USER LAIN
+	identity, err := identity_serv.identityRepository.GetIdentity(profile.Provider, profile.ProviderUserID)
+	if err == nil {
+		if identity.UserID != user.ID {
+			return errors.New("this account is already linked to another user")
+		}
+		return nil
+	}
+
+	_, err = identity_serv.identityRepository.CreateIdentity(model.UserIdentities{
+		UserID:         user.ID,
+		Provider:       profile.Provider,
+		ProviderUserID: profile.ProviderUserID,
+		Email:          profile.Email,
+	})
+
+	return err
+}
+
+func (identity_serv *identityService) UnlinkIdentity(userID string, identityID string) error {
+	identity, err := identity_serv.identityRepository.GetIdentityByID(identityID)
+	if err != nil || identity.UserID.String() != userID {
+		return errors.New("identity not found")
+	}
+
+	user, err := identity_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	// VALIDASI AGAR USER MASIH PUNYA CARA LAIN UNTUK LOGIN
+	identities, err := identity_serv.identityRepository.GetIdentitiesByUserID(userID)
+	if err != nil {
+		return err
+	}
+	passkeys, err := identity_serv.passkeyRepository.GetPasskeysByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" && len(identities) <= 1 && len(passkeys) == 0 {
+		return errors.New("cannot unlink the only sign-in method, please set a password or link another provider first")
+	}
+
+	return identity_serv.identityRepository.DeleteIdentity(identity)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"testing"
+
+	"refina-auth/internal/types/dto"
+)
+
+func TestLinkIdentity(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "link@example.com")
+	other := env.createUser(t, "other-link@example.com")
+
+	profile := dto.OAuthProfile{Provider: "github", ProviderUserID: "gh-link", Email: "link@github.example"}
+	if err := env.identityService.LinkIdentity(user.ID.String(), profile); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+
+	// MENGHUBUNGKAN ULANG KE USER YANG SAMA TIDAK MEMBUAT DUPLIKAT
+	if err := env.identityService.LinkIdentity(user.ID.String(), profile); err != nil {
+		t.Fatalf("LinkIdentity again: %v", err)
+	}
+	identities, err := env.identityService.GetIdentities(user.ID.String())
+	if err != nil {
+		t.Fatalf("GetIdentities: %v", err)
+	}
+	if len(identities) != 1 || identities[0].Provider != "github" {
+		t.Fatalf("identities = %+v, want one github identity", identities)
+	}
+
+	if err := env.identityService.LinkIdentity(other.ID.String(), profile); err == nil {
+		t.Fatal("identity linked to another user was linked again")
+	}
+	if err := env.identityService.LinkIdentity(user.ID.String(), dto.OAuthProfile{Provider: "google"}); err == nil {
+		t.Fatal("profile without a provider user id was linked")
+	}
+}
+
+func TestUnlinkIdentityKeepsASignInMethod(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "unlink@example.com")
+
+	for _, profile := range []dto.OAuthProfile{
+		{Provider: "github", ProviderUserID: "gh-unlink"},
+		{Provider: "google", ProviderUserID: "google-unlink"},
+	} {
+		if err := env.identityService.LinkIdentity(user.ID.String(), profile); err != nil {
+			t.Fatalf("LinkIdentity: %v", err)
+		}
+	}
+	identities, _ := env.identityService.GetIdentities(user.ID.String())
+
+	if err := env.identityService.UnlinkIdentity(user.ID.String(), identities[0].ID); err != nil {
+		t.Fatalf("UnlinkIdentity with another provider left: %v", err)
+	}
+
+	// USER TANPA PASSWORD DAN PASSKEY TIDAK BOLEH MELEPAS PROVIDER TERAKHIR
+	if err := env.identityService.UnlinkIdentity(user.ID.String(), identities[1].ID); err == nil {
+		t.Fatal("the only sign-in method was unlinked")
+	}
+
+	user.Password = "hashed-password"
+	if _, err := env.userRepo.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if err := env.identityService.UnlinkIdentity(user.ID.String(), identities[1].ID); err != nil {
+		t.Fatalf("UnlinkIdentity with a password set: %v", err)
+	}
+}
+
+func TestUnlinkIdentityOfAnotherUser(t *testing.T) {
+	env := newTestEnv(t)
+	owner := env.createUser(t, "owner-identity@example.com")
+	attacker := env.createUser(t, "attacker-identity@example.com")
+
+	if err := env.identityService.LinkIdentity(owner.ID.String(), dto.OAuthProfile{Provider: "github", ProviderUserID: "gh-owner"}); err != nil {
+		t.Fatalf("LinkIdentity: %v", err)
+	}
+	identities, _ := env.identityService.GetIdentities(owner.ID.String())
+
+	if err := env.identityService.UnlinkIdentity(attacker.ID.String(), identities[0].ID); err == nil {
+		t.Fatal("identity of another user was unlinked")
+	}
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// keyRotationCheckInterval - seberapa sering key store mengecek rotasi dan memuat ulang key dari database
+	keyRotationCheckInterval = time.Minute
+	// keyReloadCooldown - jeda minimum reload saat menemukan kid yang tidak dikenal
+	keyReloadCooldown = 10 * time.Second
+)
+
+type KeyService interface {
+	SignToken(claims jwt.Claims) (string, error)
+	ParseToken(tokenString string, claims jwt.Claims) error
+	GetJWKS() dto.JWKS
+	LoadKeys() error
+	StartKeyRotation()
+}
+
+type signingKey struct {
+	kid         string
+	method      jwt.SigningMethod
+	privateKey  crypto.Signer
+	publicKey   crypto.PublicKey
+	createdAt   time.Time
+	activatesAt time.Time
+	rotatedAt   time.Time
+}
+
+// canSignAt - key sudah aktif dan belum dirotasi pada waktu now
+func (key *signingKey) canSignAt(now time.Time) bool {
+	return !key.activatesAt.After(now) && (key.rotatedAt.IsZero() || key.rotatedAt.After(now))
+}
+
+type keyService struct {
+	signingKeyRepository repository.SigningKeyRepository
+	method               jwt.SigningMethod
+
+	mu           sync.RWMutex
+	latestKey    *signingKey
+	keys         map[string]*signingKey
+	lastLoadedAt time.Time
+}
+
+func NewKeyService(signingKeyRepository repository.SigningKeyRepository) (KeyService, error) {
+	method, err := helper.GetSigningMethod(env.Cfg.JWT.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyService{
+		signingKeyRepository: signingKeyRepository,
+		method:               method,
+		keys:                 map[string]*signingKey{},
+	}, nil
+}
+
+func (key_serv *keyService) isSymmetric() bool {
+	return key_serv.method == jwt.SigningMethodHS256
+}
+
+func (key_serv *keyService) SignToken(claims jwt.Claims) (string, error) {
+	if key_serv.isSymmetric() {
+		return jwt.NewWithClaims(key_serv.method, claims).SignedString([]byte(env.Cfg.Server.JWTSecretKey))
+	}
+
+	currentKey := key_serv.currentKey(time.Now())
+	if currentKey == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(currentKey.method, claims)
+	token.Header["kid"] = currentKey.kid
+
+	return token.SignedString(currentKey.privateKey)
+}
+
+// currentKey memilih key untuk sign pada waktu now. Key hasil rotasi sudah dipublikasikan di JWKS
+// sebelum aktif, sehingga service lain yang meng-cache JWKS sudah mengenalnya saat key mulai dipakai.
+func (key_serv *keyService) currentKey(now time.Time) *signingKey {
+	key_serv.mu.RLock()
+	defer key_serv.mu.RUnlock()
+
+	var currentKey *signingKey
+	for _, key := range key_serv.keys {
+		if key.method != key_serv.method || !key.canSignAt(now) {
+			continue
+		}
+		if currentKey == nil || key.activatesAt.After(currentKey.activatesAt) {
+			currentKey = key
+		}
+	}
+
+	return currentKey
+}
+
+func (key_serv *keyService) ParseToken(tokenString string, claims jwt.Claims) error {
+	var (
+		token *jwt.Token
+		err   error
+	)
+
+	if key_serv.isSymmetric() {
+		token, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(env.Cfg.Server.JWTSecretKey), nil
+		}, jwt.WithValidMethods([]string{key_serv.method.Alg()}), jwt.WithExpirationRequired())
+	} else {
+		token, err = jwt.ParseWithClaims(tokenString, claims, key_serv.lookupKey, jwt.WithValidMethods([]string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		}), jwt.WithExpirationRequired())
+	}
+	if err != nil || !token.Valid {
+		return errors.New("invalid or expired token")
+	}
+
+	return nil
+}
+
+func (key_serv *keyService) lookupKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+
+	key_serv.mu.RLock()
+	key, ok := key_serv.keys[kid]
+	key_serv.mu.RUnlock()
+
+	// KEY TIDAK DIKENAL, KEMUNGKINAN BARU DIROTASI OLEH REPLICA LAIN
+	if !ok {
+		key_serv.mu.RLock()
+		recentlyLoaded := time.Since(key_serv.lastLoadedAt) < keyReloadCooldown
+		key_serv.mu.RUnlock()
+		if recentlyLoaded {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+		if err := key_serv.LoadKeys(); err != nil {
+			return nil, err
+		}
+		key_serv.mu.RLock()
+		key, ok = key_serv.keys[kid]
+		key_serv.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %s", kid)
+		}
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("token algorithm does not match signing key")
+	}
+
+	return key.publicKey, nil
+}
+
+func (key_serv *keyService) GetJWKS() dto.JWKS {
+	jwks := dto.JWKS{Keys: []dto.JWK{}}
+
+	key_serv.mu.RLock()
+	defer key_serv.mu.RUnlock()
+
+	for _, key := range key_serv.keys {
+		jwk, err := helper.PublicKeyToJWK(key.kid, key.method.Alg(), key.publicKey)
+		if err != nil {
+			log.Error("Failed to convert signing key to JWK: "+err.Error(), map[string]interface{}{"kid": key.kid})
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// LoadKeys memuat seluruh key yang masih boleh dipakai untuk verifikasi, yaitu key aktif
+// dan key yang dirotasi belum lebih lama dari umur access token.
+func (key_serv *keyService) LoadKeys() error {
+	if key_serv.isSymmetric() {
+		return nil
+	}
+
+	storedKeys, err := key_serv.signingKeyRepository.GetVerificationKeys(time.Now().Add(-env.Cfg.JWT.AccessTokenTTL))
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*signingKey{}
+	var latestKey *signingKey
+	for _, storedKey := range storedKeys {
+		method, err := helper.GetSigningMethod(storedKey.Algorithm)
+		if err != nil {
+			return err
+		}
+		privateKey, err := key_serv.decodePrivateKey(storedKey)
+		if err != nil {
+			return err
+		}
+
+		key := &signingKey{
+			kid:         storedKey.Kid,
+			method:      method,
+			privateKey:  privateKey,
+			publicKey:   privateKey.Public(),
+			createdAt:   storedKey.CreatedAt,
+			activatesAt: storedKey.ActivatesAt,
+		}
+		if storedKey.RotatedAt.Valid {
+			key.rotatedAt = storedKey.RotatedAt.Time
+		}
+		keys[key.kid] = key
+
+		if key.rotatedAt.IsZero() && method == key_serv.method && (latestKey == nil || key.createdAt.After(latestKey.createdAt)) {
+			latestKey = key
+		}
+	}
+
+	key_serv.mu.Lock()
+	key_serv.keys = keys
+	key_serv.latestKey = latestKey
+	key_serv.lastLoadedAt = time.Now()
+	key_serv.mu.Unlock()
+
+	return nil
+}
+
+// decodePrivateKey mendekripsi private key dari database, private key selalu disimpan terenkripsi
+func (key_serv *keyService) decodePrivateKey(storedKey model.SigningKeys) (crypto.Signer, error) {
+	encodedPrivateKey, err := helper.Decrypt(storedKey.PrivateKey)
+	if err != nil {
+		return nil, errors.New("failed to decrypt signing key " + storedKey.Kid)
+	}
+
+	return helper.DecodePrivateKey(encodedPrivateKey)
+}
+
+func (key_serv *keyService) rotateIfDue() error {
+	key_serv.mu.RLock()
+	latestKey := key_serv.latestKey
+	key_serv.mu.RUnlock()
+
+	if latestKey != nil && time.Since(latestKey.createdAt) < env.Cfg.JWT.KeyRotationInterval {
+		return nil
+	}
+
+	privateKey, err := helper.GenerateSigningKey(key_serv.method.Alg())
+	if err != nil {
+		return err
+	}
+	encodedPrivateKey, err := helper.EncodePrivateKey(privateKey)
+	if err != nil {
+		return err
+	}
+	encryptedPrivateKey, err := helper.Encrypt(encodedPrivateKey)
+	if err != nil {
+		return err
+	}
+	encodedPublicKey, err := helper.EncodePublicKey(privateKey.Public())
+	if err != nil {
+		return err
+	}
+
+	// KEY BARU DIPUBLIKASIKAN DULU DAN BARU DIPAKAI SETELAH CACHE JWKS DI CLIENT KADALUARSA
+	activatesAt := time.Now()
+	if latestKey != nil {
+		activatesAt = activatesAt.Add(data.JWKS_CACHE_MAX_AGE)
+	}
+
+	kid := uuid.NewString()
+	rotated, err := key_serv.signingKeyRepository.RotateSigningKey(model.SigningKeys{
+		Kid:         kid,
+		Algorithm:   key_serv.method.Alg(),
+		PrivateKey:  encryptedPrivateKey,
+		PublicKey:   encodedPublicKey,
+		ActivatesAt: activatesAt,
+	}, time.Now().Add(-env.Cfg.JWT.KeyRotationInterval))
+	if err != nil {
+		return err
+	}
+	if rotated {
+		log.Info("JWT signing key rotated", map[string]interface{}{"kid": kid, "algorithm": key_serv.method.Alg(), "activates_at": activatesAt})
+	}
+
+	return key_serv.LoadKeys()
+}
+
+// StartKeyRotation memuat key saat startup lalu menjalankan pengecekan rotasi secara berkala di background.
+func (key_serv *keyService) StartKeyRotation() {
+	if key_serv.isSymmetric() {
+		return
+	}
+
+	if err := key_serv.LoadKeys(); err != nil {
+		log.Log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	if err := key_serv.rotateIfDue(); err != nil {
+		log.Log.Fatalf("Failed to rotate JWT signing key: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(keyRotationCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := key_serv.rotateIfDue(); err != nil {
+				log.Error("Failed to rotate JWT signing key: " + err.Error())
+				continue
+			}
+			if err := key_serv.signingKeyRepository.DeleteRotatedKeys(time.Now().Add(-env.Cfg.JWT.AccessTokenTTL)); err != nil {
+				log.Error("Failed to delete rotated JWT signing keys: " + err.Error())
+			}
+		}
+	}()
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func newTestKeyService(t *testing.T) (*keyService, *gorm.DB) {
+	t.Helper()
+	testutil.Config(t)
+	env.Cfg.JWT.Algorithm = "ES256"
+
+	db := newTestDB(t, &model.SigningKeys{})
+	keyServ, err := NewKeyService(repository.NewSigningKeyRepository(db))
+	if err != nil {
+		t.Fatalf("NewKeyService: %v", err)
+	}
+
+	return keyServ.(*keyService), db
+}
+
+func signTestToken(t *testing.T, keyServ *keyService) (string, string) {
+	t.Helper()
+
+	tokenString, err := keyServ.SignToken(dto.JWTClaims{
+		UserID: "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &dto.JWTClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+
+	return tokenString, token.Header["kid"].(string)
+}
+
+func TestKeyServiceStoresPrivateKeyEncrypted(t *testing.T) {
+	keyServ, db := newTestKeyService(t)
+
+	if err := keyServ.rotateIfDue(); err != nil {
+		t.Fatalf("rotateIfDue: %v", err)
+	}
+
+	var storedKey model.SigningKeys
+	if err := db.First(&storedKey).Error; err != nil {
+		t.Fatalf("load signing key: %v", err)
+	}
+	if strings.Contains(storedKey.PrivateKey, "PRIVATE KEY") {
+		t.Fatal("private key was stored as plaintext PEM")
+	}
+
+	// KEY PERTAMA LANGSUNG AKTIF
+	tokenString, kid := signTestToken(t, keyServ)
+	if kid != storedKey.Kid {
+		t.Fatalf("token kid = %q, want %q", kid, storedKey.Kid)
+	}
+	if err := keyServ.ParseToken(tokenString, &dto.JWTClaims{}); err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+
+	// KEY TIDAK BISA DIBACA DENGAN ENCRYPTION KEY LAIN
+	env.Cfg.Server.EncryptionKey = "another-encryption-key"
+	if err := keyServ.LoadKeys(); err == nil {
+		t.Fatal("LoadKeys succeeded with a different encryption key")
+	}
+}
+
+func TestKeyServiceRejectsPlaintextKey(t *testing.T) {
+	keyServ, db := newTestKeyService(t)
+
+	privateKey, err := helper.GenerateSigningKey("ES256")
+	if err != nil {
+		t.Fatalf("GenerateSigningKey: %v", err)
+	}
+	encodedPrivateKey, _ := helper.EncodePrivateKey(privateKey)
+	encodedPublicKey, _ := helper.EncodePublicKey(privateKey.Public())
+	if err := db.Create(&model.SigningKeys{
+		Kid:         "plaintext",
+		Algorithm:   "ES256",
+		PrivateKey:  encodedPrivateKey,
+		PublicKey:   encodedPublicKey,
+		ActivatesAt: time.Now().Add(-time.Hour),
+	}).Error; err != nil {
+		t.Fatalf("create plaintext key: %v", err)
+	}
+
+	if err := keyServ.LoadKeys(); err == nil {
+		t.Fatal("LoadKeys accepted a private key stored as plaintext PEM")
+	}
+}
+
+func TestKeyServicePublishesRotatedKeyBeforeSigning(t *testing.T) {
+	keyServ, db := newTestKeyService(t)
+
+	if err := keyServ.rotateIfDue(); err != nil {
+		t.Fatalf("rotateIfDue: %v", err)
+	}
+	_, oldKid := signTestToken(t, keyServ)
+
+	env.Cfg.JWT.KeyRotationInterval = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	if err := keyServ.rotateIfDue(); err != nil {
+		t.Fatalf("rotateIfDue: %v", err)
+	}
+
+	var newKey model.SigningKeys
+	if err := db.Where("kid <> ?", oldKid).First(&newKey).Error; err != nil {
+		t.Fatalf("rotated key was not created: %v", err)
+	}
+
+	// KEY BARU SUDAH ADA DI JWKS, TAPI KEY LAMA MASIH DIPAKAI UNTUK SIGN
+	kids := map[string]bool{}
+	for _, jwk := range keyServ.GetJWKS().Keys {
+		kids[jwk.Kid] = true
+	}
+	if !kids[oldKid] || !kids[newKey.Kid] {
+		t.Fatalf("JWKS kids = %v, want both %q and %q", kids, oldKid, newKey.Kid)
+	}
+	if _, kid := signTestToken(t, keyServ); kid != oldKid {
+		t.Fatalf("token was signed with %q before the new key activated", kid)
+	}
+
+	// SETELAH CACHE JWKS KADALUARSA, KEY BARU DIPAKAI
+	activatedAt := time.Now().Add(data.JWKS_CACHE_MAX_AGE + time.Second)
+	if currentKey := keyServ.currentKey(activatedAt); currentKey == nil || currentKey.kid != newKey.Kid {
+		t.Fatalf("current key after activation = %v, want %q", currentKey, newKey.Kid)
+	}
+
+	// JADWAL ROTASI BERIKUTNYA DIHITUNG DARI KEY BARU, BUKAN DARI KEY YANG SEDANG DIPAKAI
+	if keyServ.latestKey == nil || keyServ.latestKey.kid != newKey.Kid {
+		t.Fatalf("latest key = %v, want %q", keyServ.latestKey, newKey.Kid)
+	}
+}
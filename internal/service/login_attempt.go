@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+)
+
+var (
+	ErrLoginThrottled = errors.New("too many failed login attempts")
+	ErrAccountLocked  = errors.New("account is temporarily locked because of too many failed login attempts")
+)
+
+type LoginAttemptService interface {
+	CheckLogin(email string, ip string) error
+	RecordFailure(email string) error
+	RecordSuccess(email string, ip string) error
+	UnlockAccount(token string) error
+	AdminUnlockAccount(userID string) error
+}
+
+type loginAttemptService struct {
+	userRepository         repository.UsersRepository
+	loginAttemptRepository repository.LoginAttemptRepository
+	smtpClient             helper.SMTPClientInterface
+}
+
+func NewLoginAttemptService(userRepository repository.UsersRepository, loginAttemptRepository repository.LoginAttemptRepository, smtpClient helper.SMTPClientInterface) LoginAttemptService {
+	return &loginAttemptService{
+		userRepository:         userRepository,
+		loginAttemptRepository: loginAttemptRepository,
+		smtpClient:             smtpClient,
+	}
+}
+
+// CheckLogin dipanggil sebelum password dicek, menolak login jika akun terkunci atau masih dalam masa backoff.
+// Percobaan langsung dicatat sebagai gagal secara atomik sehingga request paralel tidak bisa melewati backoff,
+// RecordSuccess membatalkannya jika password ternyata benar.
+func (attempt_serv *loginAttemptService) CheckLogin(email string, ip string) error {
+	email = normalizeEmail(email)
+
+	lock, err := attempt_serv.loginAttemptRepository.GetLock(email)
+	if err != nil {
+		return err
+	}
+	if lock > 0 {
+		return fmt.Errorf("%w, please try again in %d minutes or use the unlock link sent to your email", ErrAccountLocked, int(math.Ceil(lock.Minutes())))
+	}
+
+	// IP DICEK LEBIH DULU AGAR PERCOBAAN DARI IP YANG SEDANG DI-THROTTLE TIDAK MENAMBAH COUNTER AKUN KORBAN.
+	// IP DIBAGI OLEH BANYAK USER (NAT, KANTOR) SEHINGGA THRESHOLD LEBIH TINGGI DAN TIDAK PERNAH DIKUNCI
+	if ip != "" {
+		if err := attempt_serv.reserveAttempt(data.LOGIN_SCOPE_IP, ip, data.LOGIN_IP_BACKOFF_THRESHOLD); err != nil {
+			return err
+		}
+	}
+
+	return attempt_serv.reserveAttempt(data.LOGIN_SCOPE_ACCOUNT, email, data.LOGIN_BACKOFF_THRESHOLD)
+}
+
+// RecordFailure dipanggil saat password salah. Kegagalan sudah dihitung oleh CheckLogin, di sini akun dikunci
+// sementara jika sudah LOGIN_LOCKOUT_THRESHOLD kali gagal.
+func (attempt_serv *loginAttemptService) RecordFailure(email string) error {
+	email = normalizeEmail(email)
+
+	accountFailures, err := attempt_serv.loginAttemptRepository.GetFailures(data.LOGIN_SCOPE_ACCOUNT, email)
+	if err != nil {
+		return err
+	}
+	if accountFailures >= int64(data.LOGIN_LOCKOUT_THRESHOLD) {
+		return attempt_serv.lockAccount(email)
+	}
+
+	return nil
+}
+
+// RecordSuccess mereset counter akun setelah password benar. Untuk IP hanya percobaan ini yang dibatalkan,
+// kegagalan sebelumnya tetap berjalan sampai window habis.
+func (attempt_serv *loginAttemptService) RecordSuccess(email string, ip string) error {
+	if ip != "" {
+		if err := attempt_serv.loginAttemptRepository.ReleaseAttempt(data.LOGIN_SCOPE_IP, ip); err != nil {
+			return err
+		}
+	}
+
+	return attempt_serv.loginAttemptRepository.ResetFailures(data.LOGIN_SCOPE_ACCOUNT, normalizeEmail(email))
+}
+
+// UnlockAccount membuka lockout lewat link yang dikirim ke email pemilik akun
+func (attempt_serv *loginAttemptService) UnlockAccount(token string) error {
+	if token == "" {
+		return errors.New("token cannot be blank")
+	}
+
+	email, err := attempt_serv.loginAttemptRepository.ConsumeUnlockToken(helper.HashToken(token))
+	if err != nil {
+		return errors.New("unlock link is invalid or expired")
+	}
+
+	return attempt_serv.clearLock(email)
+}
+
+func (attempt_serv *loginAttemptService) AdminUnlockAccount(userID string) error {
+	user, err := attempt_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return attempt_serv.clearLock(normalizeEmail(user.Email))
+}
+
+// reserveAttempt - 1s, 2s, 4s, ... SAMPAI LOGIN_BACKOFF_MAX SETELAH threshold KALI GAGAL
+func (attempt_serv *loginAttemptService) reserveAttempt(scope string, id string, threshold int) error {
+	_, delay, err := attempt_serv.loginAttemptRepository.ReserveAttempt(scope, id, repository.LoginBackoff{
+		Window:    data.LOGIN_FAILURE_WINDOW,
+		Threshold: threshold,
+		Base:      data.LOGIN_BACKOFF_BASE,
+		Max:       data.LOGIN_BACKOFF_MAX,
+	})
+	if err != nil {
+		return err
+	}
+	if delay > 0 {
+		return fmt.Errorf("%w, please try again in %d seconds", ErrLoginThrottled, int(math.Ceil(delay.Seconds())))
+	}
+
+	return nil
+}
+
+func (attempt_serv *loginAttemptService) lockAccount(email string) error {
+	if err := attempt_serv.loginAttemptRepository.LockAccount(email, data.LOGIN_LOCKOUT_DURATION); err != nil {
+		return err
+	}
+
+	// EMAIL NOTIFIKASI HANYA DIKIRIM JIKA AKUN BENAR-BENAR TERDAFTAR
+	user, err := attempt_serv.userRepository.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := secrets.Token()
+	if err != nil {
+		return err
+	}
+
+	if err := attempt_serv.loginAttemptRepository.SaveUnlockToken(helper.HashToken(token), email, data.LOGIN_LOCKOUT_DURATION); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := attempt_serv.smtpClient.SendSingleEmail(user.Email, "Your Account Has Been Locked", "account-locked-email-template.html", data.AccountLockedEmail{
+			Name:      user.Name,
+			URL:       env.Cfg.Client.Url + "/unlock-account?token=" + token,
+			ExpiresIn: int(data.LOGIN_LOCKOUT_DURATION.Minutes()),
+		}); err != nil {
+			log.Error("Failed to send account locked email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		}
+	}()
+
+	return nil
+}
+
+func (attempt_serv *loginAttemptService) clearLock(email string) error {
+	if err := attempt_serv.loginAttemptRepository.UnlockAccount(email); err != nil {
+		return err
+	}
+
+	return attempt_serv.loginAttemptRepository.ResetFailures(data.LOGIN_SCOPE_ACCOUNT, email)
+}
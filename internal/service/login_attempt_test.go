@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/utils/data"
+)
+
+func (env *testEnv) passwordLogin(email string, password string, client dto.ClientInfo) error {
+	_, err := env.usersService.Login(dto.UsersRequest{Email: email, Password: password}, client)
+	return err
+}
+
+func TestParallelLoginsCannotBypassBackoff(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "nelly@example.com", testPassword)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		attempted int
+		throttled int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := env.passwordLogin(user.Email, "wrong-password", testClient)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, ErrLoginThrottled) {
+				throttled++
+			} else {
+				attempted++
+			}
+		}()
+	}
+	wg.Wait()
+
+	// PERCOBAAN SETELAH THRESHOLD LANGSUNG MEMASANG BACKOFF SEHINGGA HANYA SATU YANG LOLOS
+	if attempted != data.LOGIN_BACKOFF_THRESHOLD+1 || throttled != 10-attempted {
+		t.Fatalf("attempted = %d, throttled = %d, want %d password checks", attempted, throttled, data.LOGIN_BACKOFF_THRESHOLD+1)
+	}
+}
+
+func TestSuccessfulLoginsDoNotThrottleIP(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "nelly@example.com", testPassword)
+
+	if err := env.passwordLogin(user.Email, "wrong-password", testClient); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	for i := 0; i <= data.LOGIN_IP_BACKOFF_THRESHOLD; i++ {
+		if err := env.passwordLogin(user.Email, testPassword, testClient); err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+	}
+
+	failures, err := env.loginAttemptRepo.GetFailures(data.LOGIN_SCOPE_ACCOUNT, user.Email)
+	if err != nil || failures != 0 {
+		t.Fatalf("account failures = %d, %v, want reset after a successful login", failures, err)
+	}
+	failures, err = env.loginAttemptRepo.GetFailures(data.LOGIN_SCOPE_IP, testClient.IP)
+	if err != nil || failures != 1 {
+		t.Fatalf("ip failures = %d, %v, want only the failed attempt", failures, err)
+	}
+}
+
+func TestThrottledIPDoesNotCountAgainstAccount(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "nelly@example.com", testPassword)
+
+	for i := 0; i <= data.LOGIN_IP_BACKOFF_THRESHOLD; i++ {
+		env.passwordLogin(fmt.Sprintf("unknown-%d@example.com", i), "wrong-password", testClient)
+	}
+
+	if err := env.passwordLogin(user.Email, "wrong-password", testClient); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("login from a throttled ip = %v, want ErrLoginThrottled", err)
+	}
+	failures, err := env.loginAttemptRepo.GetFailures(data.LOGIN_SCOPE_ACCOUNT, user.Email)
+	if err != nil || failures != 0 {
+		t.Fatalf("account failures = %d, %v, want 0 for a rejected attempt", failures, err)
+	}
+
+	otherClient := dto.ClientInfo{IP: "198.51.100.7", UserAgent: testClient.UserAgent}
+	if err := env.passwordLogin(user.Email, testPassword, otherClient); err != nil {
+		t.Fatalf("login from another ip: %v", err)
+	}
+}
+
+func TestAccountIsLockedAfterRepeatedFailures(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "nelly@example.com", testPassword)
+
+	for i := 0; i < data.LOGIN_LOCKOUT_THRESHOLD; i++ {
+		env.miniredis.FastForward(data.LOGIN_BACKOFF_MAX)
+		if err := env.passwordLogin(user.Email, "wrong-password", testClient); err == nil || errors.Is(err, ErrLoginThrottled) {
+			t.Fatalf("attempt %d = %v, want an incorrect password", i, err)
+		}
+	}
+
+	sent := env.mailer.Next(t)
+	if sent.To != user.Email || sent.File != "account-locked-email-template.html" {
+		t.Fatalf("email = %+v, want account locked notice", sent)
+	}
+
+	env.miniredis.FastForward(data.LOGIN_BACKOFF_MAX)
+	if err := env.passwordLogin(user.Email, testPassword, testClient); !errors.Is(err, ErrAccountLocked) {
+		t.Fatalf("login while locked = %v, want ErrAccountLocked", err)
+	}
+
+	link, err := url.Parse(sent.Data.(data.AccountLockedEmail).URL)
+	if err != nil {
+		t.Fatalf("parse email link: %v", err)
+	}
+	if err := env.loginAttemptService.UnlockAccount(link.Query().Get("token")); err != nil {
+		t.Fatalf("UnlockAccount: %v", err)
+	}
+	if err := env.passwordLogin(user.Email, testPassword, testClient); err != nil {
+		t.Fatalf("login after unlock: %v", err)
+	}
+}
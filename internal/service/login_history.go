@@ -0,0 +1,255 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+)
+
+type LoginHistoryService interface {
+	RecordSuccess(user model.Users, loginMethod string, client dto.ClientInfo)
+	RecordFailure(user model.Users, loginMethod string, client dto.ClientInfo, reason string)
+	CheckPasswordReset(user model.Users, loginMethod string, client dto.ClientInfo) error
+	GetLoginHistory(userID string) ([]dto.LoginHistoryResponse, error)
+	ReportLogin(token string) error
+}
+
+type loginHistoryService struct {
+	userRepository         repository.UsersRepository
+	loginHistoryRepository repository.LoginHistoryRepository
+	loginReportRepository  repository.LoginReportRepository
+	identityRepository     repository.IdentityRepository
+	passkeyRepository      repository.PasskeyRepository
+	tokenService           TokenService
+	passwordService        PasswordService
+	smtpClient             helper.SMTPClientInterface
+}
+
+func NewLoginHistoryService(userRepository repository.UsersRepository, loginHistoryRepository repository.LoginHistoryRepository, loginReportRepository repository.LoginReportRepository, identityRepository repository.IdentityRepository, passkeyRepository repository.PasskeyRepository, tokenService TokenService, passwordService PasswordService, smtpClient helper.SMTPClientInterface) LoginHistoryService {
+	return &loginHistoryService{
+		userRepository:         userRepository,
+		loginHistoryRepository: loginHistoryRepository,
+		loginReportRepository:  loginReportRepository,
+		identityRepository:     identityRepository,
+		passkeyRepository:      passkeyRepository,
+		tokenService:           tokenService,
+		passwordService:        passwordService,
+		smtpClient:             smtpClient,
+	}
+}
+
+// RecordSuccess mencatat login yang berhasil dan mengirim email peringatan jika login berasal dari perangkat
+// atau IP yang belum pernah dipakai user. Login sudah terjadi, sehingga kegagalan hanya dicatat di log.
+func (login_history_serv *loginHistoryService) RecordSuccess(user model.Users, loginMethod string, client dto.ClientInfo) {
+	// PENGECEKAN PERANGKAT BARU DILAKUKAN SEBELUM LOGIN INI DICATAT
+	newDevice, err := login_history_serv.isNewDevice(user, client)
+	if err != nil {
+		log.Error("Failed to check login history: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+	}
+
+	history, err := login_history_serv.loginHistoryRepository.CreateLoginHistory(login_history_serv.newHistory(user, loginMethod, client))
+	if err != nil {
+		log.Error("Failed to save login history: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		return
+	}
+
+	if newDevice {
+		login_history_serv.sendNewDeviceAlert(user, history)
+	}
+}
+
+// RecordFailure mencatat login yang gagal untuk akun yang terdaftar, reason berisi salah satu LOGIN_FAILURE_*
+func (login_history_serv *loginHistoryService) RecordFailure(user model.Users, loginMethod string, client dto.ClientInfo, reason string) {
+	history := login_history_serv.newHistory(user, loginMethod, client)
+	history.Success = false
+	history.FailureReason = reason
+
+	if _, err := login_history_serv.loginHistoryRepository.CreateLoginHistory(history); err != nil {
+		log.Error("Failed to save login history: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+	}
+}
+
+// CheckPasswordReset menolak login dengan metode apa pun selama akun menunggu password direset setelah
+// dilaporkan "this wasn't me", sehingga penyerang tidak bisa masuk lewat OTP, magic link, OAuth atau passkey
+func (login_history_serv *loginHistoryService) CheckPasswordReset(user model.Users, loginMethod string, client dto.ClientInfo) error {
+	if !user.PasswordResetRequired {
+		return nil
+	}
+
+	login_history_serv.RecordFailure(user, loginMethod, client, data.LOGIN_FAILURE_RESET_REQUIRED)
+	return ErrPasswordResetRequired
+}
+
+// GetLoginHistory mengembalikan LOGIN_HISTORY_LIMIT percobaan login terakhir milik user
+func (login_history_serv *loginHistoryService) GetLoginHistory(userID string) ([]dto.LoginHistoryResponse, error) {
+	histories, err := login_history_serv.loginHistoryRepository.GetLoginHistory(userID, data.LOGIN_HISTORY_LIMIT)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.LoginHistoryResponse, 0, len(histories))
+	for _, history := range histories {
+		responses = append(responses, dto.LoginHistoryResponse{
+			ID:            history.ID.String(),
+			Device:        history.Device,
+			UserAgent:     history.UserAgent,
+			IPAddress:     history.IPAddress,
+			LoginMethod:   history.LoginMethod,
+			Success:       history.Success,
+			FailureReason: history.FailureReason,
+			CreatedAt:     history.CreatedAt,
+		})
+	}
+
+	return responses, nil
+}
+
+// ReportLogin dipanggil dari link "this wasn't me" di email peringatan. Seluruh session user dicabut, passkey dan
+// identity OAuth yang ditambahkan sejak login tersebut dihapus, login ditolak sampai password direset, dan link
+// reset password dikirim ke email user.
+func (login_history_serv *loginHistoryService) ReportLogin(token string) error {
+	if token == "" {
+		return errors.New("token cannot be blank")
+	}
+
+	// TOKEN BARU DIHAPUS SETELAH AKUN DIAMANKAN AGAR LINK BISA DIPAKAI LAGI JIKA SALAH SATU LANGKAH GAGAL
+	tokenHash := helper.HashToken(token)
+	report, err := login_history_serv.loginReportRepository.GetReportToken(tokenHash)
+	if err != nil {
+		return errors.New("report link is invalid or expired")
+	}
+
+	user, err := login_history_serv.userRepository.GetUserByID(report.UserID)
+	if err != nil {
+		return errors.New("report link is invalid or expired")
+	}
+	history, err := login_history_serv.loginHistoryRepository.GetLoginHistoryByID(report.LoginHistoryID)
+	if err != nil || history.UserID != user.ID {
+		return errors.New("report link is invalid or expired")
+	}
+
+	user.PasswordResetRequired = true
+	if _, err := login_history_serv.userRepository.UpdateUser(user); err != nil {
+		return err
+	}
+
+	if err := login_history_serv.tokenService.LogoutAll(report.UserID); err != nil {
+		return err
+	}
+
+	if err := login_history_serv.revokeCredentialsSince(report.UserID, history.CreatedAt.Add(-data.LOGIN_REPORT_REVOKE_WINDOW)); err != nil {
+		return err
+	}
+
+	if err := login_history_serv.passwordService.ForgotPassword(user.Email); err != nil {
+		return err
+	}
+
+	return login_history_serv.loginReportRepository.ConsumeReportToken(tokenHash)
+}
+
+// revokeCredentialsSince menghapus passkey dan identity OAuth yang bisa ditambahkan penyerang setelah berhasil login
+func (login_history_serv *loginHistoryService) revokeCredentialsSince(userID string, since time.Time) error {
+	passkeys, err := login_history_serv.passkeyRepository.GetPasskeysByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, passkey := range passkeys {
+		if passkey.CreatedAt.Before(since) {
+			continue
+		}
+		if err := login_history_serv.passkeyRepository.DeletePasskey(passkey); err != nil {
+			return err
+		}
+	}
+
+	identities, err := login_history_serv.identityRepository.GetIdentitiesByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if identity.CreatedAt.Before(since) {
+			continue
+		}
+		if err := login_history_serv.identityRepository.DeleteIdentity(identity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (login_history_serv *loginHistoryService) newHistory(user model.Users, loginMethod string, client dto.ClientInfo) model.UserLoginHistories {
+	return model.UserLoginHistories{
+		UserID:      user.ID,
+		Device:      helper.DeviceName(client.UserAgent),
+		UserAgent:   client.UserAgent,
+		IPAddress:   client.IP,
+		LoginMethod: loginMethod,
+		Success:     true,
+	}
+}
+
+// isNewDevice - login pertama user (setelah registrasi) tidak dianggap perangkat baru,
+// selanjutnya user agent atau IP yang belum pernah berhasil login memicu peringatan
+func (login_history_serv *loginHistoryService) isNewDevice(user model.Users, client dto.ClientInfo) (bool, error) {
+	userID := user.ID.String()
+
+	hasLogin, err := login_history_serv.loginHistoryRepository.HasSuccessfulLogin(userID)
+	if err != nil || !hasLogin {
+		return false, err
+	}
+
+	knownUserAgent, err := login_history_serv.loginHistoryRepository.HasSuccessfulLoginWithUserAgent(userID, client.UserAgent)
+	if err != nil {
+		return false, err
+	}
+	if !knownUserAgent {
+		return true, nil
+	}
+
+	knownIP, err := login_history_serv.loginHistoryRepository.HasSuccessfulLoginFromIP(userID, client.IP)
+	if err != nil {
+		return false, err
+	}
+
+	return !knownIP, nil
+}
+
+func (login_history_serv *loginHistoryService) sendNewDeviceAlert(user model.Users, history model.UserLoginHistories) {
+	token, err := secrets.Token()
+	if err != nil {
+		log.Error("Failed to generate login report token: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		return
+	}
+
+	if err := login_history_serv.loginReportRepository.SaveReportToken(helper.HashToken(token), model.LoginReport{
+		UserID:         user.ID.String(),
+		LoginHistoryID: history.ID.String(),
+	}, data.LOGIN_REPORT_TTL); err != nil {
+		log.Error("Failed to save login report token: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		return
+	}
+
+	go func() {
+		if err := login_history_serv.smtpClient.SendSingleEmail(user.Email, "New Sign-in to Your Account", "new-device-login-email-template.html", data.NewDeviceLoginEmail{
+			Name:        user.Name,
+			Device:      history.Device,
+			IPAddress:   history.IPAddress,
+			LoginMethod: history.LoginMethod,
+			Time:        history.CreatedAt.UTC().Format(data.LOGIN_ALERT_TIME_FORMAT),
+			URL:         env.Cfg.Client.Url + "/report-login?token=" + token,
+			ExpiresIn:   int(data.LOGIN_REPORT_TTL / (24 * time.Hour)),
+		}); err != nil {
+			log.Error("Failed to send new device login email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		}
+	}()
+}
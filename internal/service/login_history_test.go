@@ -0,0 +1,281 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+)
+
+// reportLink login dari perangkat yang dikenal lalu dari phoneClient, dan mengembalikan token "this wasn't me"
+// dari email peringatan beserta token session login dari perangkat baru
+func (env *testEnv) reportLink(t *testing.T, user model.Users) (string, *dto.TokenResponse) {
+	t.Helper()
+
+	if _, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient); err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	login, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, phoneClient)
+	if err != nil {
+		t.Fatalf("CompleteLogin from a new device: %v", err)
+	}
+
+	sent := env.mailer.Next(t)
+	if sent.To != user.Email || sent.File != "new-device-login-email-template.html" {
+		t.Fatalf("email = %+v, want a new device alert to %s", sent, user.Email)
+	}
+	link, err := url.Parse(sent.Data.(data.NewDeviceLoginEmail).URL)
+	if err != nil {
+		t.Fatalf("parse report link: %v", err)
+	}
+
+	return link.Query().Get("token"), login.Token
+}
+
+// lastFailureReason mengembalikan alasan dari percobaan login terakhir user, kosong jika login tersebut berhasil
+func (env *testEnv) lastFailureReason(t *testing.T, userID string) string {
+	t.Helper()
+
+	histories, err := env.loginHistoryService.GetLoginHistory(userID)
+	if err != nil || len(histories) == 0 {
+		t.Fatalf("GetLoginHistory = %d entries, %v", len(histories), err)
+	}
+
+	return histories[0].FailureReason
+}
+
+// agePasskeysAndIdentities memundurkan waktu pembuatan credential yang sudah ada seolah ditambahkan jauh sebelumnya
+func (env *testEnv) agePasskeysAndIdentities(t *testing.T, userID string) {
+	t.Helper()
+
+	createdAt := time.Now().Add(-30 * 24 * time.Hour)
+	for _, credential := range []interface{}{&model.UserPasskeys{}, &model.UserIdentities{}} {
+		if err := env.db.Model(credential).Where("user_id = ?", userID).Update("created_at", createdAt).Error; err != nil {
+			t.Fatalf("age credentials: %v", err)
+		}
+	}
+}
+
+// requestPasswordResetFromEmail mengambil token dari email reset password yang dikirim oleh ReportLogin
+func (env *testEnv) requestPasswordResetFromEmail(t *testing.T, email string) string {
+	t.Helper()
+
+	sent := env.mailer.Next(t)
+	if sent.To != email || sent.File != "password-reset-email-template.html" {
+		t.Fatalf("email = %+v, want a password reset email to %s", sent, email)
+	}
+	link, err := url.Parse(sent.Data.(data.PasswordResetEmail).URL)
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+
+	return link.Query().Get("token")
+}
+
+func TestNewDeviceLoginSendsAlert(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "nelly@example.com")
+
+	// LOGIN PERTAMA SETELAH REGISTRASI DAN LOGIN DARI PERANGKAT YANG SAMA TIDAK MEMICU PERINGATAN
+	for i := 0; i < 2; i++ {
+		if _, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient); err != nil {
+			t.Fatalf("CompleteLogin: %v", err)
+		}
+		env.mailer.None(t)
+	}
+
+	// IP BARU DENGAN USER AGENT YANG SAMA TETAP DIANGGAP PERANGKAT BARU
+	newIP := testClient
+	newIP.IP = "192.0.2.44"
+	for _, client := range []dto.ClientInfo{newIP, phoneClient} {
+		if _, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, client); err != nil {
+			t.Fatalf("CompleteLogin: %v", err)
+		}
+		sent := env.mailer.Next(t)
+		alert := sent.Data.(data.NewDeviceLoginEmail)
+		if sent.File != "new-device-login-email-template.html" || alert.IPAddress != client.IP {
+			t.Fatalf("email = %+v, want a new device alert for %s", sent, client.IP)
+		}
+	}
+}
+
+func TestReportLoginSecuresAccount(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.verifyEmail(t, env.createUserWithPassword(t, "nelly@example.com", testPassword))
+
+	// PASSKEY DAN IDENTITY MILIK USER SENDIRI DITAMBAHKAN JAUH SEBELUM LOGIN YANG DILAPORKAN
+	ownAuthenticator := registerPasskey(t, passkeyService, user, "session")
+	env.mailer.Next(t)
+	if _, err := env.identityRepo.CreateIdentity(model.UserIdentities{UserID: user.ID, Provider: "google", ProviderUserID: "own-account", Email: user.Email}); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+	env.agePasskeysAndIdentities(t, user.ID.String())
+
+	token, attackerTokens := env.reportLink(t, user)
+
+	// PENYERANG MENAMBAHKAN PASSKEY DAN IDENTITY SETELAH BERHASIL LOGIN
+	registerPasskey(t, passkeyService, user, "attacker-session")
+	env.mailer.Next(t)
+	if _, err := env.identityRepo.CreateIdentity(model.UserIdentities{UserID: user.ID, Provider: "github", ProviderUserID: "attacker-account", Email: "attacker@example.com"}); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+
+	if err := env.loginHistoryService.ReportLogin(token); err != nil {
+		t.Fatalf("ReportLogin: %v", err)
+	}
+	resetToken := env.requestPasswordResetFromEmail(t, user.Email)
+
+	if !env.reloadUser(t, user.ID.String()).PasswordResetRequired {
+		t.Fatal("account was not flagged for a password reset")
+	}
+	if _, err := env.tokenService.RefreshTokens(attackerTokens.RefreshToken, phoneClient); err == nil {
+		t.Fatal("session from the reported login is still active")
+	}
+	passkeys, _ := env.passkeyRepo.GetPasskeysByUserID(user.ID.String())
+	if len(passkeys) != 1 || !passkeys[0].CreatedAt.Before(time.Now().Add(-24*time.Hour)) {
+		t.Fatalf("passkeys = %+v, want only the passkey added before the reported login", passkeys)
+	}
+	identities, _ := env.identityRepo.GetIdentitiesByUserID(user.ID.String())
+	if len(identities) != 1 || identities[0].ProviderUserID != "own-account" {
+		t.Fatalf("identities = %+v, want only the identity linked before the reported login", identities)
+	}
+
+	// LINK LAPORAN HANYA BISA DIPAKAI SEKALI
+	if err := env.loginHistoryService.ReportLogin(token); err == nil {
+		t.Fatal("report link was accepted twice")
+	}
+
+	// PASSWORD LAMA TIDAK BISA DIPAKAI UNTUK MENGGANTI PASSWORD, HANYA LINK RESET
+	if _, err := env.passwordService.ChangePassword(user.ID.String(), testPassword, "Brand-New-Password-77", testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("ChangePassword = %v, want ErrPasswordResetRequired", err)
+	}
+	if err := env.passwordLogin(user.Email, testPassword, testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("password login = %v, want ErrPasswordResetRequired", err)
+	}
+	options, _ := passkeyService.BeginLogin()
+	if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: ownAuthenticator.assert(t, options.Options)}, testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("passkey login = %v, want ErrPasswordResetRequired", err)
+	}
+	if reason := env.lastFailureReason(t, user.ID.String()); reason != data.LOGIN_FAILURE_RESET_REQUIRED {
+		t.Fatalf("last failure reason = %q, want %q", reason, data.LOGIN_FAILURE_RESET_REQUIRED)
+	}
+
+	if err := env.passwordService.ResetPassword(resetToken, "Brand-New-Password-77"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if env.reloadUser(t, user.ID.String()).PasswordResetRequired {
+		t.Fatal("password reset did not clear the flag")
+	}
+	if err := env.passwordLogin(user.Email, "Brand-New-Password-77", testClient); err != nil {
+		t.Fatalf("login after the password reset: %v", err)
+	}
+}
+
+func TestReportLoginKeepsLinkWhenAStepFails(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "nelly@example.com")
+	token, _ := env.reportLink(t, user)
+
+	// EMAIL TIDAK VALID MEMBUAT FORGOT PASSWORD GAGAL SETELAH SESSION DICABUT
+	user.Email = "not-an-email"
+	if _, err := env.userRepo.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if err := env.loginHistoryService.ReportLogin(token); err == nil {
+		t.Fatal("ReportLogin succeeded although the reset email could not be requested")
+	}
+
+	user = env.reloadUser(t, user.ID.String())
+	user.Email = "nelly@example.com"
+	if _, err := env.userRepo.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if err := env.loginHistoryService.ReportLogin(token); err != nil {
+		t.Fatalf("ReportLogin retry: %v", err)
+	}
+	env.requestPasswordResetFromEmail(t, user.Email)
+}
+
+func TestPasswordResetRequiredBlocksEveryLoginMethod(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+	user := env.verifyEmail(t, env.createUser(t, "nelly@example.com"))
+	secret, _ := env.enableTwoFactor(t, user.ID.String(), "")
+	user = env.reloadUser(t, user.ID.String())
+
+	// CHALLENGE 2FA YANG DIBUAT SEBELUM LOGIN DILAPORKAN
+	pending, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil || pending.Challenge == nil {
+		t.Fatalf("CompleteLogin = %+v, %v, want a 2FA challenge", pending, err)
+	}
+	binding, magicToken := env.requestMagicLink(t, magicLinkService, user.Email)
+	authCode, err := env.usersService.OAuthLogin(dto.OAuthProfile{Provider: "google", ProviderUserID: "google-1", Email: user.Email, EmailVerified: true}, testClient)
+	if err != nil {
+		t.Fatalf("OAuthLogin: %v", err)
+	}
+
+	user.PasswordResetRequired = true
+	if _, err := env.userRepo.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if _, err := env.twoFactorService.VerifyChallenge(pending.Challenge.ChallengeToken, totpCode(t, secret, 1), testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("VerifyChallenge = %v, want ErrPasswordResetRequired", err)
+	}
+	if _, err := magicLinkService.VerifyMagicLink(magicToken, binding, testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("VerifyMagicLink = %v, want ErrPasswordResetRequired", err)
+	}
+	if _, err := env.usersService.ExchangeAuthCode(authCode, testClient); !errors.Is(err, ErrPasswordResetRequired) {
+		t.Fatalf("ExchangeAuthCode = %v, want ErrPasswordResetRequired", err)
+	}
+	for _, loginMethod := range []string{data.LOGIN_METHOD_OTP, data.LOGIN_METHOD_MAGIC_LINK, data.LOGIN_METHOD_OAUTH} {
+		if _, err := env.twoFactorService.CompleteLogin(user, loginMethod, testClient); !errors.Is(err, ErrPasswordResetRequired) {
+			t.Fatalf("CompleteLogin(%s) = %v, want ErrPasswordResetRequired", loginMethod, err)
+		}
+	}
+}
+
+func TestFailedLoginsAreRecorded(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "nelly@example.com", testPassword)
+
+	t.Run("magic link from another browser", func(t *testing.T) {
+		magicLinkService := newTestMagicLinkService(env)
+		_, token := env.requestMagicLink(t, magicLinkService, user.Email)
+		if _, err := magicLinkService.VerifyMagicLink(token, "another-browser", phoneClient); err == nil {
+			t.Fatal("magic link was accepted from another browser")
+		}
+		if reason := env.lastFailureReason(t, user.ID.String()); reason != data.LOGIN_FAILURE_BROWSER_MISMATCH {
+			t.Fatalf("last failure reason = %q, want %q", reason, data.LOGIN_FAILURE_BROWSER_MISMATCH)
+		}
+	})
+
+	t.Run("passkey signed with another key", func(t *testing.T) {
+		passkeyService := newTestPasskeyService(t, env)
+		forged := *registerPasskey(t, passkeyService, user, "session")
+		env.mailer.Next(t)
+		forged.key = newSoftAuthenticator(t).key
+
+		options, _ := passkeyService.BeginLogin()
+		if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: forged.assert(t, options.Options)}, phoneClient); err == nil {
+			t.Fatal("FinishLogin accepted an invalid assertion")
+		}
+		if reason := env.lastFailureReason(t, user.ID.String()); reason != data.LOGIN_FAILURE_INVALID_PASSKEY {
+			t.Fatalf("last failure reason = %q, want %q", reason, data.LOGIN_FAILURE_INVALID_PASSKEY)
+		}
+	})
+
+	t.Run("oauth for an unverified account", func(t *testing.T) {
+		if _, err := env.usersService.OAuthLogin(dto.OAuthProfile{Provider: "google", ProviderUserID: "google-1", Email: user.Email, EmailVerified: true}, phoneClient); err == nil {
+			t.Fatal("provider was linked to an unverified account")
+		}
+		if reason := env.lastFailureReason(t, user.ID.String()); reason != data.LOGIN_FAILURE_ACCOUNT_NOT_LINKED {
+			t.Fatalf("last failure reason = %q, want %q", reason, data.LOGIN_FAILURE_ACCOUNT_NOT_LINKED)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+)
+
+var (
+	ErrMagicLinkCooldown   = errors.New("please wait before requesting another sign in link")
+	ErrMagicLinkDailyLimit = errors.New("too many sign in link requests for this email today, please try again tomorrow")
+)
+
+type MagicLinkService interface {
+	RequestMagicLink(email string, client dto.ClientInfo) (string, error)
+	VerifyMagicLink(token string, binding string, client dto.ClientInfo) (*dto.LoginResponse, error)
+}
+
+type magicLinkService struct {
+	userRepository      repository.UsersRepository
+	magicLinkRepository repository.MagicLinkRepository
+	twoFactorService    TwoFactorService
+	loginHistoryService LoginHistoryService
+	smtpClient          helper.SMTPClientInterface
+}
+
+func NewMagicLinkService(userRepository repository.UsersRepository, magicLinkRepository repository.MagicLinkRepository, twoFactorService TwoFactorService, loginHistoryService LoginHistoryService, smtpClient helper.SMTPClientInterface) MagicLinkService {
+	return &magicLinkService{
+		userRepository:      userRepository,
+		magicLinkRepository: magicLinkRepository,
+		twoFactorService:    twoFactorService,
+		loginHistoryService: loginHistoryService,
+		smtpClient:          smtpClient,
+	}
+}
+
+// RequestMagicLink mengirim link login ke email user dan mengembalikan nilai binding yang harus disimpan
+// di cookie browser yang meminta link. Binding selalu dikembalikan agar response tidak membocorkan apakah email terdaftar.
+func (magic_link_serv *magicLinkService) RequestMagicLink(email string, client dto.ClientInfo) (string, error) {
+	email = strings.TrimSpace(email)
+
+	// VALIDASI UNTUK FORMAT EMAIL SUDAH BENAR
+	if !helper.EmailValidator(email) {
+		return "", errors.New("please enter a valid email address")
+	}
+
+	// BATAS DICEK SEBELUM USER DICARI AGAR RESPONSE TETAP SAMA UNTUK EMAIL TERDAFTAR MAUPUN TIDAK
+	if err := magic_link_serv.checkRequestLimit(normalizeEmail(email), client.IP); err != nil {
+		return "", err
+	}
+
+	binding, err := secrets.Token()
+	if err != nil {
+		return "", err
+	}
+
+	// EMAIL DIKIRIM DI BACKGROUND AGAR WAKTU RESPONSE SAMA UNTUK EMAIL TERDAFTAR MAUPUN TIDAK
+	go func() {
+		user, err := magic_link_serv.userRepository.GetUserByEmail(email)
+		if err != nil {
+			return
+		}
+
+		token, err := secrets.Token()
+		if err != nil {
+			log.Error("Failed to generate magic link token: " + err.Error())
+			return
+		}
+
+		if err := magic_link_serv.magicLinkRepository.SaveMagicLink(helper.HashToken(token), model.MagicLink{
+			UserID:         user.ID.String(),
+			BrowserBinding: helper.HashToken(binding),
+		}, data.MAGIC_LINK_TTL); err != nil {
+			log.Error("Failed to save magic link: " + err.Error())
+			return
+		}
+
+		if err := magic_link_serv.smtpClient.SendSingleEmail(user.Email, "Your Sign In Link", "magic-link-email-template.html", data.MagicLinkEmail{
+			Name:      user.Name,
+			URL:       env.Cfg.Client.Url + "/magic-link?token=" + token,
+			ExpiresIn: int(data.MAGIC_LINK_TTL.Minutes()),
+		}); err != nil {
+			log.Error("Failed to send magic link email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		}
+	}()
+
+	return binding, nil
+}
+
+// VerifyMagicLink menukar token dari link dengan token login. Link hanya berlaku di browser yang memintanya,
+// sehingga link yang diteruskan ke orang lain tidak bisa dipakai.
+func (magic_link_serv *magicLinkService) VerifyMagicLink(token string, binding string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	if token == "" {
+		return nil, errors.New("token cannot be blank")
+	}
+
+	// BINDING DICOCOKKAN SEBELUM TOKEN DIHAPUS, LINK YANG DIBUKA DI BROWSER LAIN TETAP BERLAKU DI BROWSER USER
+	magicLink, matched, err := magic_link_serv.magicLinkRepository.ConsumeMagicLink(helper.HashToken(token), helper.HashToken(binding))
+	if err != nil {
+		return nil, errors.New("sign in link is invalid or expired")
+	}
+	if binding == "" || !matched {
+		// LINK YANG DIBUKA DI BROWSER LAIN DICATAT AGAR USER BISA MELIHAT PERCOBAAN DARI PERANGKAT ASING
+		if user, err := magic_link_serv.userRepository.GetUserByID(magicLink.UserID); err == nil {
+			magic_link_serv.loginHistoryService.RecordFailure(user, data.LOGIN_METHOD_MAGIC_LINK, client, data.LOGIN_FAILURE_BROWSER_MISMATCH)
+		}
+		return nil, errors.New("sign in link must be opened in the same browser where it was requested")
+	}
+
+	user, err := magic_link_serv.userRepository.GetUserByID(magicLink.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// LINK DITERIMA DI INBOX USER SEHINGGA EMAIL SUDAH TERBUKTI DIMILIKI
+	if !user.EmailVerfiedAt.Valid {
+		user.EmailVerfiedAt = sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		}
+		if user, err = magic_link_serv.userRepository.UpdateUser(user); err != nil {
+			return nil, err
+		}
+	}
+
+	return magic_link_serv.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_MAGIC_LINK, client)
+}
+
+// checkRequestLimit membatasi permintaan link per email dan per IP agar endpoint tidak dipakai untuk membanjiri inbox user
+func (magic_link_serv *magicLinkService) checkRequestLimit(email string, ip string) error {
+	acquired, err := magic_link_serv.magicLinkRepository.AcquireCooldown(data.LOGIN_SCOPE_ACCOUNT, email, data.MAGIC_LINK_RESEND_COOLDOWN)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrMagicLinkCooldown
+	}
+
+	if ip != "" {
+		acquired, err = magic_link_serv.magicLinkRepository.AcquireCooldown(data.LOGIN_SCOPE_IP, ip, data.MAGIC_LINK_IP_COOLDOWN)
+		if err != nil {
+			return err
+		}
+		if !acquired {
+			return ErrMagicLinkCooldown
+		}
+	}
+
+	count, err := magic_link_serv.magicLinkRepository.IncrementDailyCount(email)
+	if err != nil {
+		return err
+	}
+	if count > int64(data.MAGIC_LINK_DAILY_LIMIT) {
+		return ErrMagicLinkDailyLimit
+	}
+
+	return nil
+}
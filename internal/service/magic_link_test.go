@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/utils/data"
+)
+
+func newTestMagicLinkService(env *testEnv) MagicLinkService {
+	return NewMagicLinkService(env.userRepo, repository.NewMagicLinkRepository(env.redis), env.twoFactorService, env.loginHistoryService, env.mailer)
+}
+
+// requestMagicLink meminta link login dan mengembalikan binding cookie serta token dari email
+func (env *testEnv) requestMagicLink(t *testing.T, magicLinkService MagicLinkService, email string) (string, string) {
+	t.Helper()
+
+	binding, err := magicLinkService.RequestMagicLink(email, testClient)
+	if err != nil {
+		t.Fatalf("RequestMagicLink: %v", err)
+	}
+
+	sent := env.mailer.Next(t)
+	if sent.To != email || sent.File != "magic-link-email-template.html" {
+		t.Fatalf("email = %+v, want magic link to %s", sent, email)
+	}
+	link, err := url.Parse(sent.Data.(data.MagicLinkEmail).URL)
+	if err != nil {
+		t.Fatalf("parse email link: %v", err)
+	}
+
+	return binding, link.Query().Get("token")
+}
+
+func TestMagicLinkLogsInAndVerifiesEmail(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+	user := env.createUser(t, "nelly@example.com")
+
+	binding, token := env.requestMagicLink(t, magicLinkService, user.Email)
+
+	login, err := magicLinkService.VerifyMagicLink(token, binding, testClient)
+	if err != nil {
+		t.Fatalf("VerifyMagicLink: %v", err)
+	}
+	if login.Token == nil || login.Token.AccessToken == "" {
+		t.Fatalf("login = %+v, want tokens", login)
+	}
+	if !env.reloadUser(t, user.ID.String()).EmailVerfiedAt.Valid {
+		t.Fatal("email was not marked verified")
+	}
+
+	// LINK HANYA BISA DIPAKAI SEKALI
+	if _, err := magicLinkService.VerifyMagicLink(token, binding, testClient); err == nil {
+		t.Fatal("magic link was accepted twice")
+	}
+}
+
+func TestMagicLinkFromAnotherBrowserDoesNotBurnLink(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+	user := env.createUser(t, "nelly@example.com")
+
+	binding, token := env.requestMagicLink(t, magicLinkService, user.Email)
+
+	for _, otherBinding := range []string{"", "another-browser"} {
+		if _, err := magicLinkService.VerifyMagicLink(token, otherBinding, testClient); err == nil {
+			t.Fatalf("magic link was accepted with binding %q", otherBinding)
+		}
+	}
+
+	if _, err := magicLinkService.VerifyMagicLink(token, binding, testClient); err != nil {
+		t.Fatalf("VerifyMagicLink after a mismatched binding: %v", err)
+	}
+}
+
+func TestMagicLinkExpiresAndIsReplacedByNewerLink(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+	user := env.createUser(t, "nelly@example.com")
+
+	binding, token := env.requestMagicLink(t, magicLinkService, user.Email)
+	env.miniredis.FastForward(data.MAGIC_LINK_TTL + time.Second)
+	if _, err := magicLinkService.VerifyMagicLink(token, binding, testClient); err == nil {
+		t.Fatal("expired magic link was accepted")
+	}
+
+	firstBinding, firstToken := env.requestMagicLink(t, magicLinkService, user.Email)
+	env.miniredis.FastForward(data.MAGIC_LINK_RESEND_COOLDOWN)
+	secondBinding, secondToken := env.requestMagicLink(t, magicLinkService, user.Email)
+
+	if _, err := magicLinkService.VerifyMagicLink(firstToken, firstBinding, testClient); err == nil {
+		t.Fatal("magic link replaced by a newer request was accepted")
+	}
+	if _, err := magicLinkService.VerifyMagicLink(secondToken, secondBinding, testClient); err != nil {
+		t.Fatalf("VerifyMagicLink with the latest link: %v", err)
+	}
+}
+
+func TestMagicLinkRequestCooldownAndDailyLimit(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+	user := env.createUser(t, "nelly@example.com")
+
+	env.requestMagicLink(t, magicLinkService, user.Email)
+
+	// COOLDOWN BERLAKU PER EMAIL WALAUPUN DARI IP LAIN
+	otherClient := dto.ClientInfo{IP: "198.51.100.7", UserAgent: testClient.UserAgent}
+	if _, err := magicLinkService.RequestMagicLink("NELLY@example.com", otherClient); !errors.Is(err, ErrMagicLinkCooldown) {
+		t.Fatalf("RequestMagicLink during email cooldown = %v, want ErrMagicLinkCooldown", err)
+	}
+
+	// COOLDOWN BERLAKU PER IP WALAUPUN UNTUK EMAIL LAIN
+	env.miniredis.FastForward(data.MAGIC_LINK_IP_COOLDOWN - time.Second)
+	if _, err := magicLinkService.RequestMagicLink("unknown@example.com", testClient); !errors.Is(err, ErrMagicLinkCooldown) {
+		t.Fatalf("RequestMagicLink during ip cooldown = %v, want ErrMagicLinkCooldown", err)
+	}
+	env.mailer.None(t)
+
+	for i := 1; i < data.MAGIC_LINK_DAILY_LIMIT; i++ {
+		env.miniredis.FastForward(data.MAGIC_LINK_RESEND_COOLDOWN)
+		env.requestMagicLink(t, magicLinkService, user.Email)
+	}
+
+	env.miniredis.FastForward(data.MAGIC_LINK_RESEND_COOLDOWN)
+	if _, err := magicLinkService.RequestMagicLink(user.Email, testClient); !errors.Is(err, ErrMagicLinkDailyLimit) {
+		t.Fatalf("RequestMagicLink over the daily limit = %v, want ErrMagicLinkDailyLimit", err)
+	}
+	env.mailer.None(t)
+}
+
+func TestMagicLinkForUnknownEmailSendsNothing(t *testing.T) {
+	env := newTestEnv(t)
+	magicLinkService := newTestMagicLinkService(env)
+
+	binding, err := magicLinkService.RequestMagicLink("unknown@example.com", testClient)
+	if err != nil || binding == "" {
+		t.Fatalf("RequestMagicLink = %q, %v, want a binding for an unknown email", binding, err)
+	}
+	env.mailer.None(t)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/oauth"
+	"refina-auth/internal/utils/secrets"
+
+	"golang.org/x/oauth2"
+)
+
+type OAuthService interface {
+	StartAuth(ctx context.Context, provider string, mode string, userID string) (authURL string, binding string, err error)
+	HandleCallback(ctx context.Context, provider string, state string, binding string, code string) (model.OAuthState, dto.OAuthProfile, error)
+}
+
+type oauthService struct {
+	oauthStateRepository repository.OAuthStateRepository
+	providers            oauth.Registry
+}
+
+func NewOAuthService(oauthStateRepository repository.OAuthStateRepository, providers oauth.Registry) OAuthService {
+	return &oauthService{
+		oauthStateRepository: oauthStateRepository,
+		providers:            providers,
+	}
+}
+
+// StartAuth membuat URL login provider beserta state, PKCE challenge dan nonce
+func (oauth_serv *oauthService) StartAuth(ctx context.Context, providerName string, mode string, userID string) (string, string, error) {
+	provider, err := oauth_serv.providers.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, binding, oauthState, err := oauth_serv.createState(provider.Name(), mode, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, oauthState)
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, binding, nil
+}
+
+// HandleCallback memvalidasi state, menukar authorization code lalu mengambil profil user dari provider
+func (oauth_serv *oauthService) HandleCallback(ctx context.Context, providerName string, state string, binding string, code string) (model.OAuthState, dto.OAuthProfile, error) {
+	provider, err := oauth_serv.providers.Get(providerName)
+	if err != nil {
+		return model.OAuthState{}, dto.OAuthProfile{}, err
+	}
+
+	// VALIDASI STATE UNTUK MENCEGAH LOGIN CSRF
+	oauthState, err := oauth_serv.consumeState(provider.Name(), state, binding)
+	if err != nil {
+		return model.OAuthState{}, dto.OAuthProfile{}, err
+	}
+
+	if code == "" {
+		return model.OAuthState{}, dto.OAuthProfile{}, errors.New("authorization code not found")
+	}
+
+	token, err := provider.Exchange(ctx, code, oauthState.CodeVerifier)
+	if err != nil {
+		return model.OAuthState{}, dto.OAuthProfile{}, errors.New("failed to exchange token")
+	}
+
+	profile, err := provider.FetchProfile(ctx, token, oauthState.Nonce)
+	if err != nil {
+		return model.OAuthState{}, dto.OAuthProfile{}, err
+	}
+
+	return oauthState, profile, nil
+}
+
+// createState membuat state acak sekali pakai beserta PKCE code verifier dan nonce OIDC. Nilai binding harus disimpan
+// di cookie browser yang memulai flow, dan hanya hash-nya yang disimpan di Redis.
+func (oauth_serv *oauthService) createState(provider string, mode string, userID string) (string, string, model.OAuthState, error) {
+	state, err := secrets.Token()
+	if err != nil {
+		return "", "", model.OAuthState{}, err
+	}
+
+	binding, err := secrets.Token()
+	if err != nil {
+		return "", "", model.OAuthState{}, err
+	}
+
+	nonce, err := secrets.Token()
+	if err != nil {
+		return "", "", model.OAuthState{}, err
+	}
+
+	oauthState := model.OAuthState{
+		Provider:       provider,
+		Mode:           mode,
+		UserID:         userID,
+		CodeVerifier:   oauth2.GenerateVerifier(),
+		Nonce:          nonce,
+		BrowserBinding: helper.HashToken(binding),
+	}
+
+	if err := oauth_serv.oauthStateRepository.SaveState(state, oauthState, data.OAUTH_STATE_TTL); err != nil {
+		return "", "", model.OAuthState{}, err
+	}
+
+	return state, binding, oauthState, nil
+}
+
+func (oauth_serv *oauthService) consumeState(provider string, state string, binding string) (model.OAuthState, error) {
+	if state == "" {
+		return model.OAuthState{}, errors.New("oauth state is missing")
+	}
+
+	// STATE DIHAPUS SAAT DIAMBIL SEHINGGA REPLAY AKAN DITOLAK
+	oauthState, err := oauth_serv.oauthStateRepository.ConsumeState(state)
+	if err != nil {
+		return model.OAuthState{}, errors.New("oauth state is invalid or expired")
+	}
+
+	if oauthState.Provider != provider {
+		return model.OAuthState{}, errors.New("oauth state does not match provider")
+	}
+
+	// VALIDASI STATE DIPAKAI OLEH BROWSER YANG SAMA DENGAN YANG MEMULAI LOGIN
+	if binding == "" || subtle.ConstantTimeCompare([]byte(oauthState.BrowserBinding), []byte(helper.HashToken(binding))) != 1 {
+		return model.OAuthState{}, errors.New("oauth state was not issued to this browser")
+	}
+
+	return oauthState, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/oauth"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeProvider - provider OAuth yang mencatat PKCE verifier dan nonce yang dipakai
+type fakeProvider struct {
+	name          string
+	codeVerifier  string
+	nonce         string
+	authURLNonce  string
+	codeChallenge string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) AuthCodeURL(ctx context.Context, state string, oauthState model.OAuthState) (string, error) {
+	p.authURLNonce = oauthState.Nonce
+	return "https://provider.example/authorize?" + url.Values{
+		"state":                 {state},
+		"code_challenge":        {oauth2.S256ChallengeFromVerifier(oauthState.CodeVerifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode(), nil
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauth2.Token, error) {
+	if code != "valid-code" {
+		return nil, errors.New("invalid code")
+	}
+	p.codeVerifier = codeVerifier
+	return &oauth2.Token{AccessToken: "access"}, nil
+}
+
+func (p *fakeProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (dto.OAuthProfile, error) {
+	p.nonce = nonce
+	return dto.OAuthProfile{Provider: p.name, ProviderUserID: "fake-1", Email: "fake@example.com", EmailVerified: true}, nil
+}
+
+type fakeRegistry map[string]oauth.Provider
+
+func (registry fakeRegistry) Get(name string) (oauth.Provider, error) {
+	provider, ok := registry[name]
+	if !ok {
+		return nil, errors.New("oauth provider is not configured")
+	}
+	return provider, nil
+}
+
+func newTestOAuthService(t *testing.T, env *testEnv) (OAuthService, *fakeProvider) {
+	t.Helper()
+
+	provider := &fakeProvider{name: "fake"}
+	registry := fakeRegistry{"fake": provider, "other": &fakeProvider{name: "other"}}
+
+	return NewOAuthService(repository.NewOAuthStateRepository(env.redis), registry), provider
+}
+
+func startTestOAuth(t *testing.T, oauthService OAuthService, provider string) (string, string, url.Values) {
+	t.Helper()
+
+	authURL, binding, err := oauthService.StartAuth(context.Background(), provider, model.OAuthModeLogin, "")
+	if err != nil {
+		t.Fatalf("StartAuth: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse auth url: %v", err)
+	}
+	query := parsed.Query()
+
+	return query.Get("state"), binding, query
+}
+
+func TestOAuthCallbackUsesPKCEAndNonce(t *testing.T) {
+	env := newTestEnv(t)
+	oauthService, provider := newTestOAuthService(t, env)
+
+	state, binding, query := startTestOAuth(t, oauthService, "fake")
+	if state == "" || binding == "" {
+		t.Fatal("StartAuth returned an empty state or binding")
+	}
+
+	oauthState, profile, err := oauthService.HandleCallback(context.Background(), "fake", state, binding, "valid-code")
+	if err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if profile.ProviderUserID != "fake-1" || oauthState.Mode != model.OAuthModeLogin {
+		t.Fatalf("HandleCallback = %+v, %+v", oauthState, profile)
+	}
+
+	// VERIFIER YANG DIKIRIM SAAT EXCHANGE HARUS COCOK DENGAN CHALLENGE DI URL LOGIN
+	if oauth2.S256ChallengeFromVerifier(provider.codeVerifier) != query.Get("code_challenge") {
+		t.Fatal("code verifier does not match the S256 challenge")
+	}
+	if provider.nonce == "" || provider.nonce != provider.authURLNonce {
+		t.Fatalf("nonce = %q, want the nonce sent in the auth url %q", provider.nonce, provider.authURLNonce)
+	}
+}
+
+func TestOAuthCallbackRejectsInvalidState(t *testing.T) {
+	env := newTestEnv(t)
+	oauthService, _ := newTestOAuthService(t, env)
+	ctx := context.Background()
+
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", "", "binding", "valid-code"); err == nil {
+		t.Fatal("missing state was accepted")
+	}
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", "forged-state", "binding", "valid-code"); err == nil {
+		t.Fatal("unknown state was accepted")
+	}
+
+	// STATE DARI BROWSER LAIN (LOGIN CSRF)
+	state, _, _ := startTestOAuth(t, oauthService, "fake")
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, "attacker-binding", "valid-code"); err == nil {
+		t.Fatal("state bound to another browser was accepted")
+	}
+
+	// STATE UNTUK PROVIDER LAIN
+	state, binding, _ := startTestOAuth(t, oauthService, "other")
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "valid-code"); err == nil {
+		t.Fatal("state issued for another provider was accepted")
+	}
+
+	// STATE KADALUARSA
+	state, binding, _ = startTestOAuth(t, oauthService, "fake")
+	env.miniredis.FastForward(data.OAUTH_STATE_TTL + 1)
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "valid-code"); err == nil {
+		t.Fatal("expired state was accepted")
+	}
+}
+
+func TestOAuthCallbackStateIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	oauthService, _ := newTestOAuthService(t, env)
+	ctx := context.Background()
+
+	state, binding, _ := startTestOAuth(t, oauthService, "fake")
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "valid-code"); err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "valid-code"); err == nil {
+		t.Fatal("replayed state was accepted")
+	}
+
+	// STATE TETAP TERPAKAI MESKIPUN CODE GAGAL DITUKAR
+	state, binding, _ = startTestOAuth(t, oauthService, "fake")
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "bad-code"); err == nil {
+		t.Fatal("invalid authorization code was accepted")
+	}
+	if _, _, err := oauthService.HandleCallback(ctx, "fake", state, binding, "valid-code"); err == nil {
+		t.Fatal("state was reusable after a failed exchange")
+	}
+}
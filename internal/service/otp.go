@@ -1,28 +1,104 @@
 package service
 
 import (
-	"time"
+	"errors"
+	"strings"
 
+	"refina-auth/config/env"
 	"refina-auth/internal/repository"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+)
+
+var (
+	ErrOTPResendCooldown = errors.New("please wait before requesting another OTP")
+	ErrOTPDailyLimit     = errors.New("too many OTP requests for this email today, please try again tomorrow")
 )
 
 type OTPService interface {
-	SetOTP(email string, otp string, duration time.Duration) error
-	ValidateOTP(email string, otp string) (bool, error)
+	SendOTP(purpose model.OTPPurpose, email string) error
+	ValidateOTP(purpose model.OTPPurpose, email string, otp string) (bool, error)
 }
 
 type otpService struct {
 	otpRepository repository.OTPRepository
+	smtpClient    helper.SMTPClientInterface
+}
+
+func NewOTPService(otpRepository repository.OTPRepository, smtpClient helper.SMTPClientInterface) OTPService {
+	return &otpService{
+		otpRepository: otpRepository,
+		smtpClient:    smtpClient,
+	}
 }
 
-func NewOTPService(otpRepository repository.OTPRepository) OTPService {
-	return &otpService{otpRepository}
+// SendOTP membuat OTP baru untuk purpose tertentu lalu mengirimkannya ke email,
+// dengan jeda minimum antar pengiriman dan batas pengiriman harian per email
+func (otpServ *otpService) SendOTP(purpose model.OTPPurpose, email string) error {
+	email = normalizeEmail(email)
+
+	// VALIDASI UNTUK FORMAT EMAIL SUDAH BENAR
+	if !helper.EmailValidator(email) {
+		return errors.New("please enter a valid email address")
+	}
+
+	acquired, err := otpServ.otpRepository.AcquireResendCooldown(purpose, email, data.OTP_RESEND_COOLDOWN)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrOTPResendCooldown
+	}
+
+	count, err := otpServ.otpRepository.IncrementDailyCount(email)
+	if err != nil {
+		return err
+	}
+	if count > int64(data.OTP_DAILY_LIMIT) {
+		return ErrOTPDailyLimit
+	}
+
+	otp, err := secrets.NumericCode(env.Cfg.OTP.Length)
+	if err != nil {
+		return err
+	}
+	otpHash, err := hashOTP(purpose, email, otp)
+	if err != nil {
+		return err
+	}
+	if err := otpServ.otpRepository.SetOTP(purpose, email, otpHash, data.OTP_TTL); err != nil {
+		return err
+	}
+
+	return otpServ.smtpClient.SendSingleEmail(email, "OTP Verification", "otp-email-template.html", data.OTP{
+		Email: email,
+		OTP:   otp,
+	})
+}
+
+// ValidateOTP memvalidasi OTP sesuai purpose-nya. OTP langsung dihapus setelah berhasil dipakai
+// atau setelah jumlah percobaan gagal mencapai batas.
+func (otpServ *otpService) ValidateOTP(purpose model.OTPPurpose, email string, otp string) (bool, error) {
+	email = normalizeEmail(email)
+	if email == "" || otp == "" {
+		return false, nil
+	}
+
+	otpHash, err := hashOTP(purpose, email, otp)
+	if err != nil {
+		return false, err
+	}
+
+	return otpServ.otpRepository.ValidateOTP(purpose, email, otpHash, data.OTP_MAX_ATTEMPTS)
 }
 
-func (otpServ *otpService) SetOTP(email string, otp string, duration time.Duration) error {
-	return otpServ.otpRepository.SetOTP(email, otp, duration)
+// hashOTP - hash dicampur dengan purpose dan email agar hash yang sama tidak berlaku di konteks lain
+func hashOTP(purpose model.OTPPurpose, email string, otp string) (string, error) {
+	return helper.HashOTP(string(purpose) + ":" + email + ":" + otp)
 }
 
-func (otpServ *otpService) ValidateOTP(email string, otp string) (bool, error) {
-	return otpServ.otpRepository.ValidateOTP(email, otp)
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
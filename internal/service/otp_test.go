@@ -0,0 +1,119 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+)
+
+func newTestOTPService(env *testEnv) OTPService {
+	return NewOTPService(repository.NewOTPRepository(env.redis), env.mailer)
+}
+
+// sendOTP mengirim OTP lalu mengambil kode dari email
+func (env *testEnv) sendOTP(t *testing.T, otpService OTPService, purpose model.OTPPurpose, email string) string {
+	t.Helper()
+
+	if err := otpService.SendOTP(purpose, email); err != nil {
+		t.Fatalf("SendOTP: %v", err)
+	}
+
+	return env.mailer.Next(t).Data.(data.OTP).OTP
+}
+
+func TestOTPIsScopedToPurposeAndSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	otpService := newTestOTPService(env)
+
+	otp := env.sendOTP(t, otpService, model.OTPPurposeLogin, "otp@example.com")
+	if len(otp) != testConfig().OTP.Length {
+		t.Fatalf("otp = %q, want %d digits", otp, testConfig().OTP.Length)
+	}
+
+	if valid, _ := otpService.ValidateOTP(model.OTPPurposePasswordReset, "otp@example.com", otp); valid {
+		t.Fatal("login OTP was accepted for password reset")
+	}
+	if valid, _ := otpService.ValidateOTP(model.OTPPurposeLogin, "other@example.com", otp); valid {
+		t.Fatal("OTP was accepted for another email")
+	}
+
+	// EMAIL DINORMALISASI SEBELUM DICOCOKKAN
+	if valid, err := otpService.ValidateOTP(model.OTPPurposeLogin, " OTP@Example.com ", otp); err != nil || !valid {
+		t.Fatalf("ValidateOTP = %v, %v, want true", valid, err)
+	}
+	if valid, _ := otpService.ValidateOTP(model.OTPPurposeLogin, "otp@example.com", otp); valid {
+		t.Fatal("OTP was accepted twice")
+	}
+}
+
+func TestOTPIsStoredAsKeyedHash(t *testing.T) {
+	env := newTestEnv(t)
+	otpService := newTestOTPService(env)
+
+	otp := env.sendOTP(t, otpService, model.OTPPurposeLogin, "otp@example.com")
+
+	stored := env.miniredis.HGet("otp:"+string(model.OTPPurposeLogin)+":otp@example.com", "hash")
+	if stored == "" || strings.Contains(stored, otp) {
+		t.Fatalf("stored otp hash = %q", stored)
+	}
+	// HASH TIDAK BISA DICOCOKKAN TANPA SECRET SERVER
+	if stored == helper.HashToken(string(model.OTPPurposeLogin)+":otp@example.com:"+otp) {
+		t.Fatal("otp is stored as an unkeyed SHA-256 hash")
+	}
+
+	testConfig().Server.OTPSecretKey = "rotated-otp-secret"
+	if valid, _ := otpService.ValidateOTP(model.OTPPurposeLogin, "otp@example.com", otp); valid {
+		t.Fatal("OTP was accepted with a different secret key")
+	}
+
+	testConfig().Server.OTPSecretKey = ""
+	if err := otpService.SendOTP(model.OTPPurposeVerifyEmail, "otp@example.com"); err == nil {
+		t.Fatal("SendOTP without a secret key = nil, want an error")
+	}
+}
+
+func TestOTPIsBurnedAfterMaxAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	otpService := newTestOTPService(env)
+
+	otp := env.sendOTP(t, otpService, model.OTPPurposeLogin, "otp@example.com")
+	wrong := strings.Repeat("0", len(otp))
+	if wrong == otp {
+		wrong = strings.Repeat("1", len(otp))
+	}
+
+	for i := 0; i < data.OTP_MAX_ATTEMPTS; i++ {
+		if valid, _ := otpService.ValidateOTP(model.OTPPurposeLogin, "otp@example.com", wrong); valid {
+			t.Fatal("wrong OTP was accepted")
+		}
+	}
+	if valid, _ := otpService.ValidateOTP(model.OTPPurposeLogin, "otp@example.com", otp); valid {
+		t.Fatal("OTP was still accepted after too many failed attempts")
+	}
+}
+
+func TestOTPResendCooldownAndDailyLimit(t *testing.T) {
+	env := newTestEnv(t)
+	otpService := newTestOTPService(env)
+
+	env.sendOTP(t, otpService, model.OTPPurposeLogin, "otp@example.com")
+	if err := otpService.SendOTP(model.OTPPurposeLogin, "otp@example.com"); err != ErrOTPResendCooldown {
+		t.Fatalf("SendOTP during cooldown = %v, want ErrOTPResendCooldown", err)
+	}
+	env.mailer.None(t)
+
+	for i := 1; i < data.OTP_DAILY_LIMIT; i++ {
+		env.miniredis.FastForward(data.OTP_RESEND_COOLDOWN)
+		env.sendOTP(t, otpService, model.OTPPurposeLogin, "otp@example.com")
+	}
+
+	env.miniredis.FastForward(data.OTP_RESEND_COOLDOWN)
+	if err := otpService.SendOTP(model.OTPPurposeLogin, "otp@example.com"); err != ErrOTPDailyLimit {
+		t.Fatalf("SendOTP over the daily limit = %v, want ErrOTPDailyLimit", err)
+	}
+	env.mailer.None(t)
+}
@@ -0,0 +1,512 @@
+package service
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/hasher"
+	"refina-auth/internal/utils/secrets"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+type PasskeyService interface {
+	SendStepUpOTP(userID string) error
+	BeginRegistration(userID string, sessionID string, request dto.PasskeyStepUpRequest, client dto.ClientInfo) (*protocol.CredentialCreation, error)
+	FinishRegistration(userID string, sessionID string, request dto.PasskeyRegisterRequest, client dto.ClientInfo) (dto.PasskeyResponse, error)
+	BeginLogin() (dto.PasskeyLoginOptionsResponse, error)
+	FinishLogin(request dto.PasskeyLoginRequest, client dto.ClientInfo) (*dto.TokenResponse, error)
+	GetPasskeys(userID string) ([]dto.PasskeyResponse, error)
+	RenamePasskey(userID string, passkeyID string, name string) (dto.PasskeyResponse, error)
+	DeletePasskey(userID string, passkeyID string) error
+}
+
+type passkeyService struct {
+	userRepository            repository.UsersRepository
+	identityRepository        repository.IdentityRepository
+	passkeyRepository         repository.PasskeyRepository
+	webAuthnSessionRepository repository.WebAuthnSessionRepository
+	tokenService              TokenService
+	twoFactorService          TwoFactorService
+	otpService                OTPService
+	loginAttemptService       LoginAttemptService
+	loginHistoryService       LoginHistoryService
+	passwordHasher            hasher.Hasher
+	smtpClient                helper.SMTPClientInterface
+	webAuthn                  *webauthn.WebAuthn
+}
+
+func NewPasskeyService(userRepository repository.UsersRepository, identityRepository repository.IdentityRepository, passkeyRepository repository.PasskeyRepository, webAuthnSessionRepository repository.WebAuthnSessionRepository, tokenService TokenService, twoFactorService TwoFactorService, otpService OTPService, loginAttemptService LoginAttemptService, loginHistoryService LoginHistoryService, passwordHasher hasher.Hasher, smtpClient helper.SMTPClientInterface) (PasskeyService, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          env.Cfg.WebAuthn.RPID,
+		RPDisplayName: env.Cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     env.Cfg.WebAuthn.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: data.WEBAUTHN_SESSION_TTL,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: data.WEBAUTHN_SESSION_TTL,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &passkeyService{
+		userRepository:            userRepository,
+		identityRepository:        identityRepository,
+		passkeyRepository:         passkeyRepository,
+		webAuthnSessionRepository: webAuthnSessionRepository,
+		tokenService:              tokenService,
+		twoFactorService:          twoFactorService,
+		otpService:                otpService,
+		loginAttemptService:       loginAttemptService,
+		loginHistoryService:       loginHistoryService,
+		passwordHasher:            passwordHasher,
+		smtpClient:                smtpClient,
+		webAuthn:                  webAuthn,
+	}, nil
+}
+
+// webAuthnUser - adapter model.Users ke interface webauthn.User, user handle memakai 16 byte id user
+type webAuthnUser struct {
+	user     model.Users
+	passkeys []model.UserPasskeys
+}
+
+func (user webAuthnUser) WebAuthnID() []byte {
+	id := user.user.ID
+	return id[:]
+}
+
+func (user webAuthnUser) WebAuthnName() string {
+	return user.user.Email
+}
+
+func (user webAuthnUser) WebAuthnDisplayName() string {
+	return user.user.Name
+}
+
+func (user webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(user.passkeys))
+	for _, passkey := range user.passkeys {
+		credentials = append(credentials, webauthn.Credential{
+			ID:              passkey.CredentialID,
+			PublicKey:       passkey.PublicKey,
+			AttestationType: passkey.AttestationType,
+			Transport:       splitTransports(passkey.Transports),
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    true,
+				UserVerified:   passkey.UserVerified,
+				BackupEligible: passkey.BackupEligible,
+				BackupState:    passkey.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       passkey.AAGUID,
+				SignCount:    passkey.SignCount,
+				CloneWarning: passkey.CloneWarning,
+			},
+		})
+	}
+
+	return credentials
+}
+
+func (passkey_serv *passkeyService) loadWebAuthnUser(userID string) (webAuthnUser, error) {
+	user, err := passkey_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return webAuthnUser{}, err
+	}
+
+	passkeys, err := passkey_serv.passkeyRepository.GetPasskeysByUserID(userID)
+	if err != nil {
+		return webAuthnUser{}, err
+	}
+
+	return webAuthnUser{user: user, passkeys: passkeys}, nil
+}
+
+// SendStepUpOTP mengirim OTP ke email user untuk konfirmasi identitas sebelum registrasi passkey,
+// dipakai akun yang tidak punya password maupun 2FA
+func (passkey_serv *passkeyService) SendStepUpOTP(userID string) error {
+	user, err := passkey_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return passkey_serv.otpService.SendOTP(model.OTPPurposeStepUp, user.Email)
+}
+
+// BeginRegistration membuat challenge registrasi passkey untuk user yang sedang login setelah identitasnya
+// dikonfirmasi ulang. Credential yang sudah terdaftar dikecualikan agar authenticator yang sama tidak didaftarkan dua kali.
+func (passkey_serv *passkeyService) BeginRegistration(userID string, sessionID string, request dto.PasskeyStepUpRequest, client dto.ClientInfo) (*protocol.CredentialCreation, error) {
+	user, err := passkey_serv.loadWebAuthnUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// ACCESS TOKEN SAJA TIDAK CUKUP UNTUK MENAMBAH CARA LOGIN BARU, SEHINGGA TOKEN CURIAN TIDAK BISA
+	// DIPAKAI UNTUK MENANAM PASSKEY MILIK PENYERANG
+	if err := passkey_serv.verifyStepUp(user.user, request, client); err != nil {
+		return nil, err
+	}
+
+	var exclusions []protocol.CredentialDescriptor
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := passkey_serv.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementRequired,
+			UserVerification: protocol.VerificationRequired,
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// SATU SESSION LOGIN HANYA PUNYA SATU CEREMONY REGISTRASI AKTIF, CHALLENGE SEBELUMNYA DITIMPA
+	if err := passkey_serv.webAuthnSessionRepository.SaveSession(registrationSessionKey(userID, sessionID), *session, data.WEBAUTHN_SESSION_TTL); err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+// FinishRegistration hanya bisa menyelesaikan ceremony yang dimulai dari session login yang sama setelah
+// step-up berhasil, lalu memberitahu pemilik akun lewat email
+func (passkey_serv *passkeyService) FinishRegistration(userID string, sessionID string, request dto.PasskeyRegisterRequest, client dto.ClientInfo) (dto.PasskeyResponse, error) {
+	name, err := validatePasskeyName(request.Name)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	session, err := passkey_serv.webAuthnSessionRepository.ConsumeSession(registrationSessionKey(userID, sessionID))
+	if err != nil {
+		return dto.PasskeyResponse{}, errors.New("passkey registration session is invalid or expired")
+	}
+
+	user, err := passkey_serv.loadWebAuthnUser(userID)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	parsedResponse, err := protocol.ParseCredentialCreationResponseBytes(request.Credential)
+	if err != nil {
+		return dto.PasskeyResponse{}, errors.New("invalid passkey registration response")
+	}
+
+	credential, err := passkey_serv.webAuthn.CreateCredential(user, session, parsedResponse)
+	if err != nil {
+		return dto.PasskeyResponse{}, errors.New("failed to verify passkey registration")
+	}
+
+	// CREDENTIAL ID HARUS UNIK DI SELURUH USER
+	if _, err := passkey_serv.passkeyRepository.GetPasskeyByCredentialID(credential.ID); err == nil {
+		return dto.PasskeyResponse{}, errors.New("this passkey is already registered")
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	passkey, err := passkey_serv.passkeyRepository.CreatePasskey(model.UserPasskeys{
+		UserID:          user.user.ID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	})
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	go passkey_serv.sendPasskeyAddedNotice(user.user, passkey, client)
+
+	return toPasskeyResponse(passkey), nil
+}
+
+// BeginLogin membuat challenge untuk login passkey tanpa email (discoverable credential).
+// Session id dikembalikan ke client dan hanya hash-nya yang disimpan.
+func (passkey_serv *passkeyService) BeginLogin() (dto.PasskeyLoginOptionsResponse, error) {
+	assertion, session, err := passkey_serv.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return dto.PasskeyLoginOptionsResponse{}, err
+	}
+
+	sessionID, err := secrets.Token()
+	if err != nil {
+		return dto.PasskeyLoginOptionsResponse{}, err
+	}
+
+	if err := passkey_serv.webAuthnSessionRepository.SaveSession("login:"+helper.HashToken(sessionID), *session, data.WEBAUTHN_SESSION_TTL); err != nil {
+		return dto.PasskeyLoginOptionsResponse{}, err
+	}
+
+	return dto.PasskeyLoginOptionsResponse{
+		SessionID: sessionID,
+		Options:   assertion,
+	}, nil
+}
+
+// FinishLogin memvalidasi assertion passkey lalu menerbitkan token. Passkey dengan user verification
+// sudah mencakup dua faktor (perangkat dan biometrik/PIN) sehingga tidak meminta kode TOTP lagi.
+func (passkey_serv *passkeyService) FinishLogin(request dto.PasskeyLoginRequest, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	session, err := passkey_serv.webAuthnSessionRepository.ConsumeSession("login:" + helper.HashToken(request.SessionID))
+	if err != nil {
+		return nil, errors.New("passkey login session is invalid or expired")
+	}
+
+	parsedResponse, err := protocol.ParseCredentialRequestResponseBytes(request.Credential)
+	if err != nil {
+		return nil, errors.New("invalid passkey login response")
+	}
+
+	// MENCARI USER BERDASARKAN CREDENTIAL ID, USER HANDLE DARI AUTHENTICATOR HARUS SESUAI DENGAN PEMILIK PASSKEY
+	var passkey model.UserPasskeys
+	var owner model.Users
+	user, credential, err := passkey_serv.webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		passkey, err = passkey_serv.passkeyRepository.GetPasskeyByCredentialID(rawID)
+		if err != nil {
+			return nil, err
+		}
+
+		passkeyOwner, err := passkey_serv.loadWebAuthnUser(passkey.UserID.String())
+		if err != nil {
+			return nil, err
+		}
+		owner = passkeyOwner.user
+		if !bytes.Equal(passkeyOwner.WebAuthnID(), userHandle) {
+			return nil, errors.New("user handle does not match passkey owner")
+		}
+
+		return passkeyOwner, nil
+	}, session, parsedResponse)
+	if err != nil {
+		// KEGAGALAN HANYA DICATAT JIKA PASSKEY TERDAFTAR, CREDENTIAL ID ACAK TIDAK MENUNJUK AKUN MANA PUN
+		if owner.ID != uuid.Nil {
+			passkey_serv.loginHistoryService.RecordFailure(owner, data.LOGIN_METHOD_PASSKEY, client, data.LOGIN_FAILURE_INVALID_PASSKEY)
+		}
+		return nil, errors.New("passkey verification failed")
+	}
+	owner = user.(webAuthnUser).user
+
+	// SIGN COUNT YANG TIDAK NAIK MENANDAKAN AUTHENTICATOR KEMUNGKINAN DIKLONING
+	if credential.Authenticator.CloneWarning {
+		passkey_serv.loginHistoryService.RecordFailure(owner, data.LOGIN_METHOD_PASSKEY, client, data.LOGIN_FAILURE_CLONED_PASSKEY)
+		passkey.CloneWarning = true
+		if _, err := passkey_serv.passkeyRepository.UpdatePasskey(passkey); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("passkey verification failed, this passkey may have been cloned")
+	}
+
+	passkey.SignCount = credential.Authenticator.SignCount
+	passkey.BackupState = credential.Flags.BackupState
+	passkey.LastUsedAt = sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	}
+	if _, err := passkey_serv.passkeyRepository.UpdatePasskey(passkey); err != nil {
+		return nil, err
+	}
+
+	// AKUN YANG DILAPORKAN "THIS WASN'T ME" HARUS MENGGANTI PASSWORD LEWAT LINK RESET TERLEBIH DAHULU
+	if err := passkey_serv.loginHistoryService.CheckPasswordReset(owner, data.LOGIN_METHOD_PASSKEY, client); err != nil {
+		return nil, err
+	}
+
+	token, err := passkey_serv.tokenService.GenerateTokens(owner, data.LOGIN_METHOD_PASSKEY, client)
+	if err != nil {
+		return nil, err
+	}
+	passkey_serv.loginHistoryService.RecordSuccess(owner, data.LOGIN_METHOD_PASSKEY, client)
+
+	return token, nil
+}
+
+func (passkey_serv *passkeyService) GetPasskeys(userID string) ([]dto.PasskeyResponse, error) {
+	passkeys, err := passkey_serv.passkeyRepository.GetPasskeysByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeysResponse := []dto.PasskeyResponse{}
+	for _, passkey := range passkeys {
+		passkeysResponse = append(passkeysResponse, toPasskeyResponse(passkey))
+	}
+
+	return passkeysResponse, nil
+}
+
+func (passkey_serv *passkeyService) RenamePasskey(userID string, passkeyID string, name string) (dto.PasskeyResponse, error) {
+	name, err := validatePasskeyName(name)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	passkey, err := passkey_serv.passkeyRepository.GetPasskeyByID(passkeyID)
+	if err != nil || passkey.UserID.String() != userID {
+		return dto.PasskeyResponse{}, errors.New("passkey not found")
+	}
+
+	passkey.Name = name
+	passkeyUpdated, err := passkey_serv.passkeyRepository.UpdatePasskey(passkey)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	return toPasskeyResponse(passkeyUpdated), nil
+}
+
+func (passkey_serv *passkeyService) DeletePasskey(userID string, passkeyID string) error {
+	passkey, err := passkey_serv.passkeyRepository.GetPasskeyByID(passkeyID)
+	if err != nil || passkey.UserID.String() != userID {
+		return errors.New("passkey not found")
+	}
+
+	user, err := passkey_serv.loadWebAuthnUser(userID)
+	if err != nil {
+		return err
+	}
+
+	// VALIDASI AGAR USER MASIH PUNYA CARA LAIN UNTUK LOGIN
+	identities, err := passkey_serv.identityRepository.GetIdentitiesByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if user.user.Password == "" && len(identities) == 0 && len(user.passkeys) <= 1 {
+		return errors.New("cannot delete the only sign-in method, please set a password or add another passkey first")
+	}
+
+	return passkey_serv.passkeyRepository.DeletePasskey(passkey)
+}
+
+// verifyStepUp mengonfirmasi ulang identitas user dengan faktor terkuat yang dimilikinya: kode TOTP jika 2FA aktif,
+// password saat ini jika punya password, selain itu OTP yang dikirim ke email
+func (passkey_serv *passkeyService) verifyStepUp(user model.Users, request dto.PasskeyStepUpRequest, client dto.ClientInfo) error {
+	switch {
+	case user.TwoFactorEnabledAt.Valid:
+		if request.Code == "" {
+			return errors.New("please enter the code from your authenticator app to add a passkey")
+		}
+		return passkey_serv.twoFactorService.VerifyCode(user.ID.String(), request.Code)
+
+	case user.Password != "":
+		if request.CurrentPassword == "" {
+			return errors.New("please enter your current password to add a passkey")
+		}
+
+		// PERCOBAAN PASSWORD DIHITUNG BERSAMA PERCOBAAN LOGIN AGAR TIDAK BISA DIPAKAI UNTUK MENEBAK PASSWORD
+		if err := passkey_serv.loginAttemptService.CheckLogin(user.Email, client.IP); err != nil {
+			return err
+		}
+		if match, _ := passkey_serv.passwordHasher.Verify(user.Password, request.CurrentPassword); !match {
+			if err := passkey_serv.loginAttemptService.RecordFailure(user.Email); err != nil {
+				return err
+			}
+			return errors.New("current password is incorrect")
+		}
+		return passkey_serv.loginAttemptService.RecordSuccess(user.Email, client.IP)
+
+	default:
+		if request.Code == "" {
+			return errors.New("please enter the verification code sent to your email to add a passkey")
+		}
+		valid, err := passkey_serv.otpService.ValidateOTP(model.OTPPurposeStepUp, user.Email, request.Code)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return errors.New("verification code is invalid or expired")
+		}
+		return nil
+	}
+}
+
+func (passkey_serv *passkeyService) sendPasskeyAddedNotice(user model.Users, passkey model.UserPasskeys, client dto.ClientInfo) {
+	if err := passkey_serv.smtpClient.SendSingleEmail(user.Email, "A Passkey Was Added to Your Account", "passkey-added-email-template.html", data.PasskeyAddedEmail{
+		Name:        user.Name,
+		PasskeyName: passkey.Name,
+		Device:      helper.DeviceName(client.UserAgent),
+		IPAddress:   client.IP,
+		Time:        passkey.CreatedAt.UTC().Format(data.LOGIN_ALERT_TIME_FORMAT),
+		URL:         env.Cfg.Client.Url + "/settings/security",
+	}); err != nil {
+		log.Error("Failed to send passkey added email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+	}
+}
+
+// registrationSessionKey - ceremony registrasi terikat ke session login yang memulainya
+func registrationSessionKey(userID string, sessionID string) string {
+	return "registration:" + userID + ":" + sessionID
+}
+
+func validatePasskeyName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "Passkey", nil
+	}
+	if len(name) > data.PASSKEY_NAME_MAX_LENGTH {
+		return "", errors.New("passkey name is too long")
+	}
+
+	return name, nil
+}
+
+func splitTransports(transports string) []protocol.AuthenticatorTransport {
+	var result []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(transports, ",") {
+		if transport != "" {
+			result = append(result, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return result
+}
+
+func toPasskeyResponse(passkey model.UserPasskeys) dto.PasskeyResponse {
+	transports := []string{}
+	for _, transport := range splitTransports(passkey.Transports) {
+		transports = append(transports, string(transport))
+	}
+
+	response := dto.PasskeyResponse{
+		ID:         passkey.ID.String(),
+		Name:       passkey.Name,
+		Transports: transports,
+		Synced:     passkey.BackupState,
+		CreatedAt:  passkey.CreatedAt,
+	}
+	if passkey.LastUsedAt.Valid {
+		response.LastUsedAt = &passkey.LastUsedAt.Time
+	}
+
+	return response
+}
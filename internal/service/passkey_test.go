@@ -0,0 +1,386 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	testOrigin = "http://localhost:3000"
+	testRPID   = "localhost"
+)
+
+// softAuthenticator - authenticator ES256 di memori yang membuat response attestation "none" dan assertion
+// seperti yang dikirim browser dari navigator.credentials.create() dan get()
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	credentialID := make([]byte, 32)
+	rand.Read(credentialID)
+
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (authenticator *softAuthenticator) clientData(t *testing.T, ceremony string, challenge []byte) []byte {
+	t.Helper()
+
+	clientData, err := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatalf("marshal client data: %v", err)
+	}
+
+	return clientData
+}
+
+// authenticatorData - rpIdHash || flags || signCount, ditambah attested credential data saat registrasi
+func (authenticator *softAuthenticator) authenticatorData(flags protocol.AuthenticatorFlags, attestedCredential []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+
+	authData := append([]byte{}, rpIDHash[:]...)
+	authData = append(authData, byte(flags))
+	authData = binary.BigEndian.AppendUint32(authData, authenticator.signCount)
+
+	return append(authData, attestedCredential...)
+}
+
+// register membuat response navigator.credentials.create() untuk options dari BeginRegistration
+func (authenticator *softAuthenticator) register(t *testing.T, creation *protocol.CredentialCreation) json.RawMessage {
+	t.Helper()
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: authenticator.key.X.FillBytes(make([]byte, 32)),
+		YCoord: authenticator.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+
+	// AAGUID KOSONG, PANJANG CREDENTIAL ID, CREDENTIAL ID LALU PUBLIC KEY COSE
+	attestedCredential := make([]byte, 16)
+	attestedCredential = binary.BigEndian.AppendUint16(attestedCredential, uint16(len(authenticator.credentialID)))
+	attestedCredential = append(attestedCredential, authenticator.credentialID...)
+	attestedCredential = append(attestedCredential, publicKey...)
+
+	flags := protocol.FlagUserPresent | protocol.FlagUserVerified | protocol.FlagAttestedCredentialData
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authenticator.authenticatorData(flags, attestedCredential),
+	})
+	if err != nil {
+		t.Fatalf("marshal attestation object: %v", err)
+	}
+
+	return authenticator.credential(t, map[string]interface{}{
+		"clientDataJSON":    authenticator.clientData(t, "webauthn.create", creation.Response.Challenge),
+		"attestationObject": attestationObject,
+		"transports":        []string{"internal"},
+	})
+}
+
+// assert membuat response navigator.credentials.get() dengan sign count yang naik setiap dipanggil
+func (authenticator *softAuthenticator) assert(t *testing.T, assertion *protocol.CredentialAssertion) json.RawMessage {
+	t.Helper()
+
+	authenticator.signCount++
+	authData := authenticator.authenticatorData(protocol.FlagUserPresent|protocol.FlagUserVerified, nil)
+	clientData := authenticator.clientData(t, "webauthn.get", assertion.Response.Challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, authenticator.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign assertion: %v", err)
+	}
+
+	return authenticator.credential(t, map[string]interface{}{
+		"clientDataJSON":    clientData,
+		"authenticatorData": authData,
+		"signature":         signature,
+		"userHandle":        authenticator.userHandle,
+	})
+}
+
+// credential membungkus response menjadi PublicKeyCredential, []byte di-encode sebagai base64url
+func (authenticator *softAuthenticator) credential(t *testing.T, response map[string]interface{}) json.RawMessage {
+	t.Helper()
+
+	for key, value := range response {
+		if raw, ok := value.([]byte); ok {
+			response[key] = base64.RawURLEncoding.EncodeToString(raw)
+		}
+	}
+
+	credential, err := json.Marshal(map[string]interface{}{
+		"id":       base64.RawURLEncoding.EncodeToString(authenticator.credentialID),
+		"rawId":    base64.RawURLEncoding.EncodeToString(authenticator.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal credential: %v", err)
+	}
+
+	return credential
+}
+
+func newTestPasskeyService(t *testing.T, env *testEnv) PasskeyService {
+	t.Helper()
+
+	passkeyService, err := NewPasskeyService(env.userRepo, env.identityRepo, env.passkeyRepo, repository.NewWebAuthnSessionRepository(env.redis), env.tokenService, env.twoFactorService, newTestOTPService(env), env.loginAttemptService, env.loginHistoryService, env.passwordHasher, env.mailer)
+	if err != nil {
+		t.Fatalf("NewPasskeyService: %v", err)
+	}
+
+	return passkeyService
+}
+
+// registerPasskey mendaftarkan passkey baru dari session sessionID dengan step-up password
+func registerPasskey(t *testing.T, passkeyService PasskeyService, user model.Users, sessionID string) *softAuthenticator {
+	t.Helper()
+
+	authenticator := newSoftAuthenticator(t)
+	authenticator.userHandle = user.ID[:]
+
+	creation, err := passkeyService.BeginRegistration(user.ID.String(), sessionID, dto.PasskeyStepUpRequest{CurrentPassword: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	if _, err := passkeyService.FinishRegistration(user.ID.String(), sessionID, dto.PasskeyRegisterRequest{
+		Name:       "Laptop",
+		Credential: authenticator.register(t, creation),
+	}, testClient); err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+
+	return authenticator
+}
+
+func TestPasskeyRegistrationRequiresCurrentPassword(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+
+	for _, request := range []dto.PasskeyStepUpRequest{{}, {CurrentPassword: "Wrong-Password-00"}, {Code: "123456"}} {
+		if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", request, testClient); err == nil {
+			t.Fatalf("BeginRegistration accepted step-up %+v", request)
+		}
+	}
+
+	authenticator := newSoftAuthenticator(t)
+	creation, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{CurrentPassword: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+	passkey, err := passkeyService.FinishRegistration(user.ID.String(), "session", dto.PasskeyRegisterRequest{
+		Name:       " Laptop ",
+		Credential: authenticator.register(t, creation),
+	}, testClient)
+	if err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+	if passkey.Name != "Laptop" || len(passkey.Transports) != 1 || passkey.Transports[0] != "internal" {
+		t.Fatalf("passkey = %+v", passkey)
+	}
+
+	// PEMILIK AKUN DIBERITAHU SETIAP ADA PASSKEY BARU
+	sent := env.mailer.Next(t)
+	if sent.To != user.Email || sent.File != "passkey-added-email-template.html" || sent.Data.(data.PasskeyAddedEmail).PasskeyName != "Laptop" {
+		t.Fatalf("email = %+v, want a passkey added notice", sent)
+	}
+}
+
+func TestPasskeyRegistrationIsBoundToLoginSession(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+
+	creation, err := passkeyService.BeginRegistration(user.ID.String(), "session-a", dto.PasskeyStepUpRequest{CurrentPassword: testPassword}, testClient)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+
+	// SESSION LAIN (MISALNYA TOKEN CURIAN) TIDAK BISA MENYELESAIKAN CEREMONY YANG SUDAH DI-STEP-UP
+	if _, err := passkeyService.FinishRegistration(user.ID.String(), "session-b", dto.PasskeyRegisterRequest{
+		Credential: newSoftAuthenticator(t).register(t, creation),
+	}, testClient); err == nil {
+		t.Fatal("registration was finished from another session")
+	}
+	env.mailer.None(t)
+}
+
+func TestPasskeyStepUpWithTwoFactorCode(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+	secret, _ := env.enableTwoFactor(t, user.ID.String(), "")
+
+	// AKUN DENGAN 2FA HARUS MEMAKAI KODE TOTP, PASSWORD SAJA TIDAK CUKUP
+	if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{CurrentPassword: testPassword}, testClient); err == nil {
+		t.Fatal("password was accepted as step-up for a 2FA account")
+	}
+	if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{Code: totpCode(t, secret, 1)}, testClient); err != nil {
+		t.Fatalf("BeginRegistration with a TOTP code: %v", err)
+	}
+}
+
+func TestPasskeyStepUpWithEmailOTP(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUser(t, "oauth-only@example.com")
+
+	if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{Code: "123456"}, testClient); err == nil {
+		t.Fatal("unsent OTP was accepted")
+	}
+
+	if err := passkeyService.SendStepUpOTP(user.ID.String()); err != nil {
+		t.Fatalf("SendStepUpOTP: %v", err)
+	}
+	sent := env.mailer.Next(t)
+	if sent.To != user.Email {
+		t.Fatalf("OTP sent to %s, want %s", sent.To, user.Email)
+	}
+	otp := sent.Data.(data.OTP).OTP
+
+	if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{Code: otp}, testClient); err != nil {
+		t.Fatalf("BeginRegistration with an email OTP: %v", err)
+	}
+	if _, err := passkeyService.BeginRegistration(user.ID.String(), "session", dto.PasskeyStepUpRequest{Code: otp}, testClient); err == nil {
+		t.Fatal("step-up OTP was accepted twice")
+	}
+}
+
+func TestPasskeyLoginWithSoftwareAuthenticator(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+	authenticator := registerPasskey(t, passkeyService, user, "session")
+
+	options, err := passkeyService.BeginLogin()
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	credential := authenticator.assert(t, options.Options)
+	tokens, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: credential}, testClient)
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	claims, err := env.tokenService.VerifyAccessToken(tokens.AccessToken)
+	if err != nil || claims.UserID != user.ID.String() {
+		t.Fatalf("VerifyAccessToken = %+v, %v", claims, err)
+	}
+
+	// CHALLENGE LOGIN HANYA BISA DIPAKAI SEKALI
+	if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: credential}, testClient); err == nil {
+		t.Fatal("passkey assertion was replayed")
+	}
+
+	passkeys, err := env.passkeyRepo.GetPasskeysByUserID(user.ID.String())
+	if err != nil || len(passkeys) != 1 {
+		t.Fatalf("GetPasskeysByUserID = %d passkeys, %v", len(passkeys), err)
+	}
+	if passkeys[0].SignCount != authenticator.signCount || !passkeys[0].LastUsedAt.Valid {
+		t.Fatalf("passkey = %+v, want sign count %d and last used time", passkeys[0], authenticator.signCount)
+	}
+}
+
+func TestPasskeyLoginRejectsInvalidAssertions(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+	authenticator := registerPasskey(t, passkeyService, user, "session")
+
+	tests := []struct {
+		name   string
+		modify func(authenticator *softAuthenticator)
+	}{
+		{"signed with another key", func(authenticator *softAuthenticator) {
+			authenticator.key = newSoftAuthenticator(t).key
+		}},
+		{"user handle of another user", func(authenticator *softAuthenticator) {
+			authenticator.userHandle = make([]byte, 16)
+		}},
+		{"unknown credential", func(authenticator *softAuthenticator) {
+			authenticator.credentialID = newSoftAuthenticator(t).credentialID
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forged := *authenticator
+			test.modify(&forged)
+
+			options, err := passkeyService.BeginLogin()
+			if err != nil {
+				t.Fatalf("BeginLogin: %v", err)
+			}
+			if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: forged.assert(t, options.Options)}, testClient); err == nil {
+				t.Fatal("FinishLogin accepted an invalid assertion")
+			}
+		})
+	}
+}
+
+func TestPasskeyLoginDetectsClonedAuthenticator(t *testing.T) {
+	env := newTestEnv(t)
+	passkeyService := newTestPasskeyService(t, env)
+	user := env.createUserWithPassword(t, "passkey@example.com", testPassword)
+	authenticator := registerPasskey(t, passkeyService, user, "session")
+
+	for i := 0; i < 2; i++ {
+		options, _ := passkeyService.BeginLogin()
+		if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: authenticator.assert(t, options.Options)}, testClient); err != nil {
+			t.Fatalf("FinishLogin: %v", err)
+		}
+	}
+
+	// SALINAN AUTHENTICATOR MENGIRIM SIGN COUNT YANG TIDAK NAIK
+	authenticator.signCount = 0
+	options, _ := passkeyService.BeginLogin()
+	if _, err := passkeyService.FinishLogin(dto.PasskeyLoginRequest{SessionID: options.SessionID, Credential: authenticator.assert(t, options.Options)}, testClient); err == nil {
+		t.Fatal("assertion with a regressed sign count was accepted")
+	}
+
+	passkeys, _ := env.passkeyRepo.GetPasskeysByUserID(user.ID.String())
+	if len(passkeys) != 1 || !passkeys[0].CloneWarning {
+		t.Fatal("passkey was not flagged as possibly cloned")
+	}
+	if reason := env.lastFailureReason(t, user.ID.String()); reason != data.LOGIN_FAILURE_CLONED_PASSKEY {
+		t.Fatalf("last failure reason = %q, want %q", reason, data.LOGIN_FAILURE_CLONED_PASSKEY)
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/hasher"
+	"refina-auth/internal/utils/policy"
+	"refina-auth/internal/utils/secrets"
+)
+
+// ErrPasswordResetRequired - akun dilaporkan "this wasn't me" dan hanya bisa dipulihkan lewat link reset password
+var ErrPasswordResetRequired = errors.New("password reset is required, please check your email for a reset link")
+
+type PasswordService interface {
+	ForgotPassword(email string) error
+	ResetPassword(token string, password string) error
+	ChangePassword(userID string, currentPassword string, newPassword string, client dto.ClientInfo) (*dto.TokenResponse, error)
+}
+
+type passwordService struct {
+	userRepository            repository.UsersRepository
+	passwordResetRepository   repository.PasswordResetRepository
+	passwordHistoryRepository repository.PasswordHistoryRepository
+	tokenService              TokenService
+	smtpClient                helper.SMTPClientInterface
+	passwordHasher            hasher.Hasher
+	passwordPolicy            policy.Policy
+}
+
+func NewPasswordService(userRepository repository.UsersRepository, passwordResetRepository repository.PasswordResetRepository, passwordHistoryRepository repository.PasswordHistoryRepository, tokenService TokenService, smtpClient helper.SMTPClientInterface, passwordHasher hasher.Hasher, passwordPolicy policy.Policy) PasswordService {
+	return &passwordService{
+		userRepository:            userRepository,
+		passwordResetRepository:   passwordResetRepository,
+		passwordHistoryRepository: passwordHistoryRepository,
+		tokenService:              tokenService,
+		smtpClient:                smtpClient,
+		passwordHasher:            passwordHasher,
+		passwordPolicy:            passwordPolicy,
+	}
+}
+
+// ForgotPassword mengirim link reset password ke email user. Error hanya dikembalikan untuk input yang tidak valid,
+// sehingga response tidak membocorkan apakah email terdaftar atau tidak.
+func (password_serv *passwordService) ForgotPassword(email string) error {
+	// VALIDASI UNTUK FORMAT EMAIL SUDAH BENAR
+	if !helper.EmailValidator(email) {
+		return errors.New("please enter a valid email address")
+	}
+
+	// EMAIL DIKIRIM DI BACKGROUND AGAR WAKTU RESPONSE SAMA UNTUK EMAIL TERDAFTAR MAUPUN TIDAK
+	go func() {
+		user, err := password_serv.userRepository.GetUserByEmail(email)
+		if err != nil {
+			return
+		}
+
+		token, err := secrets.Token()
+		if err != nil {
+			log.Error("Failed to generate password reset token: " + err.Error())
+			return
+		}
+
+		if err := password_serv.passwordResetRepository.SaveResetToken(helper.HashToken(token), user.ID.String(), data.PASSWORD_RESET_TTL); err != nil {
+			log.Error("Failed to save password reset token: " + err.Error())
+			return
+		}
+
+		if err := password_serv.smtpClient.SendSingleEmail(user.Email, "Reset Password", "password-reset-email-template.html", data.PasswordResetEmail{
+			Name:      user.Name,
+			URL:       env.Cfg.Client.Url + "/reset-password?token=" + token,
+			ExpiresIn: int(data.PASSWORD_RESET_TTL.Minutes()),
+		}); err != nil {
+			log.Error("Failed to send password reset email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+		}
+	}()
+
+	return nil
+}
+
+func (password_serv *passwordService) ResetPassword(token string, password string) error {
+	// TOKEN BELUM DIHAPUS AGAR USER BISA MENCOBA LAGI JIKA PASSWORD DITOLAK POLICY
+	userID, err := password_serv.passwordResetRepository.GetResetToken(helper.HashToken(token))
+	if err != nil {
+		return errors.New("reset token is invalid or expired")
+	}
+
+	user, err := password_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return errors.New("reset token is invalid or expired")
+	}
+
+	if err := password_serv.validateNewPassword(user, password); err != nil {
+		return err
+	}
+
+	// TOKEN DIHAPUS SAAT DIAMBIL SEHINGGA HANYA BISA DIPAKAI SEKALI
+	if _, err := password_serv.passwordResetRepository.ConsumeResetToken(helper.HashToken(token)); err != nil {
+		return errors.New("reset token is invalid or expired")
+	}
+
+	// HASHING PASSWORD MENGGUNAKAN ALGORITMA DEFAULT (ARGON2ID)
+	hashedPassword, err := password_serv.passwordHasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	previousHash := user.Password
+	user.Password = hashedPassword
+	user.PasswordResetRequired = false
+
+	if _, err := password_serv.userRepository.UpdateUser(user); err != nil {
+		return err
+	}
+	password_serv.recordPasswordHistory(user, previousHash)
+
+	// MENCABUT SELURUH SESSION USER SETELAH PASSWORD DIGANTI
+	return password_serv.tokenService.LogoutAll(userID)
+}
+
+// ChangePassword mengganti password user yang sedang login, mencabut seluruh session lain
+// lalu mengembalikan token baru untuk session saat ini
+func (password_serv *passwordService) ChangePassword(userID string, currentPassword string, newPassword string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	user, err := password_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// SESSION PENYERANG SUDAH DICABUT, TETAPI PASSWORD YANG BOCOR TIDAK BOLEH DIPAKAI UNTUK MENGGANTI PASSWORD
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	// AKUN OAUTH TANPA PASSWORD HARUS MENGATUR PASSWORD LEWAT FORGOT PASSWORD
+	if user.Password == "" {
+		return nil, errors.New("this account has no password, please use forgot password to set one")
+	}
+
+	// VALIDASI APAKAH PASSWORD SAAT INI SUDAH SESUAI
+	if match, _ := password_serv.passwordHasher.Verify(user.Password, currentPassword); !match {
+		return nil, errors.New("current password is incorrect")
+	}
+
+	if currentPassword == newPassword {
+		return nil, errors.New("new password must be different from the current password")
+	}
+
+	if err := password_serv.validateNewPassword(user, newPassword); err != nil {
+		return nil, err
+	}
+
+	// HASHING PASSWORD MENGGUNAKAN ALGORITMA DEFAULT (ARGON2ID)
+	hashedPassword, err := password_serv.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	previousHash := user.Password
+	user.Password = hashedPassword
+
+	userUpdated, err := password_serv.userRepository.UpdateUser(user)
+	if err != nil {
+		return nil, err
+	}
+	password_serv.recordPasswordHistory(user, previousHash)
+
+	if err := password_serv.tokenService.LogoutAll(userID); err != nil {
+		return nil, err
+	}
+
+	return password_serv.tokenService.GenerateTokens(userUpdated, data.LOGIN_METHOD_PASSWORD, client)
+}
+
+// validateNewPassword menggabungkan pelanggaran password policy dengan pengecekan password history,
+// sehingga seluruh pelanggaran dikembalikan sekaligus
+func (password_serv *passwordService) validateNewPassword(user model.Users, password string) error {
+	var violations []dto.PasswordViolation
+	if err := password_serv.passwordPolicy.Validate(password, user.Name, user.Email); err != nil {
+		var violationErr *policy.ViolationError
+		if !errors.As(err, &violationErr) {
+			return err
+		}
+		violations = violationErr.Violations
+	}
+
+	reused, err := password_serv.isPasswordReused(user, password)
+	if err != nil {
+		return err
+	}
+	if reused {
+		violations = append(violations, dto.PasswordViolation{
+			Code:    policy.CodeReused,
+			Message: fmt.Sprintf("password must be different from your last %d passwords", env.Cfg.Password.HistoryDepth),
+		})
+	}
+
+	if len(violations) > 0 {
+		return &policy.ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// isPasswordReused mengecek password baru terhadap password saat ini dan PASSWORD_HISTORY_DEPTH - 1 password sebelumnya
+func (password_serv *passwordService) isPasswordReused(user model.Users, password string) (bool, error) {
+	depth := env.Cfg.Password.HistoryDepth
+	if depth <= 0 || password == "" {
+		return false, nil
+	}
+
+	histories, err := password_serv.passwordHistoryRepository.GetPasswordHistory(user.ID.String(), depth-1)
+	if err != nil {
+		return false, err
+	}
+
+	hashes := []string{user.Password}
+	for _, history := range histories {
+		hashes = append(hashes, history.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if match, _ := password_serv.passwordHasher.Verify(hash, password); match {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// recordPasswordHistory menyimpan hash password lama. Password sudah terganti, sehingga kegagalan
+// hanya dicatat di log dan tidak membatalkan request.
+func (password_serv *passwordService) recordPasswordHistory(user model.Users, previousHash string) {
+	// AKUN OAUTH YANG BARU PERTAMA KALI MENGATUR PASSWORD BELUM PUNYA PASSWORD LAMA
+	if previousHash == "" {
+		return
+	}
+
+	if err := password_serv.passwordHistoryRepository.AddPasswordHistory(model.UserPasswordHistories{
+		UserID:       user.ID,
+		PasswordHash: previousHash,
+	}, env.Cfg.Password.HistoryDepth-1); err != nil {
+		log.Error("Failed to save password history: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+	}
+}
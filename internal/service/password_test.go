@@ -0,0 +1,214 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/policy"
+)
+
+// requestPasswordReset mengirim forgot password lalu mengambil token dari link di email
+func (env *testEnv) requestPasswordReset(t *testing.T, email string) string {
+	t.Helper()
+
+	if err := env.passwordService.ForgotPassword(email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	sent := env.mailer.Next(t)
+	if sent.To != email || sent.File != "password-reset-email-template.html" {
+		t.Fatalf("email = %+v, want a password reset email to %s", sent, email)
+	}
+
+	link, err := url.Parse(sent.Data.(data.PasswordResetEmail).URL)
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+
+	return link.Query().Get("token")
+}
+
+func (env *testEnv) passwordMatches(t *testing.T, userID string, password string) bool {
+	t.Helper()
+
+	user, err := env.userRepo.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	match, _ := env.passwordHasher.Verify(user.Password, password)
+
+	return match
+}
+
+func TestForgotPasswordDoesNotRevealUnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	if err := env.passwordService.ForgotPassword("not-an-email"); err == nil {
+		t.Fatal("invalid email was accepted")
+	}
+	if err := env.passwordService.ForgotPassword("nobody@example.com"); err != nil {
+		t.Fatalf("ForgotPassword for an unknown email = %v, want nil", err)
+	}
+	env.mailer.None(t)
+}
+
+func TestResetPasswordChangesPasswordAndRevokesSessions(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "reset@example.com", testPassword)
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	token := env.requestPasswordReset(t, user.Email)
+
+	// HANYA HASH TOKEN YANG DISIMPAN
+	for _, key := range env.miniredis.Keys() {
+		if strings.Contains(key, token) {
+			t.Fatalf("plaintext reset token stored in redis key %q", key)
+		}
+	}
+
+	// PASSWORD YANG DITOLAK POLICY TIDAK MEMBUANG TOKEN
+	if err := env.passwordService.ResetPassword(token, "short"); err == nil {
+		t.Fatal("weak password was accepted")
+	}
+
+	newPassword := "Brand-New-Passphrase-77"
+	if err := env.passwordService.ResetPassword(token, newPassword); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if !env.passwordMatches(t, user.ID.String(), newPassword) {
+		t.Fatal("password was not changed")
+	}
+
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token survived a password reset")
+	}
+	if _, err := env.tokenService.VerifyAccessToken(tokens.AccessToken); err == nil {
+		t.Fatal("access token survived a password reset")
+	}
+
+	if err := env.passwordService.ResetPassword(token, "Another-Passphrase-88"); err == nil {
+		t.Fatal("reset token was accepted twice")
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "reset-expired@example.com", testPassword)
+
+	token := env.requestPasswordReset(t, user.Email)
+	env.miniredis.FastForward(data.PASSWORD_RESET_TTL + time.Second)
+
+	if err := env.passwordService.ResetPassword(token, "Brand-New-Passphrase-77"); err == nil {
+		t.Fatal("expired reset token was accepted")
+	}
+	if err := env.passwordService.ResetPassword("forged-token", "Brand-New-Passphrase-77"); err == nil {
+		t.Fatal("unknown reset token was accepted")
+	}
+	if !env.passwordMatches(t, user.ID.String(), testPassword) {
+		t.Fatal("password changed with an invalid token")
+	}
+}
+
+func TestChangePasswordRequiresCurrentPassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "change@example.com", testPassword)
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	newPassword := "Brand-New-Passphrase-77"
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+	}{
+		{"wrong current password", "Wrong-Password-00", newPassword},
+		{"same password", testPassword, testPassword},
+		{"weak new password", testPassword, "short"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := env.passwordService.ChangePassword(user.ID.String(), test.currentPassword, test.newPassword, testClient); err == nil {
+				t.Fatal("ChangePassword accepted an invalid request")
+			}
+		})
+	}
+	if !env.passwordMatches(t, user.ID.String(), testPassword) {
+		t.Fatal("password changed by a rejected request")
+	}
+
+	newTokens, err := env.passwordService.ChangePassword(user.ID.String(), testPassword, newPassword, testClient)
+	if err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if !env.passwordMatches(t, user.ID.String(), newPassword) {
+		t.Fatal("password was not changed")
+	}
+
+	// SESSION LAMA DICABUT, SESSION BARU TETAP BERLAKU
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("old refresh token survived a password change")
+	}
+	if _, err := env.tokenService.VerifyAccessToken(newTokens.AccessToken); err != nil {
+		t.Fatalf("new access token rejected: %v", err)
+	}
+
+	// PASSWORD LAMA TIDAK BOLEH DIPAKAI ULANG
+	if _, err := env.passwordService.ChangePassword(user.ID.String(), newPassword, testPassword, testClient); err == nil {
+		t.Fatal("previous password was reused")
+	}
+}
+
+func TestChangePasswordWithoutPassword(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "oauth-only@example.com")
+
+	if _, err := env.passwordService.ChangePassword(user.ID.String(), "", "Brand-New-Passphrase-77", testClient); err == nil {
+		t.Fatal("ChangePassword set a password on an account without one")
+	}
+}
+
+func TestPasswordHistoryBlocksRecentPasswords(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "history@example.com", testPassword)
+	depth := testConfig().Password.HistoryDepth
+
+	// PASSWORD SAAT INI DITAMBAH depth - 1 PASSWORD SEBELUMNYA TIDAK BOLEH DIPAKAI ULANG
+	passwords := []string{testPassword}
+	for i := 1; i <= depth; i++ {
+		next := fmt.Sprintf("Distinct-Sailing-Harbor-%d", i)
+		if _, err := env.passwordService.ChangePassword(user.ID.String(), passwords[len(passwords)-1], next, testClient); err != nil {
+			t.Fatalf("ChangePassword #%d: %v", i, err)
+		}
+		passwords = append(passwords, next)
+	}
+	current := passwords[len(passwords)-1]
+
+	for _, reused := range passwords[len(passwords)-depth:] {
+		token := env.requestPasswordReset(t, user.Email)
+		err := env.passwordService.ResetPassword(token, reused)
+		var violationErr *policy.ViolationError
+		if !errors.As(err, &violationErr) || violationErr.Violations[len(violationErr.Violations)-1].Code != policy.CodeReused {
+			t.Fatalf("ResetPassword(%q) = %v, want %s", reused, err, policy.CodeReused)
+		}
+		env.miniredis.FastForward(data.PASSWORD_RESET_TTL + time.Second)
+	}
+	if !env.passwordMatches(t, user.ID.String(), current) {
+		t.Fatal("password changed to a recently used password")
+	}
+
+	// PASSWORD YANG SUDAH KELUAR DARI HISTORY BOLEH DIPAKAI LAGI
+	if _, err := env.passwordService.ChangePassword(user.ID.String(), current, passwords[0], testClient); err != nil {
+		t.Fatalf("ChangePassword to a password older than the history depth: %v", err)
+	}
+}
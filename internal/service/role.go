@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+)
+
+// rolePermissionCacheTTL - lama cache mapping role ke permission sebelum dimuat ulang dari database
+const rolePermissionCacheTTL = time.Minute
+
+type RoleService interface {
+	GetAllRoles() ([]dto.RoleResponse, error)
+	HasPermission(role string, permission model.Permission) (bool, error)
+	AssignRole(userID string, role string) (dto.UsersResponse, error)
+}
+
+type roleService struct {
+	roleRepository repository.RoleRepository
+	userRepository repository.UsersRepository
+	tokenService   TokenService
+
+	mu          sync.RWMutex
+	permissions map[string]map[string]bool
+	loadedAt    time.Time
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, userRepository repository.UsersRepository, tokenService TokenService) RoleService {
+	return &roleService{
+		roleRepository: roleRepository,
+		userRepository: userRepository,
+		tokenService:   tokenService,
+	}
+}
+
+func (role_serv *roleService) GetAllRoles() ([]dto.RoleResponse, error) {
+	roles, err := role_serv.roleRepository.GetAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	var rolesResponse []dto.RoleResponse
+	for _, role := range roles {
+		roleResponse := dto.RoleResponse{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: []string{},
+		}
+		for _, permission := range role.Permissions {
+			roleResponse.Permissions = append(roleResponse.Permissions, permission.Permission)
+		}
+		rolesResponse = append(rolesResponse, roleResponse)
+	}
+
+	return rolesResponse, nil
+}
+
+func (role_serv *roleService) HasPermission(role string, permission model.Permission) (bool, error) {
+	role_serv.mu.RLock()
+	permissions, loadedAt := role_serv.permissions, role_serv.loadedAt
+	role_serv.mu.RUnlock()
+
+	if permissions == nil || time.Since(loadedAt) > rolePermissionCacheTTL {
+		roles, err := role_serv.roleRepository.GetAllRoles()
+		if err != nil {
+			return false, err
+		}
+
+		permissions = map[string]map[string]bool{}
+		for _, r := range roles {
+			permissions[r.Name] = map[string]bool{}
+			for _, p := range r.Permissions {
+				permissions[r.Name][p.Permission] = true
+			}
+		}
+
+		role_serv.mu.Lock()
+		role_serv.permissions, role_serv.loadedAt = permissions, time.Now()
+		role_serv.mu.Unlock()
+	}
+
+	return permissions[role][string(permission)], nil
+}
+
+func (role_serv *roleService) AssignRole(userID string, role string) (dto.UsersResponse, error) {
+	if role == "" {
+		return dto.UsersResponse{}, errors.New("role cannot be blank")
+	}
+
+	// MENGECEK APAKAH ROLE TERDAFTAR
+	if _, err := role_serv.roleRepository.GetRoleByName(role); err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	user, err := role_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	user.Role = role
+	userUpdated, err := role_serv.userRepository.UpdateUser(user)
+	if err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	// MENCABUT ACCESS TOKEN LAMA AGAR CLAIM ROLE LAMA TIDAK BERLAKU LAGI
+	if err := role_serv.tokenService.RevokeAccessTokens(userUpdated.ID.String()); err != nil {
+		return dto.UsersResponse{}, err
+	}
+
+	return helper.ConvertToResponseType(userUpdated).(dto.UsersResponse), nil
+}
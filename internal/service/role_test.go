@@ -0,0 +1,109 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+)
+
+func newTestRoleService(t *testing.T, env *testEnv) RoleService {
+	t.Helper()
+
+	if err := env.db.AutoMigrate(&model.Roles{}, &model.RolePermissions{}); err != nil {
+		t.Fatalf("migrate roles: %v", err)
+	}
+	roles := []model.Roles{
+		{Name: string(model.Admin), Permissions: []model.RolePermissions{
+			{Permission: string(model.UsersRead)},
+			{Permission: string(model.RolesAssign)},
+			{Permission: string(model.RolesManage)},
+		}},
+		{Name: string(model.User)},
+	}
+	if err := env.db.Create(&roles).Error; err != nil {
+		t.Fatalf("seed roles: %v", err)
+	}
+
+	return NewRoleService(repository.NewRoleRepository(env.db), env.userRepo, env.tokenService)
+}
+
+func TestRoleServiceHasPermission(t *testing.T) {
+	env := newTestEnv(t)
+	roleService := newTestRoleService(t, env)
+
+	tests := []struct {
+		role       string
+		permission model.Permission
+		want       bool
+	}{
+		{string(model.Admin), model.UsersRead, true},
+		{string(model.Admin), model.RolesManage, true},
+		{string(model.Admin), model.UsersDelete, false},
+		{string(model.User), model.UsersRead, false},
+		{string(model.User), model.RolesManage, false},
+		{"unknown", model.UsersRead, false},
+	}
+
+	for _, test := range tests {
+		allowed, err := roleService.HasPermission(test.role, test.permission)
+		if err != nil {
+			t.Fatalf("HasPermission(%s, %s): %v", test.role, test.permission, err)
+		}
+		if allowed != test.want {
+			t.Errorf("HasPermission(%s, %s) = %v, want %v", test.role, test.permission, allowed, test.want)
+		}
+	}
+}
+
+func TestRoleServiceAssignRoleRevokesAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	roleService := newTestRoleService(t, env)
+	user := env.createUser(t, "promote@example.com")
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	response, err := roleService.AssignRole(user.ID.String(), string(model.Admin))
+	if err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if response.Role != string(model.Admin) {
+		t.Fatalf("response role = %q, want admin", response.Role)
+	}
+
+	// ACCESS TOKEN LAMA MEMBAWA CLAIM ROLE LAMA
+	if _, err := env.tokenService.VerifyAccessToken(tokens.AccessToken); err == nil {
+		t.Fatal("access token with the old role claim was accepted")
+	}
+
+	refreshed, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	claims, err := env.tokenService.VerifyAccessToken(refreshed.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken: %v", err)
+	}
+	if claims.Role != string(model.Admin) {
+		t.Fatalf("refreshed role claim = %q, want admin", claims.Role)
+	}
+}
+
+func TestRoleServiceAssignRoleRejectsUnknownRole(t *testing.T) {
+	env := newTestEnv(t)
+	roleService := newTestRoleService(t, env)
+	user := env.createUser(t, "unknown-role@example.com")
+
+	if _, err := roleService.AssignRole(user.ID.String(), ""); err == nil {
+		t.Fatal("blank role was accepted")
+	}
+	if _, err := roleService.AssignRole(user.ID.String(), "superuser"); err == nil {
+		t.Fatal("unknown role was accepted")
+	}
+}
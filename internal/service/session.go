@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+)
+
+type SessionService interface {
+	GetSessions(userID string, currentSessionID string) ([]dto.SessionResponse, error)
+	RevokeSession(userID string, sessionID string, currentSessionID string) error
+	RevokeOtherSessions(userID string, currentSessionID string) error
+}
+
+type sessionService struct {
+	sessionRepository repository.SessionRepository
+	tokenService      TokenService
+}
+
+func NewSessionService(sessionRepository repository.SessionRepository, tokenService TokenService) SessionService {
+	return &sessionService{
+		sessionRepository: sessionRepository,
+		tokenService:      tokenService,
+	}
+}
+
+// GetSessions mengembalikan seluruh session aktif user, session yang dipakai request saat ini ditandai current
+func (session_serv *sessionService) GetSessions(userID string, currentSessionID string) ([]dto.SessionResponse, error) {
+	sessions, err := session_serv.sessionRepository.GetActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, dto.SessionResponse{
+			ID:          session.ID.String(),
+			Device:      session.Device,
+			UserAgent:   session.UserAgent,
+			IPAddress:   session.IPAddress,
+			LoginMethod: session.LoginMethod,
+			Current:     session.ID.String() == currentSessionID,
+			CreatedAt:   session.CreatedAt,
+			LastSeenAt:  session.LastSeenAt,
+		})
+	}
+
+	return responses, nil
+}
+
+// RevokeSession mencabut session lain milik user, session saat ini diakhiri lewat logout
+func (session_serv *sessionService) RevokeSession(userID string, sessionID string, currentSessionID string) error {
+	if sessionID == currentSessionID {
+		return errors.New("use logout to end the current session")
+	}
+
+	// SESSION MILIK USER LAIN DIANGGAP TIDAK ADA AGAR ID SESSION TIDAK BISA DITEBAK
+	session, err := session_serv.sessionRepository.GetSessionByID(sessionID)
+	if err != nil || session.UserID.String() != userID || session.RevokedAt.Valid {
+		return errors.New("session not found")
+	}
+
+	return session_serv.tokenService.RevokeSession(sessionID)
+}
+
+// RevokeOtherSessions mencabut seluruh session user kecuali session yang dipakai request saat ini
+func (session_serv *sessionService) RevokeOtherSessions(userID string, currentSessionID string) error {
+	sessions, err := session_serv.sessionRepository.GetActiveSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID.String() == currentSessionID {
+			continue
+		}
+		if err := session_serv.tokenService.RevokeSession(session.ID.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/utils/data"
+)
+
+var phoneClient = dto.ClientInfo{
+	IP:        "198.51.100.23",
+	UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+}
+
+func TestSessionsRecordDeviceAndMarkCurrent(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "sessions@example.com")
+
+	_, laptopSessionID := env.login(t, user)
+	phoneTokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_OTP, phoneClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	// REFRESH MEMPERBARUI IP DAN WAKTU TERAKHIR AKTIF SESSION
+	movedClient := dto.ClientInfo{IP: "192.0.2.44", UserAgent: phoneClient.UserAgent}
+	if _, err := env.tokenService.RefreshTokens(phoneTokens.RefreshToken, movedClient); err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+
+	sessions, err := env.sessionService.GetSessions(user.ID.String(), laptopSessionID)
+	if err != nil {
+		t.Fatalf("GetSessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+
+	// SESSION TERAKHIR AKTIF DI URUTAN PERTAMA
+	phone, laptop := sessions[0], sessions[1]
+	if phone.Current || !laptop.Current || laptop.ID != laptopSessionID {
+		t.Fatalf("sessions = %+v, want only the laptop session marked current", sessions)
+	}
+	if phone.IPAddress != movedClient.IP || phone.LoginMethod != data.LOGIN_METHOD_OTP || phone.UserAgent != phoneClient.UserAgent || phone.Device == "" {
+		t.Fatalf("phone session = %+v", phone)
+	}
+	if laptop.IPAddress != testClient.IP || laptop.LoginMethod != data.LOGIN_METHOD_PASSWORD || laptop.Device == phone.Device {
+		t.Fatalf("laptop session = %+v", laptop)
+	}
+	if phone.LastSeenAt.Before(phone.CreatedAt) {
+		t.Fatalf("phone last seen %v before creation %v", phone.LastSeenAt, phone.CreatedAt)
+	}
+}
+
+func TestRevokeSessionEndsOnlyThatSession(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "revoke-session@example.com")
+	other := env.createUser(t, "other@example.com")
+
+	currentTokens, currentSessionID := env.login(t, user)
+	targetTokens, targetSessionID := env.login(t, user)
+	_, otherSessionID := env.login(t, other)
+
+	if err := env.sessionService.RevokeSession(user.ID.String(), currentSessionID, currentSessionID); err == nil {
+		t.Fatal("current session was revoked through the sessions endpoint")
+	}
+	// SESSION USER LAIN DIPERLAKUKAN SEPERTI SESSION YANG TIDAK ADA
+	if err := env.sessionService.RevokeSession(user.ID.String(), otherSessionID, currentSessionID); err == nil {
+		t.Fatal("session of another user was revoked")
+	}
+
+	if err := env.sessionService.RevokeSession(user.ID.String(), targetSessionID, currentSessionID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := env.tokenService.VerifyAccessToken(targetTokens.AccessToken); err == nil {
+		t.Fatal("access token of a revoked session was accepted")
+	}
+	if _, err := env.tokenService.RefreshTokens(targetTokens.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token of a revoked session was accepted")
+	}
+	if err := env.sessionService.RevokeSession(user.ID.String(), targetSessionID, currentSessionID); err == nil {
+		t.Fatal("revoked session was revoked twice")
+	}
+
+	if _, err := env.tokenService.VerifyAccessToken(currentTokens.AccessToken); err != nil {
+		t.Fatalf("current session was affected: %v", err)
+	}
+	sessions, err := env.sessionService.GetSessions(other.ID.String(), "")
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("other user sessions = %d, %v, want 1", len(sessions), err)
+	}
+}
+
+func TestRevokeOtherSessionsKeepsCurrentSession(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "revoke-others@example.com")
+
+	currentTokens, currentSessionID := env.login(t, user)
+	var others []*dto.TokenResponse
+	for i := 0; i < 2; i++ {
+		tokens, _ := env.login(t, user)
+		others = append(others, tokens)
+	}
+
+	if err := env.sessionService.RevokeOtherSessions(user.ID.String(), currentSessionID); err != nil {
+		t.Fatalf("RevokeOtherSessions: %v", err)
+	}
+
+	for _, tokens := range others {
+		if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+			t.Fatal("refresh token of another session survived")
+		}
+	}
+	if _, err := env.tokenService.VerifyAccessToken(currentTokens.AccessToken); err != nil {
+		t.Fatalf("current access token rejected: %v", err)
+	}
+	sessions, err := env.sessionService.GetSessions(user.ID.String(), currentSessionID)
+	if err != nil || len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("sessions = %+v, %v, want only the current session", sessions, err)
+	}
+}
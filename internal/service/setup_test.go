@@ -0,0 +1,285 @@
+package service
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/hasher"
+	"refina-auth/internal/utils/policy"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var testClient = dto.ClientInfo{
+	IP:        "203.0.113.10",
+	UserAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+}
+
+// testEnv - service yang dirangkai seperti di routes, memakai SQLite dan miniredis
+type testEnv struct {
+	db        *gorm.DB
+	redis     *redis.Client
+	miniredis *miniredis.Miniredis
+	mailer    *testMailer
+
+	userRepo            repository.UsersRepository
+	identityRepo        repository.IdentityRepository
+	tokenRepo           repository.TokenRepository
+	sessionRepo         repository.SessionRepository
+	passwordResetRepo   repository.PasswordResetRepository
+	passwordHistoryRepo repository.PasswordHistoryRepository
+	loginHistoryRepo    repository.LoginHistoryRepository
+	loginReportRepo     repository.LoginReportRepository
+	twoFactorRepo       repository.TwoFactorRepository
+	recoveryCodeRepo    repository.RecoveryCodeRepository
+	loginAttemptRepo    repository.LoginAttemptRepository
+	passkeyRepo         repository.PasskeyRepository
+
+	passwordHasher hasher.Hasher
+	passwordPolicy policy.Policy
+
+	keyService          KeyService
+	tokenService        TokenService
+	sessionService      SessionService
+	passwordService     PasswordService
+	loginHistoryService LoginHistoryService
+	twoFactorService    TwoFactorService
+	loginAttemptService LoginAttemptService
+	usersService        UsersService
+	identityService     IdentityService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	testutil.Config(t)
+
+	db := newTestDB(t,
+		&model.Users{},
+		&model.UserSessions{},
+		&model.UserIdentities{},
+		&model.UserPasswordHistories{},
+		&model.UserLoginHistories{},
+		&model.UserRecoveryCodes{},
+		&model.UserPasskeys{},
+	)
+	redisClient, redisServer := newTestRedis(t)
+
+	passwordHasher, err := hasher.New(env.Cfg.Password)
+	if err != nil {
+		t.Fatalf("hasher.New: %v", err)
+	}
+	passwordPolicy, err := policy.New(env.Cfg.Password)
+	if err != nil {
+		t.Fatalf("policy.New: %v", err)
+	}
+	keyService, err := NewKeyService(nil)
+	if err != nil {
+		t.Fatalf("NewKeyService: %v", err)
+	}
+
+	env := &testEnv{
+		db:                  db,
+		redis:               redisClient,
+		miniredis:           redisServer,
+		mailer:              newTestMailer(),
+		userRepo:            repository.NewUsersRepository(db),
+		identityRepo:        repository.NewIdentityRepository(db),
+		tokenRepo:           repository.NewTokenRepository(redisClient),
+		sessionRepo:         repository.NewSessionRepository(db),
+		passwordResetRepo:   repository.NewPasswordResetRepository(redisClient),
+		passwordHistoryRepo: repository.NewPasswordHistoryRepository(db),
+		loginHistoryRepo:    repository.NewLoginHistoryRepository(db),
+		loginReportRepo:     repository.NewLoginReportRepository(redisClient),
+		twoFactorRepo:       repository.NewTwoFactorRepository(redisClient),
+		recoveryCodeRepo:    repository.NewRecoveryCodeRepository(db),
+		loginAttemptRepo:    repository.NewLoginAttemptRepository(redisClient),
+		passkeyRepo:         repository.NewPasskeyRepository(db),
+		passwordHasher:      passwordHasher,
+		passwordPolicy:      passwordPolicy,
+		keyService:          keyService,
+	}
+
+	env.tokenService = NewTokenService(env.tokenRepo, env.userRepo, env.sessionRepo, env.keyService)
+	env.sessionService = NewSessionService(env.sessionRepo, env.tokenService)
+	env.passwordService = NewPasswordService(env.userRepo, env.passwordResetRepo, env.passwordHistoryRepo, env.tokenService, env.mailer, env.passwordHasher, env.passwordPolicy)
+	env.loginHistoryService = NewLoginHistoryService(env.userRepo, env.loginHistoryRepo, env.loginReportRepo, env.identityRepo, env.passkeyRepo, env.tokenService, env.passwordService, env.mailer)
+	env.twoFactorService = NewTwoFactorService(env.userRepo, env.twoFactorRepo, env.recoveryCodeRepo, env.tokenService, env.sessionService, env.loginHistoryService, env.mailer)
+	env.loginAttemptService = NewLoginAttemptService(env.userRepo, env.loginAttemptRepo, env.mailer)
+	env.usersService = NewUsersService(env.userRepo, env.identityRepo, env.tokenService, env.twoFactorService, env.loginAttemptService, env.loginHistoryService, env.passwordHasher, env.passwordPolicy)
+	env.identityService = NewIdentityService(env.identityRepo, env.userRepo, env.passkeyRepo)
+
+	return env
+}
+
+func (env *testEnv) createUser(t *testing.T, email string) model.Users {
+	t.Helper()
+
+	user, err := env.userRepo.CreateUser(model.Users{
+		Name:  "Test User",
+		Email: email,
+		Role:  string(model.User),
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	return user
+}
+
+// testPassword - password yang lolos password policy default
+const testPassword = "Correct-Horse-Battery-42"
+
+func (env *testEnv) createUserWithPassword(t *testing.T, email string, password string) model.Users {
+	t.Helper()
+
+	user := env.createUser(t, email)
+	hashedPassword, err := env.passwordHasher.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	user.Password = hashedPassword
+	user, err = env.userRepo.UpdateUser(user)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	return user
+}
+
+// testConfig - env.Cfg yang sedang dipakai, untuk test yang variabel env-nya menutupi package env
+func testConfig() *env.Config {
+	return &env.Cfg
+}
+
+const sqliteDriver = "sqlite3_refina_test"
+
+var registerDriver sync.Once
+
+var sqliteDDL = strings.NewReplacer(
+	"DEFAULT uuid_generate_v4()", "DEFAULT (uuid_generate_v4())",
+	"timestamptz", "timestamp",
+)
+
+// newTestRedis menjalankan miniredis untuk satu test. Miniredis ikut dikembalikan agar test bisa memajukan waktu
+// dengan FastForward untuk menguji TTL.
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return client, server
+}
+
+// newTestDB membuat database SQLite sementara dan membuat tabel untuk models. Fungsi uuid_generate_v4 dan
+// pg_advisory_xact_lock milik postgres diganti dengan fungsi Go agar default kolom dan query repository tetap jalan.
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	registerDriver.Do(func() {
+		sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				if err := conn.RegisterFunc("uuid_generate_v4", uuid.NewString, false); err != nil {
+					return err
+				}
+				return conn.RegisterFunc("pg_advisory_xact_lock", func(int64) int64 { return 0 }, false)
+			},
+		})
+	})
+
+	db, err := gorm.Open(sqlite.New(sqlite.Config{
+		DriverName: sqliteDriver,
+		DSN:        "file:" + filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000&_txlock=immediate",
+	}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// SQLITE HANYA MENERIMA FUNGSI SEBAGAI DEFAULT KOLOM JIKA DIBUNGKUS KURUNG, DAN DRIVER HANYA MENGEMBALIKAN
+	// time.Time UNTUK KOLOM BERTIPE timestamp
+	if err := db.Callback().Raw().Before("gorm:raw").Register("test:sqlite_ddl", func(tx *gorm.DB) {
+		query := tx.Statement.SQL.String()
+		if rewritten := sqliteDDL.Replace(query); rewritten != query {
+			tx.Statement.SQL.Reset()
+			tx.Statement.SQL.WriteString(rewritten)
+		}
+	}); err != nil {
+		t.Fatalf("register sqlite callback: %v", err)
+	}
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+// sentEmail - satu email yang dikirim lewat testMailer
+type sentEmail struct {
+	To      string
+	Subject string
+	File    string
+	Data    any
+}
+
+// testMailer - SMTP client palsu yang menyimpan email terkirim, memenuhi helper.SMTPClientInterface.
+// Email di service dikirim dari goroutine, sehingga test memakai Next untuk menunggu email berikutnya.
+type testMailer struct {
+	emails chan sentEmail
+}
+
+func newTestMailer() *testMailer {
+	return &testMailer{emails: make(chan sentEmail, 100)}
+}
+
+func (mailer *testMailer) SendSingleEmail(to string, subject string, htmlFile string, data any) error {
+	mailer.emails <- sentEmail{To: to, Subject: subject, File: htmlFile, Data: data}
+	return nil
+}
+
+// Next menunggu email berikutnya, test gagal jika tidak ada email dalam satu detik
+func (mailer *testMailer) Next(t *testing.T) sentEmail {
+	t.Helper()
+
+	select {
+	case email := <-mailer.emails:
+		return email
+	case <-time.After(time.Second):
+		t.Fatal("expected an email to be sent")
+		return sentEmail{}
+	}
+}
+
+// None memastikan tidak ada email yang terkirim dalam waktu singkat
+func (mailer *testMailer) None(t *testing.T) {
+	t.Helper()
+
+	select {
+	case email := <-mailer.emails:
+		t.Fatalf("unexpected email %q to %s", email.Subject, email.To)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
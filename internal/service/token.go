@@ -0,0 +1,292 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type TokenService interface {
+	GenerateTokens(user model.Users, loginMethod string, client dto.ClientInfo) (*dto.TokenResponse, error)
+	RefreshTokens(refreshToken string, client dto.ClientInfo) (*dto.TokenResponse, error)
+	VerifyAccessToken(accessToken string) (*dto.JWTClaims, error)
+	Logout(claims *dto.JWTClaims, refreshToken string) error
+	LogoutAll(userID string) error
+	RevokeSession(sessionID string) error
+	RevokeAccessTokens(userID string) error
+	CreateAuthCode(userID string) (string, error)
+	ConsumeAuthCode(code string) (string, error)
+}
+
+type tokenService struct {
+	tokenRepository   repository.TokenRepository
+	userRepository    repository.UsersRepository
+	sessionRepository repository.SessionRepository
+	keyService        KeyService
+}
+
+func NewTokenService(tokenRepository repository.TokenRepository, userRepository repository.UsersRepository, sessionRepository repository.SessionRepository, keyService KeyService) TokenService {
+	return &tokenService{
+		tokenRepository:   tokenRepository,
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		keyService:        keyService,
+	}
+}
+
+// GenerateTokens mencatat session baru untuk perangkat yang login lalu menerbitkan token.
+// Setiap login memulai refresh token family baru dengan id yang sama dengan id session.
+func (token_serv *tokenService) GenerateTokens(user model.Users, loginMethod string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	now := time.Now()
+	session, err := token_serv.sessionRepository.CreateSession(model.UserSessions{
+		Base:        model.Base{ID: uuid.New()},
+		UserID:      user.ID,
+		Device:      helper.DeviceName(client.UserAgent),
+		UserAgent:   client.UserAgent,
+		IPAddress:   client.IP,
+		LoginMethod: loginMethod,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(env.Cfg.JWT.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token_serv.issueTokens(user, session.ID.String())
+}
+
+func (token_serv *tokenService) RefreshTokens(refreshToken string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh token cannot be blank")
+	}
+
+	tokenHash := helper.HashToken(refreshToken)
+
+	// MENGECEK APAKAH REFRESH TOKEN TERDAFTAR
+	storedToken, err := token_serv.tokenRepository.GetRefreshToken(tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// MENANDAI REFRESH TOKEN SUDAH DIPAKAI, JIKA SUDAH PERNAH DIPAKAI MAKA SELURUH FAMILY DICABUT
+	firstUse, err := token_serv.tokenRepository.UseRefreshToken(tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if !firstUse {
+		if err := token_serv.RevokeSession(storedToken.FamilyID); err != nil {
+			log.Error("Failed to revoke refresh token family: "+err.Error(), map[string]interface{}{
+				"user_id":   storedToken.UserID,
+				"family_id": storedToken.FamilyID,
+			})
+		}
+		log.Warn("Refresh token reuse detected, token family revoked", map[string]interface{}{
+			"user_id":   storedToken.UserID,
+			"family_id": storedToken.FamilyID,
+		})
+		return nil, errors.New("refresh token has already been used")
+	}
+
+	// MENGECEK APAKAH USER MASIH ADA
+	user, err := token_serv.userRepository.GetUserByID(storedToken.UserID)
+	if err != nil {
+		_ = token_serv.RevokeSession(storedToken.FamilyID)
+		return nil, err
+	}
+
+	// MEMPERBARUI WAKTU TERAKHIR AKTIF SESSION, REFRESH TOKEN BARU MEMPERPANJANG UMUR SESSION
+	now := time.Now()
+	if err := token_serv.sessionRepository.TouchSession(storedToken.FamilyID, client.IP, now, now.Add(env.Cfg.JWT.RefreshTokenTTL)); err != nil {
+		log.Error("Failed to update session: "+err.Error(), map[string]interface{}{
+			"user_id":    storedToken.UserID,
+			"session_id": storedToken.FamilyID,
+		})
+	}
+
+	return token_serv.issueTokens(user, storedToken.FamilyID)
+}
+
+func (token_serv *tokenService) VerifyAccessToken(accessToken string) (*dto.JWTClaims, error) {
+	claims := &dto.JWTClaims{}
+	if err := token_serv.keyService.ParseToken(accessToken, claims); err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || claims.UserID == "" {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	// MENGECEK APAKAH TOKEN SUDAH DI-LOGOUT
+	revoked, err := token_serv.tokenRepository.IsAccessTokenRevoked(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+
+	// MENGECEK APAKAH SESSION TEMPAT TOKEN DITERBITKAN SUDAH DICABUT
+	if claims.SessionID != "" {
+		revoked, err := token_serv.tokenRepository.IsSessionRevoked(claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, errors.New("session has been revoked")
+		}
+	}
+
+	// MENGECEK APAKAH SELURUH TOKEN USER SUDAH DICABUT (LOGOUT ALL / USER DIHAPUS)
+	revokedAt, err := token_serv.tokenRepository.GetUserTokensRevokedAt(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt > 0 && (claims.IssuedAtMicro < revokedAt) {
+		return nil, errors.New("token has been revoked")
+	}
+
+	return claims, nil
+}
+
+func (token_serv *tokenService) Logout(claims *dto.JWTClaims, refreshToken string) error {
+	// MENCABUT ACCESS TOKEN SAMPAI WAKTU EXPIRED-NYA
+	if err := token_serv.tokenRepository.RevokeAccessToken(claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		return err
+	}
+
+	if claims.SessionID != "" {
+		return token_serv.RevokeSession(claims.SessionID)
+	}
+
+	// TOKEN LAMA TANPA CLAIM SID, FAMILY DICARI DARI REFRESH TOKEN
+	if refreshToken == "" {
+		return nil
+	}
+
+	// MENCABUT REFRESH TOKEN FAMILY MILIK USER YANG SAMA
+	storedToken, err := token_serv.tokenRepository.GetRefreshToken(helper.HashToken(refreshToken))
+	if err != nil || storedToken.UserID != claims.UserID {
+		return nil
+	}
+
+	return token_serv.tokenRepository.RevokeTokenFamily(storedToken.FamilyID)
+}
+
+func (token_serv *tokenService) LogoutAll(userID string) error {
+	if err := token_serv.tokenRepository.RevokeUserTokenFamilies(userID); err != nil {
+		return err
+	}
+
+	if err := token_serv.sessionRepository.RevokeUserSessions(userID); err != nil {
+		return err
+	}
+
+	return token_serv.RevokeAccessTokens(userID)
+}
+
+// RevokeSession mencabut satu session: refresh token family dihapus dan access token yang sudah diterbitkan
+// untuk session tersebut ditolak sampai expired
+func (token_serv *tokenService) RevokeSession(sessionID string) error {
+	if err := token_serv.tokenRepository.RevokeTokenFamily(sessionID); err != nil {
+		return err
+	}
+
+	if err := token_serv.tokenRepository.RevokeSessionAccessTokens(sessionID, env.Cfg.JWT.AccessTokenTTL); err != nil {
+		return err
+	}
+
+	return token_serv.sessionRepository.RevokeSession(sessionID)
+}
+
+// RevokeAccessTokens mencabut seluruh access token user tanpa mencabut refresh token,
+// sehingga client harus refresh untuk mendapatkan claim terbaru
+func (token_serv *tokenService) RevokeAccessTokens(userID string) error {
+	return token_serv.tokenRepository.RevokeUserAccessTokens(userID, time.Now(), env.Cfg.JWT.AccessTokenTTL)
+}
+
+// CreateAuthCode membuat authorization code sekali pakai berumur pendek yang dikirim ke frontend lewat redirect,
+// sehingga token tidak pernah muncul di URL. Hanya hash-nya yang disimpan di Redis.
+func (token_serv *tokenService) CreateAuthCode(userID string) (string, error) {
+	code, err := secrets.Token()
+	if err != nil {
+		return "", err
+	}
+
+	if err := token_serv.tokenRepository.SaveAuthCode(helper.HashToken(code), userID, data.AUTH_CODE_TTL); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// ConsumeAuthCode mengembalikan id user pemilik authorization code, code dihapus saat diambil sehingga replay akan ditolak
+func (token_serv *tokenService) ConsumeAuthCode(code string) (string, error) {
+	if code == "" {
+		return "", errors.New("authorization code cannot be blank")
+	}
+
+	userID, err := token_serv.tokenRepository.ConsumeAuthCode(helper.HashToken(code))
+	if err != nil {
+		return "", errors.New("authorization code is invalid or expired")
+	}
+
+	return userID, nil
+}
+
+func (token_serv *tokenService) signAccessToken(user model.Users, sessionID string) (string, error) {
+	issuedAt := time.Now()
+	claims := dto.JWTClaims{
+		UserID:    user.ID.String(),
+		Username:  user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
+		// IAT HANYA BERPRESISI DETIK, TOKEN YANG DITERBITKAN TEPAT SETELAH LOGOUT ALL (MISALNYA SAAT GANTI
+		// PASSWORD) TIDAK BOLEH IKUT TERCABUT OLEH TIMESTAMP PENCABUTAN DI DETIK YANG SAMA
+		IssuedAtMicro: issuedAt.UnixMicro(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(env.Cfg.JWT.AccessTokenTTL)),
+		},
+	}
+
+	return token_serv.keyService.SignToken(claims)
+}
+
+// issueTokens menerbitkan access token dan refresh token untuk session, refresh token family memakai id session
+func (token_serv *tokenService) issueTokens(user model.Users, sessionID string) (*dto.TokenResponse, error) {
+	accessToken, err := token_serv.signAccessToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := secrets.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := token_serv.tokenRepository.SaveRefreshToken(helper.HashToken(refreshToken), model.RefreshToken{
+		UserID:   user.ID.String(),
+		FamilyID: sessionID,
+	}, env.Cfg.JWT.RefreshTokenTTL); err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(env.Cfg.JWT.AccessTokenTTL.Seconds()),
+	}, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"refina-auth/internal/utils/data"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRefreshTokensRotatesRefreshToken(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "rotate@example.com")
+
+	first, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	second, err := env.tokenService.RefreshTokens(first.RefreshToken, testClient)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	claims, err := env.tokenService.VerifyAccessToken(second.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken: %v", err)
+	}
+	if claims.UserID != user.ID.String() {
+		t.Fatalf("claims.UserID = %q, want %q", claims.UserID, user.ID)
+	}
+
+	// TOKEN BARU TETAP BISA DI-REFRESH
+	if _, err := env.tokenService.RefreshTokens(second.RefreshToken, testClient); err != nil {
+		t.Fatalf("RefreshTokens with rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokensReuseRevokesFamily(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "reuse@example.com")
+
+	first, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	second, err := env.tokenService.RefreshTokens(first.RefreshToken, testClient)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+
+	// REFRESH TOKEN LAMA DIPAKAI ULANG, SELURUH FAMILY HARUS DICABUT
+	if _, err := env.tokenService.RefreshTokens(first.RefreshToken, testClient); err == nil {
+		t.Fatal("reused refresh token was accepted")
+	}
+	if _, err := env.tokenService.RefreshTokens(second.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token from a revoked family was accepted")
+	}
+}
+
+func TestRefreshTokensFamiliesAreIndependent(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "families@example.com")
+
+	laptop, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	phone, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	if _, err := env.tokenService.RefreshTokens(laptop.RefreshToken, testClient); err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if _, err := env.tokenService.RefreshTokens(laptop.RefreshToken, testClient); err == nil {
+		t.Fatal("reused refresh token was accepted")
+	}
+
+	// REUSE DI SATU PERANGKAT TIDAK MENCABUT FAMILY PERANGKAT LAIN
+	if _, err := env.tokenService.RefreshTokens(phone.RefreshToken, testClient); err != nil {
+		t.Fatalf("RefreshTokens for another family: %v", err)
+	}
+}
+
+func TestRefreshTokensRejectsInvalidAndExpiredTokens(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "expired@example.com")
+
+	if _, err := env.tokenService.RefreshTokens("", testClient); err == nil {
+		t.Fatal("blank refresh token was accepted")
+	}
+	if _, err := env.tokenService.RefreshTokens("not-a-refresh-token", testClient); err == nil {
+		t.Fatal("unknown refresh token was accepted")
+	}
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	env.miniredis.FastForward(data.REFRESH_TOKEN_TTL + 1)
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("expired refresh token was accepted")
+	}
+}
+
+func TestRefreshTokensRejectsDeletedUser(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "deleted@example.com")
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	if _, err := env.userRepo.DeleteUser(user); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token of a deleted user was accepted")
+	}
+}
+
+func TestLogoutAllRevokesEarlierAccessTokensOnly(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "logout-all@example.com")
+
+	before, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	if err := env.tokenService.LogoutAll(user.ID.String()); err != nil {
+		t.Fatalf("LogoutAll: %v", err)
+	}
+
+	// TOKEN YANG DITERBITKAN TEPAT SETELAH LOGOUT ALL (SEPERTI SAAT GANTI PASSWORD) HARUS TETAP VALID
+	after, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	if _, err := env.tokenService.VerifyAccessToken(before.AccessToken); err == nil {
+		t.Fatal("access token issued before LogoutAll was accepted")
+	}
+	if _, err := env.tokenService.RefreshTokens(before.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token issued before LogoutAll was accepted")
+	}
+	if _, err := env.tokenService.VerifyAccessToken(after.AccessToken); err != nil {
+		t.Fatalf("access token issued right after LogoutAll was rejected: %v", err)
+	}
+}
+
+func TestLogoutRevokesAccessTokenAndSession(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "logout@example.com")
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	other, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+
+	claims, err := env.tokenService.VerifyAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken: %v", err)
+	}
+	if err := env.tokenService.Logout(claims, tokens.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if _, err := env.tokenService.VerifyAccessToken(tokens.AccessToken); err == nil {
+		t.Fatal("access token was accepted after Logout")
+	}
+	if _, err := env.tokenService.RefreshTokens(tokens.RefreshToken, testClient); err == nil {
+		t.Fatal("refresh token was accepted after Logout")
+	}
+
+	// SESSION LAIN TIDAK IKUT TERCABUT
+	if _, err := env.tokenService.VerifyAccessToken(other.AccessToken); err != nil {
+		t.Fatalf("access token of another session was rejected: %v", err)
+	}
+}
+
+func TestAccessTokenIssuedAtKeepsLibraryPrecision(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "issued-at@example.com")
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	claims, err := env.tokenService.VerifyAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken: %v", err)
+	}
+
+	// PRESISI IAT GLOBAL DI LIBRARY JWT TIDAK DIUBAH, WAKTU PRESISI DISIMPAN DI CLAIM iat_us
+	if jwt.TimePrecision != time.Second || claims.IssuedAt.Unix() != claims.IssuedAtMicro/1e6 {
+		t.Fatalf("iat = %v, iat_us = %d, precision = %v", claims.IssuedAt, claims.IssuedAtMicro, jwt.TimePrecision)
+	}
+}
+
+func TestAuthCodeIsSingleUseAndShortLived(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "auth-code@example.com")
+
+	code, err := env.tokenService.CreateAuthCode(user.ID.String())
+	if err != nil {
+		t.Fatalf("CreateAuthCode: %v", err)
+	}
+
+	// HANYA HASH CODE YANG DISIMPAN DI REDIS
+	for _, key := range env.miniredis.Keys() {
+		if strings.Contains(key, code) {
+			t.Fatalf("plaintext authorization code stored in redis key %q", key)
+		}
+	}
+
+	userID, err := env.tokenService.ConsumeAuthCode(code)
+	if err != nil || userID != user.ID.String() {
+		t.Fatalf("ConsumeAuthCode = %q, %v; want %q", userID, err, user.ID)
+	}
+	if _, err := env.tokenService.ConsumeAuthCode(code); err == nil {
+		t.Fatal("authorization code was accepted twice")
+	}
+	if _, err := env.tokenService.ConsumeAuthCode(""); err == nil {
+		t.Fatal("blank authorization code was accepted")
+	}
+
+	expired, err := env.tokenService.CreateAuthCode(user.ID.String())
+	if err != nil {
+		t.Fatalf("CreateAuthCode: %v", err)
+	}
+	env.miniredis.FastForward(data.AUTH_CODE_TTL + time.Second)
+	if _, err := env.tokenService.ConsumeAuthCode(expired); err == nil {
+		t.Fatal("expired authorization code was accepted")
+	}
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/repository"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/secrets"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+var ErrTwoFactorLocked = errors.New("too many invalid authentication codes, please try again later")
+
+type TwoFactorService interface {
+	Enroll(userID string) (dto.TwoFactorEnrollResponse, error)
+	Confirm(userID string, currentSessionID string, code string) ([]string, error)
+	Disable(userID string, currentSessionID string, code string) error
+	RegenerateRecoveryCodes(userID string, code string) ([]string, error)
+	VerifyCode(userID string, code string) error
+	CompleteLogin(user model.Users, loginMethod string, client dto.ClientInfo) (*dto.LoginResponse, error)
+	VerifyChallenge(challengeToken string, code string, client dto.ClientInfo) (*dto.TokenResponse, error)
+	VerifyRecoveryCode(challengeToken string, recoveryCode string, client dto.ClientInfo) (*dto.TokenResponse, error)
+}
+
+type twoFactorService struct {
+	userRepository         repository.UsersRepository
+	twoFactorRepository    repository.TwoFactorRepository
+	recoveryCodeRepository repository.RecoveryCodeRepository
+	tokenService           TokenService
+	sessionService         SessionService
+	loginHistoryService    LoginHistoryService
+	smtpClient             helper.SMTPClientInterface
+}
+
+func NewTwoFactorService(userRepository repository.UsersRepository, twoFactorRepository repository.TwoFactorRepository, recoveryCodeRepository repository.RecoveryCodeRepository, tokenService TokenService, sessionService SessionService, loginHistoryService LoginHistoryService, smtpClient helper.SMTPClientInterface) TwoFactorService {
+	return &twoFactorService{
+		userRepository:         userRepository,
+		twoFactorRepository:    twoFactorRepository,
+		recoveryCodeRepository: recoveryCodeRepository,
+		tokenService:           tokenService,
+		sessionService:         sessionService,
+		loginHistoryService:    loginHistoryService,
+		smtpClient:             smtpClient,
+	}
+}
+
+// Enroll membuat secret TOTP baru untuk user. Secret disimpan terenkripsi dan 2FA belum aktif sampai dikonfirmasi.
+func (two_factor_serv *twoFactorService) Enroll(userID string) (dto.TwoFactorEnrollResponse, error) {
+	user, err := two_factor_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	// MENGECEK APAKAH 2FA SUDAH AKTIF
+	if user.TwoFactorEnabledAt.Valid {
+		return dto.TwoFactorEnrollResponse{}, errors.New("two-factor authentication is already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      data.TOTP_ISSUER,
+		AccountName: user.Email,
+		Period:      uint(data.TOTP_PERIOD),
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	// MEMBUAT QR CODE PNG DARI URI otpauth://
+	image, err := key.Image(data.TOTP_QR_CODE_SIZE, data.TOTP_QR_CODE_SIZE)
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+	var qrCode bytes.Buffer
+	if err := png.Encode(&qrCode, image); err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	// MENYIMPAN SECRET TERENKRIPSI, ENROLLMENT SEBELUMNYA YANG BELUM DIKONFIRMASI AKAN DITIMPA
+	encryptedSecret, err := helper.Encrypt(key.Secret())
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+	user.TwoFactorSecret = encryptedSecret
+	if _, err := two_factor_serv.userRepository.UpdateUser(user); err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	return dto.TwoFactorEnrollResponse{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode.Bytes()),
+	}, nil
+}
+
+// Confirm mengaktifkan 2FA setelah user membuktikan authenticator app sudah menghasilkan kode yang benar.
+// Recovery code dikembalikan dalam bentuk plaintext hanya sekali, di sini. Session lain dicabut sehingga
+// perangkat yang login sebelum 2FA aktif harus login ulang dengan 2FA.
+func (two_factor_serv *twoFactorService) Confirm(userID string, currentSessionID string, code string) ([]string, error) {
+	user, err := two_factor_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabledAt.Valid {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, errors.New("two-factor authentication has not been enrolled")
+	}
+
+	if err := two_factor_serv.validateCodeWithLimit(user, code); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := two_factor_serv.generateRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TwoFactorEnabledAt = sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	}
+	if _, err := two_factor_serv.userRepository.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := two_factor_serv.sessionService.RevokeOtherSessions(userID, currentSessionID); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Disable mematikan 2FA, membutuhkan kode TOTP yang valid agar tidak bisa dimatikan hanya dengan access token curian.
+// Session lain ikut dicabut karena perlindungan akun berubah.
+func (two_factor_serv *twoFactorService) Disable(userID string, currentSessionID string, code string) error {
+	user, err := two_factor_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabledAt.Valid {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := two_factor_serv.validateCodeWithLimit(user, code); err != nil {
+		return err
+	}
+
+	user.TwoFactorSecret = ""
+	user.TwoFactorEnabledAt = sql.NullTime{}
+	if _, err := two_factor_serv.userRepository.UpdateUser(user); err != nil {
+		return err
+	}
+
+	if err := two_factor_serv.recoveryCodeRepository.DeleteRecoveryCodes(userID); err != nil {
+		return err
+	}
+
+	return two_factor_serv.sessionService.RevokeOtherSessions(userID, currentSessionID)
+}
+
+// RegenerateRecoveryCodes mengganti seluruh recovery code, kode lama langsung tidak berlaku
+func (two_factor_serv *twoFactorService) RegenerateRecoveryCodes(userID string, code string) ([]string, error) {
+	user, err := two_factor_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabledAt.Valid {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	if err := two_factor_serv.validateCodeWithLimit(user, code); err != nil {
+		return nil, err
+	}
+
+	return two_factor_serv.generateRecoveryCodes(userID)
+}
+
+// VerifyCode memvalidasi kode TOTP user yang 2FA-nya aktif untuk konfirmasi identitas sebelum aksi sensitif,
+// dengan batas percobaan yang sama seperti pengelolaan 2FA
+func (two_factor_serv *twoFactorService) VerifyCode(userID string, code string) error {
+	user, err := two_factor_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabledAt.Valid {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	return two_factor_serv.validateCodeWithLimit(user, code)
+}
+
+// CompleteLogin dipanggil setelah faktor pertama berhasil. Jika 2FA aktif, token belum diterbitkan dan
+// user mendapatkan challenge token yang harus diselesaikan di POST /auth/2fa/verify.
+func (two_factor_serv *twoFactorService) CompleteLogin(user model.Users, loginMethod string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	// AKUN YANG DILAPORKAN "THIS WASN'T ME" HARUS MENGGANTI PASSWORD LEWAT LINK RESET TERLEBIH DAHULU
+	if err := two_factor_serv.loginHistoryService.CheckPasswordReset(user, loginMethod, client); err != nil {
+		return nil, err
+	}
+
+	if !user.TwoFactorEnabledAt.Valid {
+		token, err := two_factor_serv.tokenService.GenerateTokens(user, loginMethod, client)
+		if err != nil {
+			return nil, err
+		}
+		two_factor_serv.loginHistoryService.RecordSuccess(user, loginMethod, client)
+		return &dto.LoginResponse{Token: token}, nil
+	}
+
+	challengeToken, err := secrets.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := two_factor_serv.twoFactorRepository.SaveChallenge(helper.HashToken(challengeToken), model.TwoFactorChallenge{
+		UserID:      user.ID.String(),
+		LoginMethod: loginMethod,
+	}, data.TWO_FACTOR_CHALLENGE_TTL); err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Challenge: &dto.TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+			ExpiresIn:         int64(data.TWO_FACTOR_CHALLENGE_TTL.Seconds()),
+		},
+	}, nil
+}
+
+func (two_factor_serv *twoFactorService) VerifyChallenge(challengeToken string, code string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	return two_factor_serv.completeChallenge(challengeToken, code, client, two_factor_serv.validateCodeWithLimit)
+}
+
+// VerifyRecoveryCode menyelesaikan challenge 2FA dengan recovery code untuk user yang kehilangan authenticator app
+func (two_factor_serv *twoFactorService) VerifyRecoveryCode(challengeToken string, recoveryCode string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	return two_factor_serv.completeChallenge(challengeToken, recoveryCode, client, func(user model.Users, recoveryCode string) error {
+		return two_factor_serv.withAttemptLimit(user, func() error {
+			codeHash := hashRecoveryCode(user.ID.String(), secrets.NormalizeRecoveryCode(recoveryCode))
+			if err := two_factor_serv.recoveryCodeRepository.UseRecoveryCode(user.ID.String(), codeHash); err != nil {
+				return errors.New("recovery code is invalid or has already been used")
+			}
+
+			// MEMBERITAHU PEMILIK AKUN KARENA RECOVERY CODE MELEWATI AUTHENTICATOR APP
+			go two_factor_serv.sendRecoveryCodeUsedNotice(user)
+
+			return nil
+		})
+	})
+}
+
+// completeChallenge memvalidasi challenge token lalu faktor kedua dengan verify. Kode salah menambah jumlah
+// percobaan dan challenge dihapus setelah mencapai batas.
+func (two_factor_serv *twoFactorService) completeChallenge(challengeToken string, code string, client dto.ClientInfo, verify func(user model.Users, code string) error) (*dto.TokenResponse, error) {
+	if challengeToken == "" || code == "" {
+		return nil, errors.New("challenge token and code cannot be blank")
+	}
+
+	challengeHash := helper.HashToken(challengeToken)
+
+	// MENGECEK APAKAH CHALLENGE MASIH BERLAKU
+	challenge, err := two_factor_serv.twoFactorRepository.GetChallenge(challengeHash)
+	if err != nil {
+		return nil, errors.New("2fa challenge is invalid or expired")
+	}
+
+	user, err := two_factor_serv.userRepository.GetUserByID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// CHALLENGE YANG DIBUAT SEBELUM LOGIN DILAPORKAN TIDAK BOLEH MENERBITKAN TOKEN
+	if err := two_factor_serv.loginHistoryService.CheckPasswordReset(user, challenge.LoginMethod, client); err != nil {
+		return nil, err
+	}
+
+	if err := verify(user, code); err != nil {
+		two_factor_serv.loginHistoryService.RecordFailure(user, challenge.LoginMethod, client, data.LOGIN_FAILURE_INVALID_2FA_CODE)
+		if failErr := two_factor_serv.twoFactorRepository.FailChallenge(challengeHash, data.TWO_FACTOR_MAX_ATTEMPTS); failErr != nil {
+			return nil, failErr
+		}
+		return nil, err
+	}
+
+	// CHALLENGE HANYA BISA DIPAKAI SEKALI
+	if err := two_factor_serv.twoFactorRepository.DeleteChallenge(challengeHash); err != nil {
+		return nil, err
+	}
+
+	token, err := two_factor_serv.tokenService.GenerateTokens(user, challenge.LoginMethod, client)
+	if err != nil {
+		return nil, err
+	}
+	two_factor_serv.loginHistoryService.RecordSuccess(user, challenge.LoginMethod, client)
+
+	return token, nil
+}
+
+// generateRecoveryCodes membuat recovery code baru dan menyimpan hash-nya, menggantikan recovery code lama
+func (two_factor_serv *twoFactorService) generateRecoveryCodes(userID string) ([]string, error) {
+	recoveryCodes, err := secrets.RecoveryCodes(data.RECOVERY_CODE_COUNT)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedUserID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]model.UserRecoveryCodes, 0, len(recoveryCodes))
+	for _, recoveryCode := range recoveryCodes {
+		codes = append(codes, model.UserRecoveryCodes{
+			UserID:   parsedUserID,
+			CodeHash: hashRecoveryCode(userID, recoveryCode),
+		})
+	}
+
+	if err := two_factor_serv.recoveryCodeRepository.ReplaceRecoveryCodes(userID, codes); err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (two_factor_serv *twoFactorService) sendRecoveryCodeUsedNotice(user model.Users) {
+	remainingCodes, err := two_factor_serv.recoveryCodeRepository.CountUnusedRecoveryCodes(user.ID.String())
+	if err != nil {
+		log.Error("Failed to count recovery codes: " + err.Error())
+	}
+
+	if err := two_factor_serv.smtpClient.SendSingleEmail(user.Email, "A Recovery Code Was Used", "recovery-code-used-email-template.html", data.RecoveryCodeUsedEmail{
+		Name:           user.Name,
+		RemainingCodes: remainingCodes,
+		URL:            env.Cfg.Client.Url + "/settings/security",
+	}); err != nil {
+		log.Error("Failed to send recovery code used email: "+err.Error(), map[string]interface{}{"user_id": user.ID.String()})
+	}
+}
+
+// hashRecoveryCode - id user ikut di-hash sehingga hash yang sama tidak berlaku untuk user lain
+func hashRecoveryCode(userID string, recoveryCode string) string {
+	return helper.HashToken(userID + ":" + recoveryCode)
+}
+
+// validateCodeWithLimit memvalidasi kode TOTP dengan batas percobaan per user, sehingga access token curian
+// maupun password yang bocor tidak bisa dipakai untuk menebak kode TOTP
+func (two_factor_serv *twoFactorService) validateCodeWithLimit(user model.Users, code string) error {
+	return two_factor_serv.withAttemptLimit(user, func() error {
+		return two_factor_serv.validateCode(user, code)
+	})
+}
+
+// withAttemptLimit menjalankan verify dengan batas percobaan per user yang berlaku lintas challenge login,
+// sehingga login ulang untuk mendapatkan challenge baru tidak mereset batas. Percobaan dihitung sebelum
+// validasi dan counter di-reset setelah kode benar.
+func (two_factor_serv *twoFactorService) withAttemptLimit(user model.Users, verify func() error) error {
+	attempts, err := two_factor_serv.twoFactorRepository.IncrementFailedAttempts(user.ID.String(), data.TWO_FACTOR_LOCKOUT_TTL)
+	if err != nil {
+		return err
+	}
+	if attempts > int64(data.TWO_FACTOR_MAX_ATTEMPTS) {
+		return ErrTwoFactorLocked
+	}
+
+	if err := verify(); err != nil {
+		return err
+	}
+
+	return two_factor_serv.twoFactorRepository.ResetFailedAttempts(user.ID.String())
+}
+
+// validateCode mencocokkan kode dengan time step sekarang dan TOTP_SKEW step sebelum/sesudahnya untuk
+// mentoleransi perbedaan jam. Time step yang sudah dipakai dicatat sehingga kode yang sama tidak bisa dipakai ulang.
+func (two_factor_serv *twoFactorService) validateCode(user model.Users, code string) error {
+	if user.TwoFactorSecret == "" {
+		return errors.New("two-factor authentication has not been enrolled")
+	}
+
+	secret, err := helper.Decrypt(user.TwoFactorSecret)
+	if err != nil {
+		return err
+	}
+
+	opts := totp.ValidateOpts{
+		Period:    uint(data.TOTP_PERIOD),
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	currentCounter := time.Now().Unix() / int64(data.TOTP_PERIOD)
+	for offset := -data.TOTP_SKEW; offset <= data.TOTP_SKEW; offset++ {
+		counter := currentCounter + int64(offset)
+
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(counter*int64(data.TOTP_PERIOD), 0), opts)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		// REPLAY PROTECTION, CATATAN DISIMPAN SELAMA KODE MASIH BISA DITERIMA
+		window := time.Duration(2*data.TOTP_SKEW+1) * time.Duration(data.TOTP_PERIOD) * time.Second
+		firstUse, err := two_factor_serv.twoFactorRepository.MarkCodeUsed(user.ID.String(), counter, window)
+		if err != nil {
+			return err
+		}
+		if !firstUse {
+			return errors.New("authentication code has already been used")
+		}
+
+		return nil
+	}
+
+	return errors.New("authentication code is invalid")
+}
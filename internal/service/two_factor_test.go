@@ -0,0 +1,306 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// totpCode membuat kode TOTP untuk time step sekarang + step. Setiap kode hanya bisa dipakai sekali,
+// sehingga test yang butuh beberapa kode memakai step yang berbeda di dalam skew window.
+func totpCode(t *testing.T, secret string, step int) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, time.Now().Add(time.Duration(step*data.TOTP_PERIOD)*time.Second))
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+
+	return code
+}
+
+// login menerbitkan token untuk user dan mengembalikan id session-nya
+func (env *testEnv) login(t *testing.T, user model.Users) (*dto.TokenResponse, string) {
+	t.Helper()
+
+	tokens, err := env.tokenService.GenerateTokens(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("GenerateTokens: %v", err)
+	}
+	claims, err := env.tokenService.VerifyAccessToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyAccessToken: %v", err)
+	}
+
+	return tokens, claims.SessionID
+}
+
+// enableTwoFactor mengaktifkan 2FA dari session sessionID dan mengembalikan secret TOTP beserta recovery code
+func (env *testEnv) enableTwoFactor(t *testing.T, userID string, sessionID string) (string, []string) {
+	t.Helper()
+
+	enrollment, err := env.twoFactorService.Enroll(userID)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	recoveryCodes, err := env.twoFactorService.Confirm(userID, sessionID, totpCode(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	return enrollment.Secret, recoveryCodes
+}
+
+func (env *testEnv) reloadUser(t *testing.T, userID string) model.Users {
+	t.Helper()
+
+	user, err := env.userRepo.GetUserByID(userID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+
+	return user
+}
+
+func TestTwoFactorEnrollStoresEncryptedSecret(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+
+	enrollment, err := env.twoFactorService.Enroll(user.ID.String())
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURL, "otpauth://totp/") || !strings.Contains(enrollment.OTPAuthURL, "secret="+enrollment.Secret) {
+		t.Fatalf("OTPAuthURL = %q", enrollment.OTPAuthURL)
+	}
+	if !strings.HasPrefix(enrollment.QRCode, "data:image/png;base64,") {
+		t.Fatalf("QRCode = %.40q, want a PNG data URI", enrollment.QRCode)
+	}
+
+	user = env.reloadUser(t, user.ID.String())
+	if user.TwoFactorSecret == "" || strings.Contains(user.TwoFactorSecret, enrollment.Secret) {
+		t.Fatal("TOTP secret is not stored encrypted")
+	}
+	if user.TwoFactorEnabledAt.Valid {
+		t.Fatal("2FA enabled before confirmation")
+	}
+
+	if _, err := env.twoFactorService.Confirm(user.ID.String(), "", "000000"); err == nil {
+		t.Fatal("Confirm accepted a wrong code")
+	}
+}
+
+func TestTwoFactorConfirmRevokesOtherSessions(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+
+	currentTokens, currentSession := env.login(t, user)
+	otherTokens, _ := env.login(t, user)
+
+	_, recoveryCodes := env.enableTwoFactor(t, user.ID.String(), currentSession)
+	if len(recoveryCodes) != data.RECOVERY_CODE_COUNT {
+		t.Fatalf("got %d recovery codes, want %d", len(recoveryCodes), data.RECOVERY_CODE_COUNT)
+	}
+
+	if _, err := env.tokenService.RefreshTokens(otherTokens.RefreshToken, testClient); err == nil {
+		t.Fatal("other session survived enabling 2FA")
+	}
+	if _, err := env.tokenService.RefreshTokens(currentTokens.RefreshToken, testClient); err != nil {
+		t.Fatalf("current session was revoked: %v", err)
+	}
+}
+
+func TestTwoFactorLoginRequiresChallenge(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+	secret, _ := env.enableTwoFactor(t, user.ID.String(), "")
+	user = env.reloadUser(t, user.ID.String())
+
+	login, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if login.Token != nil || login.Challenge == nil || !login.Challenge.TwoFactorRequired {
+		t.Fatalf("CompleteLogin = %+v, want a 2FA challenge without tokens", login)
+	}
+
+	// KODE DI LUAR SKEW WINDOW DITOLAK
+	if _, err := env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, totpCode(t, secret, -3), testClient); err == nil {
+		t.Fatal("code outside the skew window was accepted")
+	}
+
+	code := totpCode(t, secret, 1)
+	tokens, err := env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, code, testClient)
+	if err != nil {
+		t.Fatalf("VerifyChallenge: %v", err)
+	}
+	if _, err := env.tokenService.VerifyAccessToken(tokens.AccessToken); err != nil {
+		t.Fatalf("access token from 2FA login rejected: %v", err)
+	}
+
+	// CHALLENGE DAN KODE TOTP HANYA BISA DIPAKAI SEKALI
+	if _, err := env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, totpCode(t, secret, -1), testClient); err == nil {
+		t.Fatal("challenge token was accepted twice")
+	}
+	login, _ = env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if _, err := env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, code, testClient); err == nil {
+		t.Fatal("TOTP code was replayed")
+	}
+}
+
+func TestTwoFactorChallengeIsBurnedAfterMaxAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+	secret, _ := env.enableTwoFactor(t, user.ID.String(), "")
+	user = env.reloadUser(t, user.ID.String())
+
+	login, err := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	for i := 0; i < data.TWO_FACTOR_MAX_ATTEMPTS; i++ {
+		env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, "000000", testClient)
+	}
+
+	if _, err := env.twoFactorService.VerifyChallenge(login.Challenge.ChallengeToken, totpCode(t, secret, 1), testClient); err == nil {
+		t.Fatal("challenge was still usable after too many wrong codes")
+	}
+}
+
+func TestTwoFactorLoginAttemptsAreLimitedAcrossChallenges(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUserWithPassword(t, "totp@example.com", testPassword)
+	secret, recoveryCodes := env.enableTwoFactor(t, user.ID.String(), "")
+
+	// PASSWORD YANG BOCOR TIDAK BISA DIPAKAI UNTUK TERUS MEMBUAT CHALLENGE BARU DAN MENEBAK KODE
+	challenge := func() string {
+		t.Helper()
+		login, err := env.usersService.Login(dto.UsersRequest{Email: user.Email, Password: testPassword}, testClient)
+		if err != nil || login.Challenge == nil {
+			t.Fatalf("Login = %+v, %v, want a 2FA challenge", login, err)
+		}
+		return login.Challenge.ChallengeToken
+	}
+	for i := 0; i < data.TWO_FACTOR_MAX_ATTEMPTS; i++ {
+		if _, err := env.twoFactorService.VerifyChallenge(challenge(), "000000", testClient); err == nil || errors.Is(err, ErrTwoFactorLocked) {
+			t.Fatalf("attempt %d: VerifyChallenge = %v, want an invalid code error", i+1, err)
+		}
+	}
+
+	if _, err := env.twoFactorService.VerifyChallenge(challenge(), totpCode(t, secret, 1), testClient); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("VerifyChallenge while locked = %v, want ErrTwoFactorLocked", err)
+	}
+	if _, err := env.twoFactorService.VerifyRecoveryCode(challenge(), recoveryCodes[0], testClient); !errors.Is(err, ErrTwoFactorLocked) {
+		t.Fatalf("VerifyRecoveryCode while locked = %v, want ErrTwoFactorLocked", err)
+	}
+
+	env.miniredis.FastForward(data.TWO_FACTOR_LOCKOUT_TTL)
+	if _, err := env.twoFactorService.VerifyChallenge(challenge(), totpCode(t, secret, 1), testClient); err != nil {
+		t.Fatalf("VerifyChallenge after the lockout: %v", err)
+	}
+}
+
+func TestTwoFactorRecoveryCodeIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+	_, recoveryCodes := env.enableTwoFactor(t, user.ID.String(), "")
+	user = env.reloadUser(t, user.ID.String())
+
+	login, _ := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	// FORMAT INPUT USER DINORMALISASI
+	if _, err := env.twoFactorService.VerifyRecoveryCode(login.Challenge.ChallengeToken, strings.ToLower(strings.ReplaceAll(recoveryCodes[0], "-", "")), testClient); err != nil {
+		t.Fatalf("VerifyRecoveryCode: %v", err)
+	}
+
+	for {
+		if sent := env.mailer.Next(t); sent.File == "recovery-code-used-email-template.html" {
+			if remaining := sent.Data.(data.RecoveryCodeUsedEmail).RemainingCodes; remaining != int64(data.RECOVERY_CODE_COUNT-1) {
+				t.Fatalf("RemainingCodes = %d, want %d", remaining, data.RECOVERY_CODE_COUNT-1)
+			}
+			break
+		}
+	}
+
+	login, _ = env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if _, err := env.twoFactorService.VerifyRecoveryCode(login.Challenge.ChallengeToken, recoveryCodes[0], testClient); err == nil {
+		t.Fatal("recovery code was accepted twice")
+	}
+}
+
+func TestTwoFactorManagementAttemptsAreLimited(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+
+	_, currentSession := env.login(t, user)
+	secret, _ := env.enableTwoFactor(t, user.ID.String(), currentSession)
+	otherTokens, _ := env.login(t, user)
+
+	for i := 0; i < data.TWO_FACTOR_MAX_ATTEMPTS; i++ {
+		if _, err := env.twoFactorService.RegenerateRecoveryCodes(user.ID.String(), "000000"); err == nil || err == ErrTwoFactorLocked {
+			t.Fatalf("attempt %d: RegenerateRecoveryCodes = %v, want an invalid code error", i+1, err)
+		}
+	}
+
+	// BATAS BERLAKU UNTUK SEMUA AKSI PENGELOLAAN 2FA, KODE YANG BENAR PUN DITOLAK
+	if err := env.twoFactorService.Disable(user.ID.String(), currentSession, totpCode(t, secret, 1)); err != ErrTwoFactorLocked {
+		t.Fatalf("Disable while locked = %v, want ErrTwoFactorLocked", err)
+	}
+	if !env.reloadUser(t, user.ID.String()).TwoFactorEnabledAt.Valid {
+		t.Fatal("2FA was disabled while locked")
+	}
+
+	env.miniredis.FastForward(data.TWO_FACTOR_LOCKOUT_TTL)
+	if err := env.twoFactorService.Disable(user.ID.String(), currentSession, totpCode(t, secret, 1)); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if env.reloadUser(t, user.ID.String()).TwoFactorEnabledAt.Valid {
+		t.Fatal("2FA is still enabled")
+	}
+	if _, err := env.tokenService.RefreshTokens(otherTokens.RefreshToken, testClient); err == nil {
+		t.Fatal("other session survived disabling 2FA")
+	}
+}
+
+func TestRegenerateRecoveryCodesInvalidatesPreviousCodes(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "totp@example.com")
+	secret, oldCodes := env.enableTwoFactor(t, user.ID.String(), "")
+	user = env.reloadUser(t, user.ID.String())
+
+	// HANYA HASH RECOVERY CODE YANG DISIMPAN
+	var stored []model.UserRecoveryCodes
+	env.db.Where("user_id = ?", user.ID).Find(&stored)
+	if len(stored) != data.RECOVERY_CODE_COUNT {
+		t.Fatalf("stored %d recovery codes, want %d", len(stored), data.RECOVERY_CODE_COUNT)
+	}
+	for _, code := range stored {
+		for _, plaintext := range oldCodes {
+			if code.CodeHash == plaintext {
+				t.Fatal("recovery code stored in plaintext")
+			}
+		}
+	}
+
+	if _, err := env.twoFactorService.RegenerateRecoveryCodes(user.ID.String(), "000000"); err == nil {
+		t.Fatal("recovery codes regenerated with a wrong code")
+	}
+	newCodes, err := env.twoFactorService.RegenerateRecoveryCodes(user.ID.String(), totpCode(t, secret, 1))
+	if err != nil {
+		t.Fatalf("RegenerateRecoveryCodes: %v", err)
+	}
+
+	login, _ := env.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_PASSWORD, testClient)
+	if _, err := env.twoFactorService.VerifyRecoveryCode(login.Challenge.ChallengeToken, oldCodes[0], testClient); err == nil {
+		t.Fatal("recovery code from before regeneration was accepted")
+	}
+	if _, err := env.twoFactorService.VerifyRecoveryCode(login.Challenge.ChallengeToken, newCodes[0], testClient); err != nil {
+		t.Fatalf("VerifyRecoveryCode with a new code: %v", err)
+	}
+}
@@ -5,30 +5,51 @@ import (
 	"errors"
 	"time"
 
+	"refina-auth/config/log"
 	"refina-auth/internal/repository"
 	"refina-auth/internal/types/dto"
 	"refina-auth/internal/types/model"
 	helper "refina-auth/internal/utils"
+	"refina-auth/internal/utils/data"
+	"refina-auth/internal/utils/hasher"
+	"refina-auth/internal/utils/policy"
 )
 
 type UsersService interface {
 	Register(user dto.UsersRequest) (dto.UsersResponse, error)
-	Login(user dto.UsersRequest) (*string, error)
-	OAuthLogin(name string, email string) (*string, error)
+	Login(user dto.UsersRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	OAuthLogin(profile dto.OAuthProfile, client dto.ClientInfo) (string, error)
+	ExchangeAuthCode(code string, client dto.ClientInfo) (*dto.LoginResponse, error)
 	GetAllUsers() ([]dto.UsersResponse, error)
 	GetUserByID(id string) (dto.UsersResponse, error)
 	GetUserByEmail(email string) (dto.UsersResponse, error)
 	UpdateUser(id string, userNew dto.UsersRequest) (dto.UsersResponse, error)
-	VerifyUser(email string) (dto.UsersResponse, error)
+	VerifyUser(email string, client dto.ClientInfo) (*dto.LoginResponse, error)
 	DeleteUser(id string) (dto.UsersResponse, error)
 }
 
 type usersService struct {
-	userRepository repository.UsersRepository
+	userRepository      repository.UsersRepository
+	identityRepository  repository.IdentityRepository
+	tokenService        TokenService
+	twoFactorService    TwoFactorService
+	loginAttemptService LoginAttemptService
+	loginHistoryService LoginHistoryService
+	passwordHasher      hasher.Hasher
+	passwordPolicy      policy.Policy
 }
 
-func NewUsersService(usersRepository repository.UsersRepository) UsersService {
-	return &usersService{usersRepository}
+func NewUsersService(usersRepository repository.UsersRepository, identityRepository repository.IdentityRepository, tokenService TokenService, twoFactorService TwoFactorService, loginAttemptService LoginAttemptService, loginHistoryService LoginHistoryService, passwordHasher hasher.Hasher, passwordPolicy policy.Policy) UsersService {
+	return &usersService{
+		userRepository:      usersRepository,
+		identityRepository:  identityRepository,
+		tokenService:        tokenService,
+		twoFactorService:    twoFactorService,
+		loginAttemptService: loginAttemptService,
+		loginHistoryService: loginHistoryService,
+		passwordHasher:      passwordHasher,
+		passwordPolicy:      passwordPolicy,
+	}
 }
 
 func (user_serv *usersService) Register(user dto.UsersRequest) (dto.UsersResponse, error) {
@@ -48,20 +69,13 @@ func (user_serv *usersService) Register(user dto.UsersRequest) (dto.UsersRespons
 		return dto.UsersResponse{}, errors.New("email already exists")
 	}
 
-	// VALIDASI PASSWORD SUDAH SESUAI, MIN 8 KARAKTER, MENGANDUNG ALFABET DAN NUMERIK
-	hasMinLen, hasLetter, hasDigit := helper.PasswordValidator(user.Password)
-	if !hasMinLen {
-		return dto.UsersResponse{}, errors.New("password must be at least 8 characters long")
-	}
-	if !hasLetter {
-		return dto.UsersResponse{}, errors.New("password must contain at least one letter")
-	}
-	if !hasDigit {
-		return dto.UsersResponse{}, errors.New("password must contain at least one number")
+	// VALIDASI PASSWORD SESUAI POLICY, SELURUH PELANGGARAN DIKEMBALIKAN SEKALIGUS
+	if err := user_serv.passwordPolicy.Validate(user.Password, user.Name, user.Email); err != nil {
+		return dto.UsersResponse{}, err
 	}
 
-	// HASHING PASSWORD MENGGUNAKAN BCRYPT
-	hashedPassword, err := helper.PasswordHashing(user.Password)
+	// HASHING PASSWORD MENGGUNAKAN ALGORITMA DEFAULT (ARGON2ID)
+	hashedPassword, err := user_serv.passwordHasher.Hash(user.Password)
 	if err != nil {
 		return dto.UsersResponse{}, err
 	}
@@ -81,38 +95,140 @@ func (user_serv *usersService) Register(user dto.UsersRequest) (dto.UsersRespons
 	return userResponse, nil
 }
 
-func (user_serv *usersService) Login(user dto.UsersRequest) (*string, error) {
+// Login mengembalikan token, atau challenge 2FA jika user mengaktifkan two-factor authentication
+func (user_serv *usersService) Login(user dto.UsersRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
 	// VALIDASI APAKAH EMAIL DAN PASSWORD KOSONG
 	if user.Email == "" || user.Password == "" {
 		return nil, errors.New("email and password cannot be blank")
 	}
 
+	// MENGECEK APAKAH AKUN TERKUNCI ATAU MASIH DALAM MASA BACKOFF SETELAH BEBERAPA KALI GAGAL
+	if err := user_serv.loginAttemptService.CheckLogin(user.Email, client.IP); err != nil {
+		return nil, err
+	}
+
 	// MENGECEK APAKAH USER SUDAH TERDAFTAR
 	userExist, err := user_serv.userRepository.GetUserByEmail(user.Email)
 	if err != nil {
+		if err := user_serv.loginAttemptService.RecordFailure(user.Email); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("user not found")
 	}
 
 	// VALIDASI APAKAH PASSWORD SUDAH SESUAI
-	if !helper.ComparePass(userExist.Password, user.Password) {
+	match, needsRehash := user_serv.passwordHasher.Verify(userExist.Password, user.Password)
+	if !match {
+		user_serv.loginHistoryService.RecordFailure(userExist, data.LOGIN_METHOD_PASSWORD, client, data.LOGIN_FAILURE_INVALID_PASSWORD)
+		if err := user_serv.loginAttemptService.RecordFailure(user.Email); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("password is incorrect")
 	}
 
-	token, err := helper.GenerateToken(userExist.ID.String(), userExist.Name, userExist.Email)
-	if err != nil {
+	// PASSWORD BENAR, PERCOBAAN YANG SUDAH DICATAT OLEH CheckLogin DIBATALKAN
+	if err := user_serv.loginAttemptService.RecordSuccess(user.Email, client.IP); err != nil {
 		return nil, err
 	}
 
-	return &token, nil
+	// HASH DENGAN ALGORITMA LAMA ATAU PARAMETER LEMAH DIGANTI SELAGI PASSWORD ASLI DIKETAHUI
+	if needsRehash {
+		if userExist, err = user_serv.rehashPassword(userExist, user.Password); err != nil {
+			log.Error("Failed to rehash password: "+err.Error(), map[string]interface{}{"user_id": userExist.ID.String()})
+		}
+	}
+
+	return user_serv.twoFactorService.CompleteLogin(userExist, data.LOGIN_METHOD_PASSWORD, client)
 }
 
-func (user_serv *usersService) OAuthLogin(name string, email string) (*string, error) {
-	token, err := helper.GenerateToken("99", name, email)
+// OAuthLogin mengembalikan authorization code sekali pakai yang ditukar dengan token lewat POST /auth/exchange
+func (user_serv *usersService) OAuthLogin(profile dto.OAuthProfile, client dto.ClientInfo) (string, error) {
+	// VALIDASI APAKAH PROVIDER MENGEMBALIKAN ID DAN EMAIL
+	if profile.ProviderUserID == "" {
+		return "", errors.New("provider did not return a user id")
+	}
+
+	// MENGECEK APAKAH IDENTITY PROVIDER SUDAH TERHUBUNG KE USER
+	identity, err := user_serv.identityRepository.GetIdentity(profile.Provider, profile.ProviderUserID)
+	if err == nil {
+		user, err := user_serv.userRepository.GetUserByID(identity.UserID.String())
+		if err != nil {
+			return "", err
+		}
+
+		// EMAIL DI PROVIDER BERUBAH, CUKUP PERBARUI DATA IDENTITY TANPA MEMBUAT AKUN BARU
+		if profile.Email != "" && identity.Email != profile.Email {
+			identity.Email = profile.Email
+			if _, err := user_serv.identityRepository.UpdateIdentity(identity); err != nil {
+				return "", err
+			}
+		}
+
+		return user_serv.tokenService.CreateAuthCode(user.ID.String())
+	}
+
+	if profile.Email == "" {
+		return "", errors.New("provider did not return an email address")
+	}
+
+	// MENGECEK APAKAH EMAIL SUDAH TERDAFTAR, HANYA DIHUBUNGKAN JIKA PROVIDER MENJAMIN EMAIL SUDAH TERVERIFIKASI
+	// DAN EMAIL AKUN LOKAL JUGA SUDAH TERVERIFIKASI. AKUN LOKAL YANG BELUM TERVERIFIKASI BISA SAJA DIDAFTARKAN
+	// ORANG LAIN SEBELUM PEMILIK EMAIL ASLI LOGIN LEWAT PROVIDER
+	user, err := user_serv.userRepository.GetUserByEmail(profile.Email)
+	if err == nil {
+		if !profile.EmailVerified || !user.EmailVerfiedAt.Valid {
+			user_serv.loginHistoryService.RecordFailure(user, data.LOGIN_METHOD_OAUTH, client, data.LOGIN_FAILURE_ACCOUNT_NOT_LINKED)
+			return "", errors.New("an account with this email already exists, please sign in and link this provider from your profile")
+		}
+	} else {
+		if profile.Name == "" {
+			profile.Name = profile.Email
+		}
+		user, err = user_serv.userRepository.CreateUser(model.Users{
+			Name:  profile.Name,
+			Email: profile.Email,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// MENANDAI EMAIL SUDAH TERVERIFIKASI JIKA DIJAMIN OLEH PROVIDER
+	if profile.EmailVerified && !user.EmailVerfiedAt.Valid {
+		user.EmailVerfiedAt = sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		}
+		if user, err = user_serv.userRepository.UpdateUser(user); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := user_serv.identityRepository.CreateIdentity(model.UserIdentities{
+		UserID:         user.ID,
+		Provider:       profile.Provider,
+		ProviderUserID: profile.ProviderUserID,
+		Email:          profile.Email,
+	}); err != nil {
+		return "", err
+	}
+
+	return user_serv.tokenService.CreateAuthCode(user.ID.String())
+}
+
+// ExchangeAuthCode menukar authorization code dari callback OAuth, login OAuth juga harus melewati 2FA jika aktif
+func (user_serv *usersService) ExchangeAuthCode(code string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	userID, err := user_serv.tokenService.ConsumeAuthCode(code)
 	if err != nil {
 		return nil, err
 	}
 
-	return &token, nil
+	user, err := user_serv.userRepository.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user_serv.twoFactorService.CompleteLogin(user, data.LOGIN_METHOD_OAUTH, client)
 }
 
 func (user_serv *usersService) GetAllUsers() ([]dto.UsersResponse, error) {
@@ -159,28 +275,17 @@ func (user_serv *usersService) UpdateUser(id string, userNew dto.UsersRequest) (
 		return dto.UsersResponse{}, err
 	}
 
-	// VALIDASI APAKAH FULLNAME & EMAIL KOSONG
-	if userNew.Name == "" && userNew.Email == "" {
-		return dto.UsersResponse{}, errors.New("fullname and email cannot be blank")
+	// VALIDASI APAKAH FULLNAME KOSONG
+	if userNew.Name == "" {
+		return dto.UsersResponse{}, errors.New("fullname cannot be blank")
 	}
 
-	// VALIDASI APAKAH FULLNAME / EMAIL SUDAH DI INPUT
-	if userNew.Name != "" {
-		user.Name = userNew.Name
+	// EMAIL HANYA BISA DIGANTI LEWAT FLOW KONFIRMASI DI /auth/email/change
+	if userNew.Email != "" && userNew.Email != user.Email {
+		return dto.UsersResponse{}, errors.New("email cannot be changed here, please use the change email flow")
 	}
 
-	if userNew.Email != "" {
-		// VALIDASI UNTUK FORMAT EMAIL SUDAH BENAR
-		if isValid := helper.EmailValidator(userNew.Email); !isValid {
-			return dto.UsersResponse{}, errors.New("please enter a valid email address")
-		}
-		// MENGECEK APAKAH EMAIL SUDAH DIGUNAKAN
-		existingUser, err := user_serv.userRepository.GetUserByEmail(userNew.Email)
-		if err == nil && existingUser.ID != user.ID {
-			return dto.UsersResponse{}, errors.New("email already in use by another user")
-		}
-		user.Email = userNew.Email
-	}
+	user.Name = userNew.Name
 
 	userUpdated, err := user_serv.userRepository.UpdateUser(user)
 	if err != nil {
@@ -192,11 +297,11 @@ func (user_serv *usersService) UpdateUser(id string, userNew dto.UsersRequest) (
 	return userResponse.(dto.UsersResponse), nil
 }
 
-func (user_serv *usersService) VerifyUser(email string) (dto.UsersResponse, error) {
-	// MENGAMBIL DATA YANG INGIN DI UPDATE
-	user, err := user_serv.userRepository.GetUserByEmail(email)
+func (user_serv *usersService) VerifyUser(email string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	// EMAIL DINORMALISASI SEPERTI SAAT OTP DIVALIDASI
+	user, err := user_serv.userRepository.GetUserByNormalizedEmail(normalizeEmail(email))
 	if err != nil {
-		return dto.UsersResponse{}, err
+		return nil, err
 	}
 
 	current := time.Now()
@@ -207,12 +312,10 @@ func (user_serv *usersService) VerifyUser(email string) (dto.UsersResponse, erro
 
 	userExist, err := user_serv.userRepository.UpdateUser(user)
 	if err != nil {
-		return dto.UsersResponse{}, err
+		return nil, err
 	}
 
-	userResponse := helper.ConvertToResponseType(userExist).(dto.UsersResponse)
-
-	return userResponse, nil
+	return user_serv.twoFactorService.CompleteLogin(userExist, data.LOGIN_METHOD_OTP, client)
 }
 
 func (user_serv *usersService) DeleteUser(id string) (dto.UsersResponse, error) {
@@ -227,7 +330,29 @@ func (user_serv *usersService) DeleteUser(id string) (dto.UsersResponse, error)
 		return dto.UsersResponse{}, err
 	}
 
+	// MENCABUT SELURUH TOKEN MILIK USER YANG DIHAPUS
+	if err := user_serv.tokenService.LogoutAll(userDeleted.ID.String()); err != nil {
+		return dto.UsersResponse{}, err
+	}
+
 	userResponse := helper.ConvertToResponseType(userDeleted)
 
 	return userResponse.(dto.UsersResponse), nil
 }
+
+// rehashPassword menyimpan ulang hash password dengan algoritma dan parameter saat ini. Jika gagal,
+// user lama dikembalikan agar login tetap berjalan dan rehash dicoba lagi pada login berikutnya.
+func (user_serv *usersService) rehashPassword(user model.Users, password string) (model.Users, error) {
+	hashedPassword, err := user_serv.passwordHasher.Hash(password)
+	if err != nil {
+		return user, err
+	}
+
+	rehashed := user
+	rehashed.Password = hashedPassword
+	if rehashed, err = user_serv.userRepository.UpdateUser(rehashed); err != nil {
+		return user, err
+	}
+
+	return rehashed, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (env *testEnv) verifyEmail(t *testing.T, user model.Users) model.Users {
+	t.Helper()
+
+	user.EmailVerfiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	user, err := env.userRepo.UpdateUser(user)
+	if err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	return user
+}
+
+func (env *testEnv) identityCount(t *testing.T, userID string) int {
+	t.Helper()
+
+	identities, err := env.identityRepo.GetIdentitiesByUserID(userID)
+	if err != nil {
+		t.Fatalf("GetIdentitiesByUserID: %v", err)
+	}
+
+	return len(identities)
+}
+
+func TestOAuthLoginCreatesVerifiedUser(t *testing.T) {
+	env := newTestEnv(t)
+
+	code, err := env.usersService.OAuthLogin(dto.OAuthProfile{
+		Provider:       "github",
+		ProviderUserID: "gh-1",
+		Email:          "new-oauth@example.com",
+		EmailVerified:  true,
+	}, testClient)
+	if err != nil {
+		t.Fatalf("OAuthLogin: %v", err)
+	}
+
+	user, err := env.userRepo.GetUserByEmail("new-oauth@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if !user.EmailVerfiedAt.Valid {
+		t.Fatal("email verified by the provider was not marked verified")
+	}
+	if user.Name != "new-oauth@example.com" {
+		t.Fatalf("name = %q, want the email as fallback", user.Name)
+	}
+
+	response, err := env.usersService.ExchangeAuthCode(code, testClient)
+	if err != nil {
+		t.Fatalf("ExchangeAuthCode: %v", err)
+	}
+	if response.Token == nil {
+		t.Fatal("ExchangeAuthCode returned no token")
+	}
+	if _, err := env.usersService.ExchangeAuthCode(code, testClient); err == nil {
+		t.Fatal("authorization code was accepted twice")
+	}
+}
+
+func TestOAuthLoginLinksVerifiedAccount(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.verifyEmail(t, env.createUser(t, "verified@example.com"))
+
+	if _, err := env.usersService.OAuthLogin(dto.OAuthProfile{
+		Provider:       "google",
+		ProviderUserID: "google-1",
+		Email:          "verified@example.com",
+		EmailVerified:  true,
+	}, testClient); err != nil {
+		t.Fatalf("OAuthLogin: %v", err)
+	}
+
+	if count := env.identityCount(t, user.ID.String()); count != 1 {
+		t.Fatalf("identity count = %d, want 1", count)
+	}
+}
+
+func TestOAuthLoginDoesNotLinkUnverifiedAccount(t *testing.T) {
+	env := newTestEnv(t)
+
+	// AKUN LOKAL DIDAFTARKAN DENGAN EMAIL KORBAN TANPA PERNAH DIVERIFIKASI
+	squatter := env.createUser(t, "victim@example.com")
+
+	if _, err := env.usersService.OAuthLogin(dto.OAuthProfile{
+		Provider:       "google",
+		ProviderUserID: "victim-google",
+		Email:          "victim@example.com",
+		EmailVerified:  true,
+	}, testClient); err == nil {
+		t.Fatal("OAuth identity was linked to an unverified local account")
+	}
+
+	if count := env.identityCount(t, squatter.ID.String()); count != 0 {
+		t.Fatalf("identity count = %d, want 0", count)
+	}
+	user, err := env.userRepo.GetUserByID(squatter.ID.String())
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.EmailVerfiedAt.Valid {
+		t.Fatal("unverified local account was marked verified")
+	}
+}
+
+func TestOAuthLoginDoesNotLinkUnverifiedProviderEmail(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.verifyEmail(t, env.createUser(t, "owner@example.com"))
+
+	if _, err := env.usersService.OAuthLogin(dto.OAuthProfile{
+		Provider:       "github",
+		ProviderUserID: "gh-unverified",
+		Email:          "owner@example.com",
+		EmailVerified:  false,
+	}, testClient); err == nil {
+		t.Fatal("OAuth identity with an unverified email was linked")
+	}
+
+	if count := env.identityCount(t, user.ID.String()); count != 0 {
+		t.Fatalf("identity count = %d, want 0", count)
+	}
+}
+
+func TestOAuthLoginExistingIdentityUpdatesEmail(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "identity@example.com")
+	if _, err := env.identityRepo.CreateIdentity(model.UserIdentities{
+		UserID:         user.ID,
+		Provider:       "github",
+		ProviderUserID: "gh-2",
+		Email:          "old@example.com",
+	}); err != nil {
+		t.Fatalf("CreateIdentity: %v", err)
+	}
+
+	code, err := env.usersService.OAuthLogin(dto.OAuthProfile{
+		Provider:       "github",
+		ProviderUserID: "gh-2",
+		Email:          "changed@example.com",
+	}, testClient)
+	if err != nil {
+		t.Fatalf("OAuthLogin: %v", err)
+	}
+
+	userID, err := env.tokenService.ConsumeAuthCode(code)
+	if err != nil || userID != user.ID.String() {
+		t.Fatalf("ConsumeAuthCode = %q, %v; want %q", userID, err, user.ID)
+	}
+	identity, err := env.identityRepo.GetIdentity("github", "gh-2")
+	if err != nil {
+		t.Fatalf("GetIdentity: %v", err)
+	}
+	if identity.Email != "changed@example.com" {
+		t.Fatalf("identity email = %q, want changed@example.com", identity.Email)
+	}
+	if count := env.identityCount(t, user.ID.String()); count != 1 {
+		t.Fatalf("identity count = %d, want 1", count)
+	}
+}
+
+func TestLoginRehashesLegacyPasswordHash(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "legacy@example.com")
+
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("GenerateFromPassword: %v", err)
+	}
+	user.Password = string(legacyHash)
+	if _, err := env.userRepo.UpdateUser(user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if _, err := env.usersService.Login(dto.UsersRequest{Email: user.Email, Password: testPassword}, testClient); err != nil {
+		t.Fatalf("Login with a legacy hash: %v", err)
+	}
+
+	rehashed := env.reloadUser(t, user.ID.String()).Password
+	if !strings.HasPrefix(rehashed, "$argon2id$") {
+		t.Fatalf("password hash = %q, want it upgraded to argon2id", rehashed)
+	}
+	if match, needsRehash := env.passwordHasher.Verify(rehashed, testPassword); !match || needsRehash {
+		t.Fatalf("Verify(rehashed) = %v, %v, want a current hash of the same password", match, needsRehash)
+	}
+}
+
+func TestVerifyUserNormalizesEmail(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "Verify@Example.com")
+
+	if _, err := env.usersService.VerifyUser("  verify@EXAMPLE.com ", testClient); err != nil {
+		t.Fatalf("VerifyUser with a differently cased email: %v", err)
+	}
+
+	if !env.reloadUser(t, user.ID.String()).EmailVerfiedAt.Valid {
+		t.Fatal("email_verified_at not set after VerifyUser")
+	}
+}
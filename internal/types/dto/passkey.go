@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type PasskeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	Synced     bool       `json:"synced"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// PasskeyRegisterRequest - Credential berisi response navigator.credentials.create() apa adanya
+type PasskeyRegisterRequest struct {
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyStepUpRequest - konfirmasi identitas sebelum registrasi passkey: kode TOTP untuk akun dengan 2FA,
+// password saat ini untuk akun dengan password, atau OTP email untuk akun tanpa keduanya
+type PasskeyStepUpRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+type PasskeyLoginOptionsResponse struct {
+	SessionID string                        `json:"session_id"`
+	Options   *protocol.CredentialAssertion `json:"options"`
+}
+
+// PasskeyLoginRequest - Credential berisi response navigator.credentials.get() apa adanya
+type PasskeyLoginRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
@@ -0,0 +1,86 @@
+package dto
+
+import "github.com/golang-jwt/jwt/v5"
+
+type JWTClaims struct {
+	UserID   string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	// SessionID - id session (refresh token family) tempat access token diterbitkan
+	SessionID string `json:"sid,omitempty"`
+	// IssuedAtMicro - waktu token diterbitkan dalam mikrodetik, dibandingkan dengan waktu logout all
+	IssuedAtMicro int64 `json:"iat_us,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ExchangeCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+}
+
+// LoginResponse - hasil login, berisi token atau challenge 2FA jika user mengaktifkan 2FA
+type LoginResponse struct {
+	Token     *TokenResponse
+	Challenge *TwoFactorChallengeResponse
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type TwoFactorRecoveryRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	RecoveryCode   string `json:"recovery_code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
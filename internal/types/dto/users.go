@@ -1,9 +1,12 @@
 package dto
 
+import "time"
+
 type UsersResponse struct {
 	ID    string `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Role  string `json:"role"`
 }
 
 type UsersRequest struct {
@@ -11,3 +14,87 @@ type UsersRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 }
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type OAuthProfile struct {
+	Provider       string
+	ProviderUserID string
+	Name           string
+	Email          string
+	EmailVerified  bool
+}
+
+type IdentityResponse struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type EmailTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ClientInfo - informasi perangkat yang melakukan request, diambil dari request HTTP
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	Device      string    `json:"device"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	LoginMethod string    `json:"login_method"`
+	Current     bool      `json:"current"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type LoginHistoryResponse struct {
+	ID            string    `json:"id"`
+	Device        string    `json:"device"`
+	UserAgent     string    `json:"user_agent"`
+	IPAddress     string    `json:"ip_address"`
+	LoginMethod   string    `json:"login_method"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,7 @@
+package model
+
+type EmailChange struct {
+	UserID   string `json:"user_id"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "github.com/google/uuid"
+
+type UserIdentities struct {
+	Base
+	UserID         uuid.UUID `gorm:"type:uuid;not null"`
+	Provider       string    `gorm:"type:varchar(50);not null"`
+	ProviderUserID string    `gorm:"type:varchar(255);not null"`
+	Email          string    `gorm:"type:varchar(100)"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import "github.com/google/uuid"
+
+// UserLoginHistories - satu percobaan login user, berhasil maupun gagal
+type UserLoginHistories struct {
+	Base
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	Device        string    `gorm:"type:varchar(100);not null;default:''"`
+	UserAgent     string    `gorm:"type:text;not null;default:''"`
+	IPAddress     string    `gorm:"type:varchar(45);not null;default:''"`
+	LoginMethod   string    `gorm:"type:varchar(20);not null"`
+	Success       bool      `gorm:"not null"`
+	FailureReason string    `gorm:"type:varchar(50);not null;default:''"`
+}
+
+// LoginReport - data link "this wasn't me" yang disimpan di Redis, LoginHistoryID menunjuk login yang dilaporkan
+type LoginReport struct {
+	UserID         string
+	LoginHistoryID string
+}
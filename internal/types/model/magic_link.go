@@ -0,0 +1,7 @@
+package model
+
+// MagicLink - data link login yang disimpan di Redis, BrowserBinding berisi hash cookie browser yang meminta link
+type MagicLink struct {
+	UserID         string `json:"user_id"`
+	BrowserBinding string `json:"browser_binding"`
+}
@@ -0,0 +1,15 @@
+package model
+
+const (
+	OAuthModeLogin = "login"
+	OAuthModeLink  = "link"
+)
+
+type OAuthState struct {
+	Provider       string `json:"provider"`
+	Mode           string `json:"mode"`
+	UserID         string `json:"user_id,omitempty"`
+	CodeVerifier   string `json:"code_verifier"`
+	Nonce          string `json:"nonce"`
+	BrowserBinding string `json:"browser_binding"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type OTPPurpose string
+
+const (
+	OTPPurposeVerifyEmail   OTPPurpose = "verify-email"
+	OTPPurposeLogin         OTPPurpose = "login"
+	OTPPurposePasswordReset OTPPurpose = "password-reset"
+	OTPPurposeStepUp        OTPPurpose = "step-up"
+)
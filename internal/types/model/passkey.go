@@ -0,0 +1,25 @@
+package model
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// UserPasskeys - credential WebAuthn (passkey) milik user. Transports disimpan dipisahkan koma.
+type UserPasskeys struct {
+	Base
+	UserID          uuid.UUID    `gorm:"type:uuid;not null"`
+	Name            string       `gorm:"type:varchar(100);not null"`
+	CredentialID    []byte       `gorm:"type:bytea;not null"`
+	PublicKey       []byte       `gorm:"type:bytea;not null"`
+	AttestationType string       `gorm:"type:varchar(50);not null;default:''"`
+	Transports      string       `gorm:"type:text;not null;default:''"`
+	AAGUID          []byte       `gorm:"column:aaguid;type:bytea"`
+	SignCount       uint32       `gorm:"type:bigint;not null;default:0"`
+	CloneWarning    bool         `gorm:"not null;default:false"`
+	UserVerified    bool         `gorm:"not null;default:false"`
+	BackupEligible  bool         `gorm:"not null;default:false"`
+	BackupState     bool         `gorm:"not null;default:false"`
+	LastUsedAt      sql.NullTime `gorm:"type:timestamp"`
+}
@@ -0,0 +1,10 @@
+package model
+
+import "github.com/google/uuid"
+
+// UserPasswordHistories - hash password lama user, dipakai untuk mencegah password yang sama dipakai ulang
+type UserPasswordHistories struct {
+	Base
+	UserID       uuid.UUID `gorm:"type:uuid;not null"`
+	PasswordHash string    `gorm:"type:varchar(255);not null"`
+}
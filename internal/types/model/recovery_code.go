@@ -0,0 +1,15 @@
+package model
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// UserRecoveryCodes - recovery code 2FA sekali pakai, hanya hash-nya yang disimpan
+type UserRecoveryCodes struct {
+	Base
+	UserID   uuid.UUID    `gorm:"type:uuid;not null"`
+	CodeHash string       `gorm:"type:varchar(64);not null"`
+	UsedAt   sql.NullTime `gorm:"type:timestamp"`
+}
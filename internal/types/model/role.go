@@ -0,0 +1,24 @@
+package model
+
+type Permission string
+
+const (
+	UsersRead   Permission = "users:read"
+	UsersUpdate Permission = "users:update"
+	UsersDelete Permission = "users:delete"
+	RolesAssign Permission = "roles:assign"
+	RolesManage Permission = "roles:manage"
+)
+
+type Roles struct {
+	Base
+	Name        string            `gorm:"type:varchar(100);unique;not null"`
+	Description string            `gorm:"type:varchar(255)"`
+	Permissions []RolePermissions `gorm:"foreignKey:Role;references:Name"`
+}
+
+type RolePermissions struct {
+	Base
+	Role       string `gorm:"type:varchar(100);not null"`
+	Permission string `gorm:"type:varchar(100);not null"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSessions - satu session login user. ID session sama dengan id refresh token family dan claim sid di access token.
+type UserSessions struct {
+	Base
+	UserID      uuid.UUID    `gorm:"type:uuid;not null"`
+	Device      string       `gorm:"type:varchar(100);not null;default:''"`
+	UserAgent   string       `gorm:"type:text;not null;default:''"`
+	IPAddress   string       `gorm:"type:varchar(45);not null;default:''"`
+	LoginMethod string       `gorm:"type:varchar(20);not null"`
+	LastSeenAt  time.Time    `gorm:"not null"`
+	ExpiresAt   time.Time    `gorm:"not null"`
+	RevokedAt   sql.NullTime `gorm:"type:timestamp"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+type SigningKeys struct {
+	Base
+	Kid         string       `gorm:"type:varchar(64);unique;not null"`
+	Algorithm   string       `gorm:"type:varchar(10);not null"`
+	PrivateKey  string       `gorm:"type:text;not null"`
+	PublicKey   string       `gorm:"type:text;not null"`
+	ActivatesAt time.Time    `gorm:"type:timestamptz;not null"`
+	RotatedAt   sql.NullTime `gorm:"type:timestamptz"`
+}
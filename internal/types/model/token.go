@@ -0,0 +1,7 @@
+package model
+
+type RefreshToken struct {
+	UserID   string
+	FamilyID string
+	Used     bool
+}
@@ -0,0 +1,7 @@
+package model
+
+// TwoFactorChallenge - login yang menunggu kode 2FA, LoginMethod adalah faktor pertama yang sudah berhasil
+type TwoFactorChallenge struct {
+	UserID      string
+	LoginMethod string
+}
@@ -13,7 +13,12 @@ type Users struct {
 	Base
 	Name           string       `gorm:"type:varchar(100);not null"`
 	Email          string       `gorm:"type:varchar(100);unique;not null"`
-	Password       string       `gorm:"type:varchar(100);not null"`
+	Password       string       `gorm:"type:varchar(255);not null"`
 	Role           string       `gorm:"type:varchar(100);not null;default:'user'"`
 	EmailVerfiedAt sql.NullTime `gorm:"type:timestamp"`
+	// TwoFactorSecret - secret TOTP yang dienkripsi, 2FA baru aktif setelah TwoFactorEnabledAt terisi
+	TwoFactorSecret    string       `gorm:"type:text;not null;default:''"`
+	TwoFactorEnabledAt sql.NullTime `gorm:"type:timestamp"`
+	// PasswordResetRequired - login dengan password ditolak sampai user mengganti password lewat link reset
+	PasswordResetRequired bool `gorm:"not null;default:false"`
 }
\ No newline at end of file
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"refina-auth/config/env"
+)
+
+// encryptionKey - key AES-256 diturunkan dari ENCRYPTION_KEY sehingga panjang nilai env bebas
+func encryptionKey() ([]byte, error) {
+	if env.Cfg.Server.EncryptionKey == "" {
+		return nil, errors.New("encryption key is not configured")
+	}
+
+	key := sha256.Sum256([]byte(env.Cfg.Server.EncryptionKey))
+	return key[:], nil
+}
+
+// Encrypt mengenkripsi data sensitif yang disimpan di database (misalnya secret TOTP) dengan AES-GCM.
+// Hasilnya berupa base64 dari nonce || ciphertext.
+func Encrypt(plaintext string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := cryptorand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func Decrypt(encoded string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// HashOTP - HMAC-SHA256 dengan OTP_SECRET_KEY. Ruang kode OTP sangat kecil sehingga SHA-256 biasa bisa
+// di-brute force dari isi Redis yang bocor, tanpa secret server hash tidak bisa dicocokkan secara offline.
+func HashOTP(value string) (string, error) {
+	if env.Cfg.Server.OTPSecretKey == "" {
+		return "", errors.New("otp secret key is not configured")
+	}
+
+	mac := hmac.New(sha256.New, []byte(env.Cfg.Server.OTPSecretKey))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
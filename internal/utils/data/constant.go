@@ -1,11 +1,30 @@
 package data
 
+import "time"
+
 var (
 	DEVELOPMENT_MODE = "development"
 	STAGING_MODE     = "staging"
 	PRODUCTION_MODE  = "production"
 )
 
+var (
+	JWT_ALGORITHM             = "HS256"
+	JWT_KEY_ROTATION_INTERVAL = 30 * 24 * time.Hour
+	ACCESS_TOKEN_TTL          = 15 * time.Minute
+	REFRESH_TOKEN_TTL         = 30 * 24 * time.Hour
+	JWKS_CACHE_MAX_AGE        = 5 * time.Minute
+)
+
+var (
+	TOKEN_DELIVERY_BODY   = "body"
+	TOKEN_DELIVERY_COOKIE = "cookie"
+	TOKEN_DELIVERY        = TOKEN_DELIVERY_BODY
+	ACCESS_TOKEN_COOKIE   = "token"
+	REFRESH_TOKEN_COOKIE  = "refresh_token"
+	AUTH_CODE_TTL         = time.Minute
+)
+
 type GitHubPlan struct {
 	Collaborators int    `json:"collaborators"`
 	Name          string `json:"name"`
@@ -61,3 +80,165 @@ type OTP struct {
 	Email string `json:"email"`
 	OTP   string `json:"otp"`
 }
+
+var (
+	OTP_LENGTH          = 6
+	OTP_MIN_LENGTH      = 4
+	OTP_MAX_LENGTH      = 10
+	OTP_TTL             = 5 * time.Minute
+	OTP_MAX_ATTEMPTS    = 5
+	OTP_RESEND_COOLDOWN = time.Minute
+	OTP_DAILY_LIMIT     = 10
+)
+
+var OAUTH_STATE_TTL = 10 * time.Minute
+
+var OAUTH_BINDING_COOKIE = "oauth_binding"
+
+var PASSWORD_RESET_TTL = 30 * time.Minute
+
+type PasswordResetEmail struct {
+	Name      string
+	URL       string
+	ExpiresIn int
+}
+
+var (
+	EMAIL_CHANGE_TTL      = 30 * time.Minute
+	EMAIL_CHANGE_UNDO_TTL = 7 * 24 * time.Hour
+)
+
+type EmailChangeEmail struct {
+	Name      string
+	OldEmail  string
+	NewEmail  string
+	URL       string
+	ExpiresIn int
+}
+
+var (
+	TOTP_ISSUER              = "Refina"
+	TOTP_PERIOD              = 30
+	TOTP_SKEW                = 1
+	TOTP_QR_CODE_SIZE        = 256
+	TWO_FACTOR_CHALLENGE_TTL = 5 * time.Minute
+	TWO_FACTOR_MAX_ATTEMPTS  = 5
+	TWO_FACTOR_LOCKOUT_TTL   = 15 * time.Minute
+)
+
+var RECOVERY_CODE_COUNT = 10
+
+type RecoveryCodeUsedEmail struct {
+	Name           string
+	RemainingCodes int64
+	URL            string
+}
+
+var (
+	WEBAUTHN_RP_ID          = "localhost"
+	WEBAUTHN_RP_NAME        = "Refina"
+	WEBAUTHN_SESSION_TTL    = 5 * time.Minute
+	PASSKEY_NAME_MAX_LENGTH = 100
+)
+
+type PasskeyAddedEmail struct {
+	Name        string
+	PasskeyName string
+	Device      string
+	IPAddress   string
+	Time        string
+	URL         string
+}
+
+var (
+	MAGIC_LINK_TTL             = 15 * time.Minute
+	MAGIC_LINK_BINDING_COOKIE  = "magic_link_binding"
+	MAGIC_LINK_RESEND_COOLDOWN = time.Minute
+	MAGIC_LINK_IP_COOLDOWN     = 10 * time.Second
+	MAGIC_LINK_DAILY_LIMIT     = 10
+)
+
+type MagicLinkEmail struct {
+	Name      string
+	URL       string
+	ExpiresIn int
+}
+
+var (
+	LOGIN_SCOPE_ACCOUNT        = "account"
+	LOGIN_SCOPE_IP             = "ip"
+	LOGIN_FAILURE_WINDOW       = 15 * time.Minute
+	LOGIN_BACKOFF_THRESHOLD    = 3
+	LOGIN_IP_BACKOFF_THRESHOLD = 20
+	LOGIN_BACKOFF_BASE         = time.Second
+	LOGIN_BACKOFF_MAX          = 5 * time.Minute
+	LOGIN_LOCKOUT_THRESHOLD    = 10
+	LOGIN_LOCKOUT_DURATION     = 30 * time.Minute
+)
+
+type AccountLockedEmail struct {
+	Name      string
+	URL       string
+	ExpiresIn int
+}
+
+var (
+	PASSWORD_HASHER_ARGON2ID    = "argon2id"
+	PASSWORD_HASHER_BCRYPT      = "bcrypt"
+	PASSWORD_HASHER             = PASSWORD_HASHER_ARGON2ID
+	PASSWORD_BCRYPT_COST        = 12
+	PASSWORD_ARGON2_MEMORY      = 64 * 1024 // KiB
+	PASSWORD_ARGON2_ITERATIONS  = 3
+	PASSWORD_ARGON2_PARALLELISM = 2
+	PASSWORD_ARGON2_SALT_LENGTH = 16
+	PASSWORD_ARGON2_KEY_LENGTH  = 32
+	PASSWORD_MIN_LENGTH         = 8
+	PASSWORD_MAX_LENGTH         = 128
+	PASSWORD_REQUIRE_LETTER     = true
+	PASSWORD_REQUIRE_DIGIT      = true
+	PASSWORD_REQUIRE_MIXED_CASE = false
+	PASSWORD_REQUIRE_SYMBOL     = false
+	PASSWORD_BAN_PERSONAL_INFO  = true
+	// PASSWORD_MIN_STRENGTH - skor zxcvbn 0-4, 0 menonaktifkan pengecekan
+	PASSWORD_MIN_STRENGTH   = 2
+	PASSWORD_BREACHED_CHECK = true
+	// PASSWORD_BREACHED_FILTER_FP - peluang password yang tidak pernah bocor ikut ditolak oleh bloom filter
+	PASSWORD_BREACHED_FILTER_FP    = 0.001
+	PASSWORD_PERSONAL_INFO_MIN_LEN = 3
+	// PASSWORD_HISTORY_DEPTH - jumlah password terakhir (termasuk password saat ini) yang tidak boleh dipakai ulang, 0 menonaktifkan
+	PASSWORD_HISTORY_DEPTH = 5
+)
+
+var (
+	LOGIN_METHOD_PASSWORD   = "password"
+	LOGIN_METHOD_OTP        = "otp"
+	LOGIN_METHOD_OAUTH      = "oauth"
+	LOGIN_METHOD_PASSKEY    = "passkey"
+	LOGIN_METHOD_MAGIC_LINK = "magic_link"
+)
+
+var (
+	LOGIN_FAILURE_INVALID_PASSWORD   = "invalid_password"
+	LOGIN_FAILURE_INVALID_2FA_CODE   = "invalid_2fa_code"
+	LOGIN_FAILURE_RESET_REQUIRED     = "password_reset_required"
+	LOGIN_FAILURE_INVALID_PASSKEY    = "invalid_passkey"
+	LOGIN_FAILURE_CLONED_PASSKEY     = "cloned_passkey"
+	LOGIN_FAILURE_BROWSER_MISMATCH   = "browser_mismatch"
+	LOGIN_FAILURE_ACCOUNT_NOT_LINKED = "account_not_linked"
+	LOGIN_HISTORY_LIMIT              = 50
+	LOGIN_REPORT_TTL                 = 7 * 24 * time.Hour
+	LOGIN_ALERT_TIME_FORMAT          = "02 Jan 2006 15:04 MST"
+	// LOGIN_REPORT_REVOKE_WINDOW - passkey dan identity OAuth yang ditambahkan sejak sebelum login yang dilaporkan
+	// ikut dicabut, termasuk identity yang terhubung saat flow OAuth dari login tersebut
+	LOGIN_REPORT_REVOKE_WINDOW = 10 * time.Minute
+)
+
+type NewDeviceLoginEmail struct {
+	Name        string
+	Device      string
+	IPAddress   string
+	LoginMethod string
+	Time        string
+	URL         string
+	ExpiresIn   int
+}
@@ -0,0 +1,61 @@
+package utils
+
+import "strings"
+
+// DeviceName membuat label perangkat yang mudah dibaca dari user agent, misalnya "Chrome on Windows"
+func DeviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, candidate := range []struct {
+		token string
+		name  string
+	}{
+		// URUTAN PENTING KARENA EDGE DAN OPERA JUGA MENGANDUNG "Chrome/", DAN CHROME MENGANDUNG "Safari/"
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp/", "Android app"},
+		{"Dart/", "Mobile app"},
+		{"curl/", "curl"},
+		{"PostmanRuntime/", "Postman"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, candidate := range []struct {
+		token string
+		name  string
+	}{
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Android", "Android"},
+		{"CrOS", "ChromeOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Macintosh", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			platform = candidate.name
+			break
+		}
+	}
+
+	if platform == "" {
+		return browser
+	}
+
+	return browser + " on " + platform
+}
@@ -0,0 +1,129 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"refina-auth/internal/utils/data"
+
+	"golang.org/x/crypto/argon2"
+)
+
+type Argon2idParams struct {
+	// Memory - dalam KiB
+	Memory      int
+	Iterations  int
+	Parallelism int
+	SaltLength  int
+	KeyLength   int
+}
+
+type argon2idAlgorithm struct {
+	params Argon2idParams
+}
+
+// NewArgon2id membuat algoritma Argon2id, hash disimpan dalam format PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func NewArgon2id(params Argon2idParams) (Algorithm, error) {
+	if params.Memory < 8*params.Parallelism || params.Iterations < 1 || params.Parallelism < 1 || params.Parallelism > 255 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+	if params.SaltLength < 8 || params.KeyLength < 16 {
+		return nil, errors.New("argon2id salt must be at least 8 bytes and key at least 16 bytes")
+	}
+
+	return &argon2idAlgorithm{params: params}, nil
+}
+
+func (algorithm *argon2idAlgorithm) Name() string {
+	return data.PASSWORD_HASHER_ARGON2ID
+}
+
+func (algorithm *argon2idAlgorithm) Hash(password string) (string, error) {
+	salt := make([]byte, algorithm.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, uint32(algorithm.params.Iterations), uint32(algorithm.params.Memory), uint8(algorithm.params.Parallelism), uint32(algorithm.params.KeyLength))
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		algorithm.params.Memory,
+		algorithm.params.Iterations,
+		algorithm.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (algorithm *argon2idAlgorithm) Verify(encoded string, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	// PARAMETER DIAMBIL DARI HASH, BUKAN DARI KONFIGURASI SEKARANG
+	other := argon2.IDKey([]byte(password), salt, uint32(params.Iterations), uint32(params.Memory), uint8(params.Parallelism), uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (algorithm *argon2idAlgorithm) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (algorithm *argon2idAlgorithm) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < algorithm.params.Memory ||
+		params.Iterations < algorithm.params.Iterations ||
+		params.Parallelism < algorithm.params.Parallelism ||
+		params.SaltLength < algorithm.params.SaltLength ||
+		params.KeyLength < algorithm.params.KeyLength
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+	if params.Iterations < 1 || params.Parallelism < 1 || params.Parallelism > 255 || params.Memory < 1 {
+		return params, nil, nil, errors.New("invalid argon2id hash parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.SaltLength = len(salt)
+	params.KeyLength = len(key)
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,63 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"refina-auth/internal/utils/data"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type bcryptAlgorithm struct {
+	cost int
+}
+
+// NewBcrypt membuat algoritma bcrypt. Format modular crypt bcrypt ($2a$<cost>$...) sudah memuat cost,
+// sehingga hash lama dengan cost rendah bisa dikenali dan diganti.
+func NewBcrypt(cost int) (Algorithm, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &bcryptAlgorithm{cost: cost}, nil
+}
+
+func (algorithm *bcryptAlgorithm) Name() string {
+	return data.PASSWORD_HASHER_BCRYPT
+}
+
+func (algorithm *bcryptAlgorithm) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), algorithm.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (algorithm *bcryptAlgorithm) Verify(encoded string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (algorithm *bcryptAlgorithm) Identify(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (algorithm *bcryptAlgorithm) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+
+	return err != nil || cost < algorithm.cost
+}
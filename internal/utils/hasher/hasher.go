@@ -0,0 +1,91 @@
+package hasher
+
+import (
+	"fmt"
+	"strings"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/utils/data"
+)
+
+// Algorithm - satu algoritma hash password. Hash disimpan dalam format encoded yang memuat nama algoritma
+// dan parameternya, sehingga hash lama tetap bisa diverifikasi setelah konfigurasi berubah.
+type Algorithm interface {
+	Name() string
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) (bool, error)
+	// Identify - apakah encoded hash dibuat oleh algoritma ini
+	Identify(encoded string) bool
+	// NeedsRehash - apakah parameter hash lebih lemah dari parameter yang dikonfigurasi
+	NeedsRehash(encoded string) bool
+}
+
+// Hasher - hash baru selalu memakai algoritma default, verifikasi mendukung semua algoritma yang terdaftar
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded string, password string) (match bool, needsRehash bool)
+}
+
+type hasher struct {
+	current    Algorithm
+	algorithms []Algorithm
+}
+
+// New membuat hasher dengan Argon2id dan bcrypt terdaftar, algoritma default dipilih dari PASSWORD_HASHER
+func New(config env.Password) (Hasher, error) {
+	argon2id, err := NewArgon2id(Argon2idParams{
+		Memory:      config.Argon2Memory,
+		Iterations:  config.Argon2Iterations,
+		Parallelism: config.Argon2Parallelism,
+		SaltLength:  data.PASSWORD_ARGON2_SALT_LENGTH,
+		KeyLength:   data.PASSWORD_ARGON2_KEY_LENGTH,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bcrypt, err := NewBcrypt(config.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHasher(strings.ToLower(config.Hasher), argon2id, bcrypt)
+}
+
+// NewHasher membuat hasher dari daftar algoritma, algoritma bernama current dipakai untuk hash baru
+func NewHasher(current string, algorithms ...Algorithm) (Hasher, error) {
+	hasher := &hasher{algorithms: algorithms}
+	for _, algorithm := range algorithms {
+		if algorithm.Name() == current {
+			hasher.current = algorithm
+		}
+	}
+
+	if hasher.current == nil {
+		return nil, fmt.Errorf("password hasher %q is not supported", current)
+	}
+
+	return hasher, nil
+}
+
+func (hasher *hasher) Hash(password string) (string, error) {
+	return hasher.current.Hash(password)
+}
+
+func (hasher *hasher) Verify(encoded string, password string) (bool, bool) {
+	for _, algorithm := range hasher.algorithms {
+		if !algorithm.Identify(encoded) {
+			continue
+		}
+
+		match, err := algorithm.Verify(encoded, password)
+		if err != nil || !match {
+			return false, false
+		}
+
+		// HASH DARI ALGORITMA LAIN ATAU DENGAN PARAMETER LEMAH DIGANTI SAAT PASSWORD DIKETAHUI
+		return true, algorithm != hasher.current || algorithm.NeedsRehash(encoded)
+	}
+
+	return false, false
+}
@@ -0,0 +1,149 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"refina-auth/internal/utils/data"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2id(t *testing.T, memory int, iterations int) Algorithm {
+	t.Helper()
+
+	algorithm, err := NewArgon2id(Argon2idParams{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: 1,
+		SaltLength:  data.PASSWORD_ARGON2_SALT_LENGTH,
+		KeyLength:   data.PASSWORD_ARGON2_KEY_LENGTH,
+	})
+	if err != nil {
+		t.Fatalf("NewArgon2id: %v", err)
+	}
+
+	return algorithm
+}
+
+func testBcrypt(t *testing.T, cost int) Algorithm {
+	t.Helper()
+
+	algorithm, err := NewBcrypt(cost)
+	if err != nil {
+		t.Fatalf("NewBcrypt: %v", err)
+	}
+
+	return algorithm
+}
+
+func TestArgon2idHashIsEncodedAndSalted(t *testing.T) {
+	algorithm := testArgon2id(t, 1024, 1)
+
+	first, err := algorithm.Hash("secret-password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	second, err := algorithm.Hash("secret-password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("hash = %q, want PHC format with parameters", first)
+	}
+	if first == second {
+		t.Fatal("two hashes of the same password are equal, salt is not random")
+	}
+
+	for _, test := range []struct {
+		password string
+		want     bool
+	}{
+		{"secret-password", true},
+		{"secret-passwore", false},
+		{"", false},
+	} {
+		if match, err := algorithm.Verify(first, test.password); err != nil || match != test.want {
+			t.Fatalf("Verify(%q) = %v, %v, want %v", test.password, match, err, test.want)
+		}
+	}
+}
+
+func TestArgon2idRejectsMalformedHash(t *testing.T) {
+	algorithm := testArgon2id(t, 1024, 1)
+
+	for _, encoded := range []string{
+		"",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHQ$a2V5a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5a2V5a2V5a2V5a2V5",
+	} {
+		if match, err := algorithm.Verify(encoded, "secret-password"); match || err == nil {
+			t.Fatalf("Verify(%q) = %v, %v, want an error", encoded, match, err)
+		}
+	}
+}
+
+func TestNewArgon2idValidatesParams(t *testing.T) {
+	for _, params := range []Argon2idParams{
+		{Memory: 1024, Iterations: 0, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 4, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+		{Memory: 1024, Iterations: 1, Parallelism: 0, SaltLength: 16, KeyLength: 32},
+		{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 4, KeyLength: 32},
+		{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 8},
+	} {
+		if _, err := NewArgon2id(params); err == nil {
+			t.Fatalf("NewArgon2id(%+v) accepted invalid parameters", params)
+		}
+	}
+
+	if _, err := NewBcrypt(bcrypt.MaxCost + 1); err == nil {
+		t.Fatal("NewBcrypt accepted a cost above the maximum")
+	}
+}
+
+func TestHasherVerifiesLegacyHashesAndFlagsRehash(t *testing.T) {
+	hasher, err := NewHasher(data.PASSWORD_HASHER_ARGON2ID, testArgon2id(t, 2048, 2), testBcrypt(t, 6))
+	if err != nil {
+		t.Fatalf("NewHasher: %v", err)
+	}
+
+	current, err := hasher.Hash("secret-password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	weakArgon2id, _ := testArgon2id(t, 1024, 1).Hash("secret-password")
+	legacyBcrypt, _ := testBcrypt(t, bcrypt.MinCost).Hash("secret-password")
+
+	tests := []struct {
+		name        string
+		encoded     string
+		password    string
+		match       bool
+		needsRehash bool
+	}{
+		{"current hash", current, "secret-password", true, false},
+		{"weaker argon2id parameters", weakArgon2id, "secret-password", true, true},
+		{"legacy bcrypt hash", legacyBcrypt, "secret-password", true, true},
+		{"wrong password never needs rehash", legacyBcrypt, "wrong-password", false, false},
+		{"unknown format", "plaintext", "plaintext", false, false},
+		{"empty hash", "", "", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, needsRehash := hasher.Verify(test.encoded, test.password)
+			if match != test.match || needsRehash != test.needsRehash {
+				t.Fatalf("Verify = %v, %v, want %v, %v", match, needsRehash, test.match, test.needsRehash)
+			}
+		})
+	}
+}
+
+func TestNewHasherRejectsUnknownAlgorithm(t *testing.T) {
+	if _, err := NewHasher("md5", testArgon2id(t, 1024, 1), testBcrypt(t, bcrypt.MinCost)); err == nil {
+		t.Fatal("NewHasher accepted an unregistered algorithm")
+	}
+}
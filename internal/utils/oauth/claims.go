@@ -0,0 +1,196 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	helper "refina-auth/internal/utils"
+)
+
+// OIDCClaims - claim id_token atau respon userinfo yang dapat dipakai untuk membentuk profil user
+type OIDCClaims struct {
+	Subject           claimString `json:"sub"`
+	ID                claimString `json:"id"`
+	ObjectID          claimString `json:"oid"`
+	TenantID          claimString `json:"tid"`
+	Email             claimString `json:"email"`
+	EmailVerified     claimBool   `json:"email_verified"`
+	Verified          claimBool   `json:"verified"`
+	Name              claimString `json:"name"`
+	PreferredUsername claimString `json:"preferred_username"`
+	GlobalName        claimString `json:"global_name"`
+	Username          claimString `json:"username"`
+	Login             claimString `json:"login"`
+}
+
+// stringClaim - nilai claim berdasarkan nama claim-nya, ok bernilai false jika claim tidak didukung
+func (claims OIDCClaims) stringClaim(name string) (value string, ok bool) {
+	switch name {
+	case "sub":
+		return string(claims.Subject), true
+	case "id":
+		return string(claims.ID), true
+	case "oid":
+		return string(claims.ObjectID), true
+	case "tid":
+		return string(claims.TenantID), true
+	case "email":
+		return string(claims.Email), true
+	case "name":
+		return string(claims.Name), true
+	case "preferred_username":
+		return string(claims.PreferredUsername), true
+	case "global_name":
+		return string(claims.GlobalName), true
+	case "username":
+		return string(claims.Username), true
+	case "login":
+		return string(claims.Login), true
+	}
+
+	return "", false
+}
+
+// boolClaim - claim boolean berdasarkan nama claim-nya, ok bernilai false jika claim tidak didukung
+func (claims OIDCClaims) boolClaim(name string) (value claimBool, ok bool) {
+	switch name {
+	case "email_verified":
+		return claims.EmailVerified, true
+	case "verified":
+		return claims.Verified, true
+	}
+
+	return claimBool{}, false
+}
+
+// ClaimMapping - nama claim yang dipakai untuk membentuk profil user. Beberapa claim dapat dipisahkan koma,
+// claim pertama yang berisi nilai akan dipakai.
+type ClaimMapping struct {
+	ID            string
+	Email         string
+	EmailVerified string
+	Name          string
+}
+
+func newClaimMapping(defaults ClaimMapping, id string, email string, emailVerified string, name string) ClaimMapping {
+	mapping := defaults
+	if id != "" {
+		mapping.ID = id
+	}
+	if email != "" {
+		mapping.Email = email
+	}
+	if emailVerified != "" {
+		mapping.EmailVerified = emailVerified
+	}
+	if name != "" {
+		mapping.Name = name
+	}
+
+	return mapping
+}
+
+// validateClaimMapping memastikan claim yang dikonfigurasi lewat OAUTH_<NAME>_CLAIM_* didukung oleh OIDCClaims
+func validateClaimMapping(config env.OAuthProvider) error {
+	var claims OIDCClaims
+
+	for _, key := range splitClaims(config.ClaimID + "," + config.ClaimEmail + "," + config.ClaimName) {
+		if _, ok := claims.stringClaim(key); !ok {
+			return fmt.Errorf("oauth provider %s: unsupported claim %q", config.Name, key)
+		}
+	}
+	for _, key := range splitClaims(config.ClaimEmailVerified) {
+		if _, ok := claims.boolClaim(key); !ok {
+			return fmt.Errorf("oauth provider %s: unsupported claim %q", config.Name, key)
+		}
+	}
+
+	return nil
+}
+
+func (mapping ClaimMapping) Profile(provider string, claims OIDCClaims) dto.OAuthProfile {
+	profile := dto.OAuthProfile{
+		Provider:       provider,
+		ProviderUserID: firstClaim(claims, mapping.ID, nil),
+		Name:           firstClaim(claims, mapping.Name, nil),
+		Email:          firstClaim(claims, mapping.Email, helper.EmailValidator),
+	}
+
+	// Email hanya dianggap terverifikasi jika provider secara eksplisit menyatakannya
+	for _, key := range splitClaims(mapping.EmailVerified) {
+		if verified, _ := claims.boolClaim(key); verified.valid {
+			profile.EmailVerified = verified.value
+			break
+		}
+	}
+
+	return profile
+}
+
+func splitClaims(keys string) []string {
+	var result []string
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
+
+func firstClaim(claims OIDCClaims, keys string, valid func(string) bool) string {
+	for _, key := range splitClaims(keys) {
+		value, _ := claims.stringClaim(key)
+		if value != "" && (valid == nil || valid(value)) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// claimString - claim teks yang juga menerima angka (mis. id numerik GitHub/Discord) tanpa kehilangan presisi
+type claimString string
+
+func (claim *claimString) UnmarshalJSON(raw []byte) error {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		*claim = claimString(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err == nil {
+		*claim = claimString(number.String())
+	}
+
+	// TIPE LAIN (OBJECT, ARRAY, BOOLEAN) DIANGGAP KOSONG
+	return nil
+}
+
+// claimBool - claim boolean yang juga menerima teks "true"/"false", valid bernilai false jika claim tidak dikirim
+type claimBool struct {
+	value bool
+	valid bool
+}
+
+func (claim *claimBool) UnmarshalJSON(raw []byte) error {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil && string(raw) != "null" {
+		*claim = claimBool{value: value, valid: true}
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if value, err := strconv.ParseBool(text); err == nil {
+			*claim = claimBool{value: value, valid: true}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package oauth
+
+import (
+	"context"
+	"strconv"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// githubProvider - GitHub tidak mendukung OIDC dan email utama hanya tersedia dari endpoint /user/emails
+type githubProvider struct {
+	name   string
+	config *oauth2.Config
+}
+
+func newGithubProvider(config env.OAuthProvider, oauthConfig *oauth2.Config) *githubProvider {
+	if len(oauthConfig.Scopes) == 0 {
+		oauthConfig.Scopes = []string{"read:user", "user:email"}
+	}
+	if oauthConfig.Endpoint.AuthURL == "" || oauthConfig.Endpoint.TokenURL == "" {
+		oauthConfig.Endpoint = github.Endpoint
+	}
+
+	return &githubProvider{
+		name:   config.Name,
+		config: oauthConfig,
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return p.name
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state string, oauthState model.OAuthState) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(oauthState.CodeVerifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+}
+
+func (p *githubProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (dto.OAuthProfile, error) {
+	client := p.config.Client(ctx, token)
+
+	var githubUser data.GitHubUser
+	if err := getJSON(ctx, client, "https://api.github.com/user", &githubUser); err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, "https://api.github.com/user/emails", &emails); err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	profile := dto.OAuthProfile{
+		Provider:       p.name,
+		ProviderUserID: strconv.FormatInt(int64(githubUser.ID), 10),
+		Name:           githubUser.Name,
+	}
+	if profile.Name == "" {
+		profile.Name = githubUser.Login
+	}
+
+	// Pilih email utama (primary)
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = email.Email
+			profile.EmailVerified = email.Verified
+			break
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+
+	"golang.org/x/oauth2"
+)
+
+// oauth2Provider - provider OAuth2 biasa (tanpa id_token), misalnya Discord. Profil user diambil dari
+// endpoint userinfo lalu dipetakan sesuai konfigurasi claim.
+type oauth2Provider struct {
+	name        string
+	userInfoURL string
+	claims      ClaimMapping
+	config      *oauth2.Config
+}
+
+func newOAuth2Provider(config env.OAuthProvider, oauthConfig *oauth2.Config) *oauth2Provider {
+	return &oauth2Provider{
+		name:        config.Name,
+		userInfoURL: config.UserInfoURL,
+		claims: newClaimMapping(ClaimMapping{
+			ID:            "sub,id",
+			Email:         "email",
+			EmailVerified: "email_verified,verified",
+			Name:          "name,username,login",
+		}, config.ClaimID, config.ClaimEmail, config.ClaimEmailVerified, config.ClaimName),
+		config: oauthConfig,
+	}
+}
+
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+func (p *oauth2Provider) AuthCodeURL(ctx context.Context, state string, oauthState model.OAuthState) (string, error) {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(oauthState.CodeVerifier)), nil
+}
+
+func (p *oauth2Provider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+}
+
+func (p *oauth2Provider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (dto.OAuthProfile, error) {
+	var claims OIDCClaims
+	if err := getJSON(ctx, p.config.Client(ctx, token), p.userInfoURL, &claims); err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	return p.claims.Profile(p.name, claims), nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s: %s", url, resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+
+	return decoder.Decode(result)
+}
@@ -0,0 +1,144 @@
+package oauth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcProvider - provider OpenID Connect generik. Endpoint diambil dari discovery document issuer dan
+// profil user dibentuk dari id_token yang sudah divalidasi. Discovery dilakukan saat pertama kali dipakai lalu di-cache.
+type oidcProvider struct {
+	name         string
+	issuer       string
+	issuerFormat string
+	claims       ClaimMapping
+
+	mu       sync.Mutex
+	config   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+func newOIDCProvider(config env.OAuthProvider, oauthConfig *oauth2.Config) *oidcProvider {
+	if len(oauthConfig.Scopes) == 0 {
+		oauthConfig.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &oidcProvider{
+		name:         config.Name,
+		issuer:       config.Issuer,
+		issuerFormat: config.IssuerFormat,
+		claims: newClaimMapping(ClaimMapping{
+			ID:            "sub",
+			Email:         "email",
+			EmailVerified: "email_verified",
+			Name:          "name,preferred_username",
+		}, config.ClaimID, config.ClaimEmail, config.ClaimEmailVerified, config.ClaimName),
+		config: oauthConfig,
+	}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.verifier != nil {
+		return p.config, p.verifier, nil
+	}
+
+	// Issuer multi tenant (misalnya Microsoft "common") memiliki nilai iss yang berbeda per tenant.
+	// issuerFormat berisi placeholder {tenantid} yang diganti dengan claim tid saat validasi.
+	if p.issuerFormat != "" {
+		ctx = oidc.InsecureIssuerURLContext(ctx, p.issuerFormat)
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Endpoint dari config tetap diutamakan jika diisi
+	endpoint := provider.Endpoint()
+	if p.config.Endpoint.AuthURL != "" {
+		endpoint.AuthURL = p.config.Endpoint.AuthURL
+	}
+	if p.config.Endpoint.TokenURL != "" {
+		endpoint.TokenURL = p.config.Endpoint.TokenURL
+	}
+	p.config.Endpoint = endpoint
+
+	p.verifier = provider.Verifier(&oidc.Config{
+		ClientID:        p.config.ClientID,
+		SkipIssuerCheck: p.issuerFormat != "",
+	})
+
+	return p.config, p.verifier, nil
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, oauthState model.OAuthState) (string, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(oauthState.CodeVerifier), oidc.Nonce(oauthState.Nonce)), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, codeVerifier string) (*oauth2.Token, error) {
+	config, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+}
+
+// FetchProfile memvalidasi signature, audience, issuer, expiry dan nonce id_token lalu memetakan claim-nya
+func (p *oidcProvider) FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (dto.OAuthProfile, error) {
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return dto.OAuthProfile{}, errors.New("id_token not found in token response")
+	}
+
+	_, verifier, err := p.discover(ctx)
+	if err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		return dto.OAuthProfile{}, errors.New("id_token nonce mismatch")
+	}
+
+	var claims OIDCClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return dto.OAuthProfile{}, err
+	}
+
+	// ISSUER MULTI TENANT HARUS SESUAI DENGAN TENANT DI DALAM TOKEN
+	if p.issuerFormat != "" {
+		tenantID := string(claims.TenantID)
+		if tenantID == "" || idToken.Issuer != strings.ReplaceAll(p.issuerFormat, "{tenantid}", tenantID) {
+			return dto.OAuthProfile{}, errors.New("id_token issuer mismatch")
+		}
+	}
+
+	return p.claims.Profile(p.name, claims), nil
+}
@@ -0,0 +1,311 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/testutil"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const testClientID = "refina-client"
+
+// fakeIssuer - issuer OIDC lokal: discovery document, JWKS dan token endpoint yang mengembalikan id_token
+// dari claims yang diatur test
+type fakeIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// issuer - nilai "issuer" di discovery document, default URL server
+	issuer string
+
+	mu           sync.Mutex
+	idToken      string
+	codeVerifier string
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	issuer := &fakeIssuer{key: key}
+	mux := http.NewServeMux()
+	discovery := func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                issuer.issuer,
+			"authorization_endpoint":                issuer.server.URL + "/authorize",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"jwks_uri":                              issuer.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	}
+	mux.HandleFunc("/.well-known/openid-configuration", discovery)
+	mux.HandleFunc("/common/.well-known/openid-configuration", discovery)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+
+		issuer.codeVerifier = r.FormValue("code_verifier")
+		writeJSON(w, map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     issuer.idToken,
+		})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	issuer.issuer = issuer.server.URL
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// sign menandatangani claims dengan key issuer, atau dengan key lain untuk menguji signature palsu
+func (issuer *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	t.Helper()
+
+	if key == nil {
+		key = issuer.key
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign id_token: %v", err)
+	}
+
+	return signed
+}
+
+func (issuer *fakeIssuer) claims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            issuer.issuer,
+		"aud":            testClientID,
+		"sub":            "oidc-user-1",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          nonce,
+		"email":          "oidc@example.com",
+		"email_verified": true,
+		"name":           "OIDC User",
+	}
+}
+
+func newTestOIDCProvider(t *testing.T, config env.OAuthProvider) Provider {
+	t.Helper()
+	testutil.Config(t)
+
+	config.Name = "fakeoidc"
+	config.Type = ProviderTypeOIDC
+	config.ClientID = testClientID
+	config.ClientSecret = "secret"
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	return provider
+}
+
+// login menjalankan flow lengkap: URL login, token exchange lalu validasi id_token
+func login(t *testing.T, provider Provider, issuer *fakeIssuer, idToken func(nonce string) string) (dto.OAuthProfile, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	oauthState := model.OAuthState{CodeVerifier: oauth2.GenerateVerifier(), Nonce: "expected-nonce"}
+	authURL, err := provider.AuthCodeURL(ctx, "state", oauthState)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	query := parsed.Query()
+	if query.Get("nonce") != oauthState.Nonce {
+		t.Fatalf("auth url nonce = %q, want %q", query.Get("nonce"), oauthState.Nonce)
+	}
+	if query.Get("code_challenge") != oauth2.S256ChallengeFromVerifier(oauthState.CodeVerifier) || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("auth url has no S256 code challenge: %s", authURL)
+	}
+
+	issuer.mu.Lock()
+	issuer.idToken = idToken(oauthState.Nonce)
+	issuer.mu.Unlock()
+
+	token, err := provider.Exchange(ctx, "code", oauthState.CodeVerifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if issuer.codeVerifier != oauthState.CodeVerifier {
+		t.Fatalf("token endpoint received verifier %q, want %q", issuer.codeVerifier, oauthState.CodeVerifier)
+	}
+
+	return provider.FetchProfile(ctx, token, oauthState.Nonce)
+}
+
+func TestOIDCProviderValidatesIDToken(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := newTestOIDCProvider(t, env.OAuthProvider{Issuer: issuer.server.URL})
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(claims jwt.MapClaims)
+		key     *rsa.PrivateKey
+		wantErr bool
+	}{
+		{name: "valid id_token"},
+		{name: "signed with an unknown key", key: otherKey, wantErr: true},
+		{name: "wrong issuer", modify: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example" }, wantErr: true},
+		{name: "wrong audience", modify: func(claims jwt.MapClaims) { claims["aud"] = "another-client" }, wantErr: true},
+		{name: "wrong nonce", modify: func(claims jwt.MapClaims) { claims["nonce"] = "replayed-nonce" }, wantErr: true},
+		{name: "missing nonce", modify: func(claims jwt.MapClaims) { delete(claims, "nonce") }, wantErr: true},
+		{name: "expired", modify: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() }, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile, err := login(t, provider, issuer, func(nonce string) string {
+				claims := issuer.claims(nonce)
+				if test.modify != nil {
+					test.modify(claims)
+				}
+				return issuer.sign(t, claims, test.key)
+			})
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("FetchProfile accepted an invalid id_token: %+v", profile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchProfile: %v", err)
+			}
+
+			want := dto.OAuthProfile{Provider: "fakeoidc", ProviderUserID: "oidc-user-1", Name: "OIDC User", Email: "oidc@example.com", EmailVerified: true}
+			if profile != want {
+				t.Fatalf("profile = %+v, want %+v", profile, want)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderEmailVerified(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := newTestOIDCProvider(t, env.OAuthProvider{Issuer: issuer.server.URL})
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{"boolean true", true, true},
+		{"boolean false", false, false},
+		{"string true", "true", true},
+		{"string false", "false", false},
+		{"missing", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			profile, err := login(t, provider, issuer, func(nonce string) string {
+				claims := issuer.claims(nonce)
+				if test.value == nil {
+					delete(claims, "email_verified")
+				} else {
+					claims["email_verified"] = test.value
+				}
+				return issuer.sign(t, claims, nil)
+			})
+			if err != nil {
+				t.Fatalf("FetchProfile: %v", err)
+			}
+			if profile.EmailVerified != test.want {
+				t.Fatalf("EmailVerified = %v, want %v", profile.EmailVerified, test.want)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderMultiTenantIssuer(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	issuer.issuer = issuer.server.URL + "/{tenantid}"
+	provider := newTestOIDCProvider(t, env.OAuthProvider{
+		Issuer:       issuer.server.URL + "/common",
+		IssuerFormat: issuer.server.URL + "/{tenantid}",
+	})
+
+	tests := []struct {
+		name    string
+		iss     string
+		tid     string
+		wantErr bool
+	}{
+		{"issuer of the token tenant", issuer.server.URL + "/tenant-a", "tenant-a", false},
+		{"issuer of another tenant", issuer.server.URL + "/tenant-b", "tenant-a", true},
+		{"missing tenant", issuer.server.URL + "/tenant-a", "", true},
+		{"unrelated issuer", "https://evil.example/tenant-a", "tenant-a", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := login(t, provider, issuer, func(nonce string) string {
+				claims := issuer.claims(nonce)
+				claims["iss"] = test.iss
+				if test.tid != "" {
+					claims["tid"] = test.tid
+				}
+				return issuer.sign(t, claims, nil)
+			})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("FetchProfile error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderRequiresIDToken(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := newTestOIDCProvider(t, env.OAuthProvider{Issuer: issuer.server.URL})
+
+	if _, err := provider.FetchProfile(context.Background(), &oauth2.Token{AccessToken: "access-token"}, "nonce"); err == nil || !strings.Contains(err.Error(), "id_token") {
+		t.Fatalf("FetchProfile without id_token = %v, want an id_token error", err)
+	}
+}
@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/data"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	ProviderTypeOIDC   = "oidc"
+	ProviderTypeOAuth2 = "oauth2"
+	ProviderTypeGithub = "github"
+)
+
+// Provider - satu provider OAuth2/OIDC: membuat URL login, menukar authorization code dan mengambil profil user
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state string, oauthState model.OAuthState) (string, error)
+	Exchange(ctx context.Context, code string, codeVerifier string) (*oauth2.Token, error)
+	FetchProfile(ctx context.Context, token *oauth2.Token, nonce string) (dto.OAuthProfile, error)
+}
+
+type Registry interface {
+	Get(name string) (Provider, error)
+}
+
+type registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry membuat registry dari provider bawaan (Google, GitHub, Microsoft) yang client id-nya diisi,
+// lalu provider dari config OAUTH_PROVIDERS. Provider config dengan nama yang sama akan menimpa provider bawaan.
+func NewRegistry(configs []env.OAuthProvider) (Registry, error) {
+	registry := &registry{providers: map[string]Provider{}}
+
+	for _, config := range append(builtinProviders(), configs...) {
+		provider, err := NewProvider(config)
+		if err != nil {
+			return nil, err
+		}
+		registry.providers[provider.Name()] = provider
+	}
+
+	return registry, nil
+}
+
+func (registry *registry) Get(name string) (Provider, error) {
+	provider, ok := registry.providers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("oauth provider %q is not configured", name)
+	}
+
+	return provider, nil
+}
+
+func NewProvider(config env.OAuthProvider) (Provider, error) {
+	config.Name = strings.ToLower(strings.TrimSpace(config.Name))
+	if config.Name == "" {
+		return nil, errors.New("oauth provider name cannot be blank")
+	}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("oauth provider %s: client id cannot be blank", config.Name)
+	}
+	if err := validateClaimMapping(config); err != nil {
+		return nil, err
+	}
+
+	oauthConfig := &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  CallbackURL(config.Name),
+		Scopes:       config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  config.AuthURL,
+			TokenURL: config.TokenURL,
+		},
+	}
+
+	switch strings.ToLower(config.Type) {
+	case ProviderTypeOIDC, "":
+		if config.Issuer == "" {
+			return nil, fmt.Errorf("oauth provider %s: issuer cannot be blank", config.Name)
+		}
+		return newOIDCProvider(config, oauthConfig), nil
+	case ProviderTypeOAuth2:
+		if config.AuthURL == "" || config.TokenURL == "" || config.UserInfoURL == "" {
+			return nil, fmt.Errorf("oauth provider %s: auth, token and userinfo url are required", config.Name)
+		}
+		return newOAuth2Provider(config, oauthConfig), nil
+	case ProviderTypeGithub:
+		return newGithubProvider(config, oauthConfig), nil
+	}
+
+	return nil, fmt.Errorf("oauth provider %s: unsupported type %q", config.Name, config.Type)
+}
+
+// CallbackURL - URL callback yang harus didaftarkan di provider, mengikuti mode server
+func CallbackURL(name string) string {
+	if env.Cfg.Server.Mode == data.STAGING_MODE || env.Cfg.Server.Mode == data.PRODUCTION_MODE {
+		return env.Cfg.Client.Url + "/v1/auth/callback/" + name
+	}
+
+	return "http://localhost:" + env.Cfg.Client.Port + "/v1/auth/callback/" + name
+}
+
+// builtinProviders - provider lama yang dikonfigurasi lewat env GOOGLE_*, GITHUB_* dan MICROSOFT_*
+func builtinProviders() []env.OAuthProvider {
+	var providers []env.OAuthProvider
+
+	if env.Cfg.OAuth.Google.GOClientID != "" {
+		providers = append(providers, env.OAuthProvider{
+			Name:         "google",
+			Type:         ProviderTypeOIDC,
+			ClientID:     env.Cfg.OAuth.Google.GOClientID,
+			ClientSecret: env.Cfg.OAuth.Google.GOClientSecret,
+			Issuer:       "https://accounts.google.com",
+		})
+	}
+
+	if env.Cfg.OAuth.Github.GHClientID != "" {
+		providers = append(providers, env.OAuthProvider{
+			Name:         "github",
+			Type:         ProviderTypeGithub,
+			ClientID:     env.Cfg.OAuth.Github.GHClientID,
+			ClientSecret: env.Cfg.OAuth.Github.GHClientSecret,
+		})
+	}
+
+	if env.Cfg.OAuth.Microsoft.MSClientID != "" {
+		// Endpoint "common" menerima akun dari tenant manapun, sehingga issuer divalidasi berdasarkan claim tid.
+		// oid sama dengan id di Microsoft Graph dan email bersifat opsional, gunakan preferred_username sebagai cadangan.
+		providers = append(providers, env.OAuthProvider{
+			Name:         "microsoft",
+			Type:         ProviderTypeOIDC,
+			ClientID:     env.Cfg.OAuth.Microsoft.MSClientID,
+			ClientSecret: env.Cfg.OAuth.Microsoft.MSClientSecret,
+			Issuer:       "https://login.microsoftonline.com/common/v2.0",
+			IssuerFormat: "https://login.microsoftonline.com/{tenantid}/v2.0",
+			ClaimID:      "oid,sub",
+			ClaimEmail:   "email,preferred_username",
+		})
+	}
+
+	return providers
+}
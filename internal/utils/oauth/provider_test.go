@@ -0,0 +1,173 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/types/model"
+	"refina-auth/internal/utils/testutil"
+
+	"golang.org/x/oauth2"
+)
+
+func TestNewProviderValidatesConfig(t *testing.T) {
+	testutil.Config(t)
+
+	tests := []struct {
+		name   string
+		config env.OAuthProvider
+	}{
+		{"blank name", env.OAuthProvider{ClientID: "id", Issuer: "https://issuer.example"}},
+		{"blank client id", env.OAuthProvider{Name: "keycloak", Issuer: "https://issuer.example"}},
+		{"oidc without issuer", env.OAuthProvider{Name: "keycloak", ClientID: "id"}},
+		{"oauth2 without userinfo url", env.OAuthProvider{Name: "discord", Type: ProviderTypeOAuth2, ClientID: "id", AuthURL: "https://a.example", TokenURL: "https://t.example"}},
+		{"unsupported type", env.OAuthProvider{Name: "saml", Type: "saml", ClientID: "id"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewProvider(test.config); err == nil {
+				t.Fatal("NewProvider accepted an invalid config")
+			}
+		})
+	}
+}
+
+func TestRegistryProvidersFromConfig(t *testing.T) {
+	testutil.Config(t)
+	env.Cfg.OAuth.Github.GHClientID = "builtin-github"
+
+	registry, err := NewRegistry([]env.OAuthProvider{
+		{Name: "GitLab", Type: ProviderTypeOIDC, ClientID: "gitlab-id", Issuer: "https://gitlab.example"},
+		{Name: "github", Type: ProviderTypeOAuth2, ClientID: "override", AuthURL: "https://a.example", TokenURL: "https://t.example", UserInfoURL: "https://u.example"},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	// NAMA PROVIDER TIDAK CASE SENSITIVE
+	provider, err := registry.Get("gitlab")
+	if err != nil || provider.Name() != "gitlab" {
+		t.Fatalf("Get(gitlab) = %v, %v", provider, err)
+	}
+
+	// PROVIDER DARI CONFIG MENIMPA PROVIDER BAWAAN DENGAN NAMA YANG SAMA
+	provider, err = registry.Get("GitHub")
+	if err != nil {
+		t.Fatalf("Get(GitHub): %v", err)
+	}
+	if _, ok := provider.(*oauth2Provider); !ok {
+		t.Fatalf("github provider = %T, want the configured oauth2 provider", provider)
+	}
+
+	if _, err := registry.Get("google"); err == nil {
+		t.Fatal("provider without a client id was registered")
+	}
+}
+
+func TestOAuth2ProviderMapsConfiguredClaims(t *testing.T) {
+	testutil.Config(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "discord-token", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer discord-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 80351110224678912, "username": "nelly", "global_name": "Nelly", "email": "nelly@example.com", "verified": true}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	provider, err := NewProvider(env.OAuthProvider{
+		Name:               "discord",
+		Type:               ProviderTypeOAuth2,
+		ClientID:           "discord-id",
+		AuthURL:            server.URL + "/authorize",
+		TokenURL:           server.URL + "/token",
+		UserInfoURL:        server.URL + "/users/@me",
+		ClaimName:          "global_name,username",
+		ClaimEmailVerified: "verified",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", testOAuthState())
+	if err != nil || !strings.HasPrefix(authURL, server.URL+"/authorize") || !strings.Contains(authURL, "code_challenge=") {
+		t.Fatalf("AuthCodeURL = %q, %v", authURL, err)
+	}
+
+	token, err := provider.Exchange(context.Background(), "code", oauth2.GenerateVerifier())
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	profile, err := provider.FetchProfile(context.Background(), token, "")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+
+	// ID BESAR TIDAK BOLEH BERUBAH MENJADI NOTASI FLOAT
+	want := dto.OAuthProfile{Provider: "discord", ProviderUserID: "80351110224678912", Name: "Nelly", Email: "nelly@example.com", EmailVerified: true}
+	if profile != want {
+		t.Fatalf("profile = %+v, want %+v", profile, want)
+	}
+}
+
+func TestClaimMappingIgnoresInvalidEmail(t *testing.T) {
+	mapping := ClaimMapping{ID: "sub", Email: "email,preferred_username", EmailVerified: "email_verified", Name: "name"}
+
+	profile := mapping.Profile("microsoft", OIDCClaims{Subject: "user", PreferredUsername: "not-an-email"})
+	if profile.Email != "" {
+		t.Fatalf("email = %q, want empty for a non-email claim", profile.Email)
+	}
+
+	profile = mapping.Profile("microsoft", OIDCClaims{Subject: "user", PreferredUsername: "user@contoso.example"})
+	if profile.Email != "user@contoso.example" || profile.EmailVerified {
+		t.Fatalf("profile = %+v, want fallback email without verification", profile)
+	}
+}
+
+func TestOIDCClaimsDecodesLooselyTypedClaims(t *testing.T) {
+	var claims OIDCClaims
+	raw := `{"sub": "user", "id": 583231, "email": "user@example.com", "email_verified": "true", "verified": null, "name": {"first": "User"}}`
+	if err := json.Unmarshal([]byte(raw), &claims); err != nil {
+		t.Fatalf("unmarshal claims: %v", err)
+	}
+
+	mapping := ClaimMapping{ID: "id", Email: "email", EmailVerified: "verified,email_verified", Name: "name,sub"}
+	want := dto.OAuthProfile{Provider: "custom", ProviderUserID: "583231", Name: "user", Email: "user@example.com", EmailVerified: true}
+	if profile := mapping.Profile("custom", claims); profile != want {
+		t.Fatalf("profile = %+v, want %+v", profile, want)
+	}
+}
+
+func TestNewProviderRejectsUnsupportedClaim(t *testing.T) {
+	_, err := NewProvider(env.OAuthProvider{
+		Name:        "custom",
+		Type:        ProviderTypeOAuth2,
+		ClientID:    "client",
+		AuthURL:     "https://custom.example/authorize",
+		TokenURL:    "https://custom.example/token",
+		UserInfoURL: "https://custom.example/userinfo",
+		ClaimName:   "nickname",
+	})
+	if err == nil {
+		t.Fatal("expected unsupported claim to be rejected")
+	}
+}
+
+func testOAuthState() model.OAuthState {
+	return model.OAuthState{CodeVerifier: oauth2.GenerateVerifier(), Nonce: "nonce"}
+}
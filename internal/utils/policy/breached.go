@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+// defaultBreachedFilter - bloom filter dari config/password/common-passwords.txt, dibuat ulang dengan `make breached-filter`.
+// Hanya fallback untuk development, produksi memakai filter dari dump Pwned Passwords lewat PASSWORD_BREACHED_FILTER.
+//
+//go:embed breached.bloom
+var defaultBreachedFilter []byte
+
+// BreachedList - daftar password yang pernah bocor, dicek sepenuhnya offline
+type BreachedList interface {
+	Contains(password string) bool
+}
+
+type bloomBreachedList struct {
+	filter *bloom.BloomFilter
+}
+
+// NewBreachedList memuat bloom filter dari path, atau filter bawaan jika path kosong. Filter berisi SHA-1
+// uppercase dari password sehingga bisa dibangun dari dump hash Pwned Passwords tanpa mengetahui password aslinya.
+func NewBreachedList(path string) (BreachedList, error) {
+	var reader io.Reader = bytes.NewReader(defaultBreachedFilter)
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open breached password filter: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	filter := &bloom.BloomFilter{}
+	if _, err := filter.ReadFrom(reader); err != nil {
+		return nil, fmt.Errorf("failed to read breached password filter: %w", err)
+	}
+
+	return &bloomBreachedList{filter: filter}, nil
+}
+
+// Contains bisa false positive sesuai rate filter, tetapi tidak pernah false negative
+func (list *bloomBreachedList) Contains(password string) bool {
+	return list.filter.TestString(BreachedKey(password))
+}
+
+// BreachedKey - format key di dalam filter, sama dengan format hash Pwned Passwords
+func BreachedKey(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"refina-auth/internal/utils/data"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+func TestDefaultBreachedListContainsEveryCommonPassword(t *testing.T) {
+	list, err := NewBreachedList("")
+	if err != nil {
+		t.Fatalf("NewBreachedList: %v", err)
+	}
+
+	// FILTER BAWAAN HARUS DIBUAT ULANG SETIAP KALI common-passwords.txt BERUBAH
+	file, err := os.Open("../../../config/password/common-passwords.txt")
+	if err != nil {
+		t.Fatalf("open common passwords: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if password := scanner.Text(); password != "" && !strings.HasPrefix(password, "#") && !list.Contains(password) {
+			t.Fatalf("built-in filter is missing %q, run `make breached-filter`", password)
+		}
+	}
+
+	if list.Contains("Correct-Horse-Battery-42") {
+		t.Fatal("built-in filter contains an uncommon passphrase")
+	}
+}
+
+func TestBreachedListFalsePositiveRate(t *testing.T) {
+	const entries = 20000
+
+	filter := bloom.NewWithEstimates(entries, data.PASSWORD_BREACHED_FILTER_FP)
+	for i := 0; i < entries; i++ {
+		filter.AddString(BreachedKey("breached-" + strconv.Itoa(i)))
+	}
+
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create filter: %v", err)
+	}
+	if _, err := filter.WriteTo(file); err != nil {
+		t.Fatalf("write filter: %v", err)
+	}
+	file.Close()
+
+	list, err := NewBreachedList(path)
+	if err != nil {
+		t.Fatalf("NewBreachedList: %v", err)
+	}
+
+	// TIDAK PERNAH FALSE NEGATIVE
+	for i := 0; i < entries; i++ {
+		if !list.Contains("breached-" + strconv.Itoa(i)) {
+			t.Fatalf("filter is missing breached-%d", i)
+		}
+	}
+
+	falsePositives := 0
+	const probes = 100000
+	for i := 0; i < probes; i++ {
+		if list.Contains("not-breached-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / probes; rate > 2*data.PASSWORD_BREACHED_FILTER_FP {
+		t.Fatalf("false positive rate = %.4f, want about %.4f", rate, data.PASSWORD_BREACHED_FILTER_FP)
+	}
+}
+
+func TestNewBreachedListRejectsInvalidFilter(t *testing.T) {
+	if _, err := NewBreachedList(filepath.Join(t.TempDir(), "missing.bloom")); err == nil {
+		t.Fatal("NewBreachedList accepted a missing file")
+	}
+
+	path := filepath.Join(t.TempDir(), "corrupt.bloom")
+	if err := os.WriteFile(path, []byte("not a bloom filter"), 0o600); err != nil {
+		t.Fatalf("write filter: %v", err)
+	}
+	if _, err := NewBreachedList(path); err == nil {
+		t.Fatal("NewBreachedList accepted a corrupt filter")
+	}
+}
+
+func TestBreachedKeyMatchesPwnedPasswordsFormat(t *testing.T) {
+	// SHA-1("password") SEPERTI DI DUMP PWNED PASSWORDS
+	if key := BreachedKey("password"); key != "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8" {
+		t.Fatalf("BreachedKey = %s", key)
+	}
+}
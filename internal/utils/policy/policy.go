@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/types/dto"
+	"refina-auth/internal/utils/data"
+
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+const (
+	CodeBlank            = "password_blank"
+	CodeTooShort         = "password_too_short"
+	CodeTooLong          = "password_too_long"
+	CodeMissingLetter    = "password_missing_letter"
+	CodeMissingDigit     = "password_missing_digit"
+	CodeMissingMixedCase = "password_missing_mixed_case"
+	CodeMissingSymbol    = "password_missing_symbol"
+	CodeContainsPersonal = "password_contains_personal_info"
+	CodeTooWeak          = "password_too_weak"
+	CodeBreached         = "password_breached"
+	CodeReused           = "password_reused"
+)
+
+// ViolationError - seluruh aturan yang dilanggar password, dikembalikan sekaligus agar user bisa memperbaiki semuanya
+type ViolationError struct {
+	Violations []dto.PasswordViolation
+}
+
+func (err *ViolationError) Error() string {
+	messages := make([]string, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return "password does not meet the requirements: " + strings.Join(messages, ", ")
+}
+
+type Policy interface {
+	// Validate mengecek password terhadap seluruh aturan. personalInfo berisi nama dan email user
+	// yang tidak boleh dipakai di dalam password.
+	Validate(password string, personalInfo ...string) error
+}
+
+type policy struct {
+	config   env.Password
+	breached BreachedList
+}
+
+// New membuat policy dari konfigurasi PASSWORD_*. Breached list dimuat dari PASSWORD_BREACHED_FILTER,
+// atau dari bloom filter bawaan jika path kosong.
+func New(config env.Password) (Policy, error) {
+	if config.MinLength < 1 || config.MaxLength < config.MinLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be at least 1 and not greater than PASSWORD_MAX_LENGTH")
+	}
+	if config.MinStrength < 0 || config.MinStrength > 4 {
+		return nil, fmt.Errorf("PASSWORD_MIN_STRENGTH must be between 0 and 4")
+	}
+
+	policy := &policy{config: config}
+	if config.BreachedCheck {
+		// FILTER BAWAAN HANYA BERISI RATUSAN PASSWORD PALING UMUM, BUKAN DAFTAR PASSWORD YANG BOCOR
+		if config.BreachedFilter == "" {
+			log.Warn("PASSWORD_BREACHED_FILTER is not set, only the built-in list of common passwords is checked. Build a filter from the Pwned Passwords dump with `make breached-filter-hibp`")
+		}
+		breached, err := NewBreachedList(config.BreachedFilter)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+
+	return policy, nil
+}
+
+func (policy *policy) Validate(password string, personalInfo ...string) error {
+	if strings.TrimSpace(password) == "" {
+		return &ViolationError{Violations: []dto.PasswordViolation{
+			{Code: CodeBlank, Message: "password cannot be blank"},
+		}}
+	}
+
+	var violations []dto.PasswordViolation
+	add := func(code string, message string) {
+		violations = append(violations, dto.PasswordViolation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < policy.config.MinLength {
+		add(CodeTooShort, fmt.Sprintf("password must be at least %d characters long", policy.config.MinLength))
+	}
+	if length > policy.config.MaxLength {
+		add(CodeTooLong, fmt.Sprintf("password must be at most %d characters long", policy.config.MaxLength))
+	}
+
+	var hasLetter, hasDigit, hasUpper, hasLower, hasSymbol bool
+	for _, char := range password {
+		switch {
+		case unicode.IsLetter(char):
+			hasLetter = true
+			hasUpper = hasUpper || unicode.IsUpper(char)
+			hasLower = hasLower || unicode.IsLower(char)
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char) || unicode.IsSpace(char):
+			hasSymbol = true
+		}
+	}
+	if policy.config.RequireLetter && !hasLetter {
+		add(CodeMissingLetter, "password must contain at least one letter")
+	}
+	if policy.config.RequireDigit && !hasDigit {
+		add(CodeMissingDigit, "password must contain at least one number")
+	}
+	if policy.config.RequireMixedCase && !(hasUpper && hasLower) {
+		add(CodeMissingMixedCase, "password must contain both uppercase and lowercase letters")
+	}
+	if policy.config.RequireSymbol && !hasSymbol {
+		add(CodeMissingSymbol, "password must contain at least one symbol")
+	}
+
+	userInputs := personalTokens(personalInfo)
+	if policy.config.BanPersonalInfo && containsAny(strings.ToLower(password), userInputs) {
+		add(CodeContainsPersonal, "password must not contain your name or email")
+	}
+
+	// ZXCVBN MAHAL UNTUK INPUT PANJANG, PASSWORD YANG MELEBIHI MAX LENGTH SUDAH DITOLAK
+	if policy.config.MinStrength > 0 && length <= policy.config.MaxLength {
+		if score := zxcvbn.PasswordStrength(password, userInputs).Score; score < policy.config.MinStrength {
+			add(CodeTooWeak, "password is too easy to guess, try a longer passphrase or uncommon words")
+		}
+	}
+
+	if policy.breached != nil && policy.breached.Contains(password) {
+		add(CodeBreached, "password has appeared in a data breach, please choose a different one")
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// personalTokens memecah nama dan email menjadi bagian yang cukup panjang untuk dicari di dalam password
+func personalTokens(personalInfo []string) []string {
+	var tokens []string
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		if local, _, ok := strings.Cut(info, "@"); ok {
+			info = local
+		}
+
+		parts := strings.FieldsFunc(info, func(char rune) bool {
+			return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+		})
+		for _, part := range append(parts, strings.Join(parts, "")) {
+			if utf8.RuneCountInString(part) >= data.PASSWORD_PERSONAL_INFO_MIN_LEN {
+				tokens = append(tokens, part)
+			}
+		}
+	}
+
+	return tokens
+}
+
+func containsAny(password string, tokens []string) bool {
+	for _, token := range tokens {
+		if strings.Contains(password, token) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,99 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"refina-auth/config/env"
+	"refina-auth/internal/utils/testutil"
+)
+
+func violationCodes(t *testing.T, err error) map[string]bool {
+	t.Helper()
+
+	codes := map[string]bool{}
+	if err == nil {
+		return codes
+	}
+
+	var violationErr *ViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("error = %v, want *ViolationError", err)
+	}
+	for _, violation := range violationErr.Violations {
+		codes[violation.Code] = true
+	}
+
+	return codes
+}
+
+func TestPolicyReportsEveryViolation(t *testing.T) {
+	testutil.Config(t)
+	config := env.Cfg.Password
+	config.RequireMixedCase = true
+	config.RequireSymbol = true
+
+	policy, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		want     []string
+	}{
+		{"blank", "   ", nil, []string{CodeBlank}},
+		{"short and simple", "abc", nil, []string{CodeTooShort, CodeMissingDigit, CodeMissingMixedCase, CodeMissingSymbol, CodeTooWeak}},
+		{"common password", "password", nil, []string{CodeMissingDigit, CodeMissingMixedCase, CodeMissingSymbol, CodeTooWeak, CodeBreached}},
+		{"personal info", "Nelly-Refina-2024!", []string{"Nelly Kurnia", "nelly@example.com"}, []string{CodeContainsPersonal}},
+		{"valid", "Correct-Horse-Battery-42", []string{"Nelly", "nelly@example.com"}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			codes := violationCodes(t, policy.Validate(test.password, test.personal...))
+			for _, code := range test.want {
+				if !codes[code] {
+					t.Fatalf("violations = %v, want %s", codes, code)
+				}
+			}
+			if len(codes) != len(test.want) {
+				t.Fatalf("violations = %v, want none", codes)
+			}
+		})
+	}
+}
+
+func TestPolicyBreachedCheckCanBeDisabled(t *testing.T) {
+	testutil.Config(t)
+	config := env.Cfg.Password
+	config.BreachedCheck = false
+	config.MinStrength = 0
+
+	policy, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if codes := violationCodes(t, policy.Validate("password123")); codes[CodeBreached] {
+		t.Fatal("breached password was rejected with PASSWORD_BREACHED_CHECK disabled")
+	}
+}
+
+func TestNewPolicyValidatesConfig(t *testing.T) {
+	testutil.Config(t)
+
+	for _, mutate := range []func(*env.Password){
+		func(config *env.Password) { config.MinLength = 0 },
+		func(config *env.Password) { config.MaxLength = config.MinLength - 1 },
+		func(config *env.Password) { config.MinStrength = 5 },
+		func(config *env.Password) { config.BreachedFilter = "/nonexistent/breached.bloom" },
+	} {
+		config := env.Cfg.Password
+		mutate(&config)
+		if _, err := New(config); err == nil {
+			t.Fatalf("New accepted invalid config %+v", config)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// tokenSize - 256 bit entropi untuk token opaque
+	tokenSize = 32
+	// recoveryCodeAlphabet - tanpa karakter yang mudah tertukar (0/O, 1/I/L)
+	recoveryCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	recoveryCodeGroup    = 5
+)
+
+// NumericCode membuat kode numerik dengan panjang tertentu, setiap digit terdistribusi merata
+func NumericCode(length int) (string, error) {
+	if length <= 0 {
+		return "", errors.New("code length must be greater than zero")
+	}
+
+	return randomString("0123456789", length)
+}
+
+// Token membuat token opaque URL-safe (base64url tanpa padding)
+func Token() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// RecoveryCodes membuat sejumlah recovery code dengan format XXXXX-XXXXX
+func RecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, 0, count)
+	for len(codes) < count {
+		first, err := randomString(recoveryCodeAlphabet, recoveryCodeGroup)
+		if err != nil {
+			return nil, err
+		}
+		second, err := randomString(recoveryCodeAlphabet, recoveryCodeGroup)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, first+"-"+second)
+	}
+
+	return codes, nil
+}
+
+// NormalizeRecoveryCode menyamakan format input user (huruf kecil, spasi, tanpa tanda hubung) dengan format recovery code
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != recoveryCodeGroup*2 {
+		return code
+	}
+
+	return code[:recoveryCodeGroup] + "-" + code[recoveryCodeGroup:]
+}
+
+func randomString(alphabet string, length int) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+
+	var builder strings.Builder
+	builder.Grow(length)
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(alphabet[n.Int64()])
+	}
+
+	return builder.String(), nil
+}
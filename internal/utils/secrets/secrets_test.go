@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"regexp"
+	"testing"
+)
+
+func TestNumericCode(t *testing.T) {
+	for _, length := range []int{4, 6, 10} {
+		code, err := NumericCode(length)
+		if err != nil {
+			t.Fatalf("NumericCode(%d): %v", length, err)
+		}
+		if !regexp.MustCompile(`^[0-9]+$`).MatchString(code) || len(code) != length {
+			t.Fatalf("NumericCode(%d) = %q", length, code)
+		}
+	}
+
+	if _, err := NumericCode(0); err == nil {
+		t.Fatal("NumericCode(0) = nil error")
+	}
+}
+
+func TestNumericCodeDigitDistribution(t *testing.T) {
+	// SETIAP DIGIT HARUS MUNCUL, DAN TIDAK ADA DIGIT YANG JAUH LEBIH SERING (MODULO BIAS)
+	counts := make(map[rune]int)
+	for i := 0; i < 2000; i++ {
+		code, err := NumericCode(10)
+		if err != nil {
+			t.Fatalf("NumericCode: %v", err)
+		}
+		for _, digit := range code {
+			counts[digit]++
+		}
+	}
+
+	for digit := '0'; digit <= '9'; digit++ {
+		if counts[digit] < 1600 || counts[digit] > 2400 {
+			t.Fatalf("digit %c appeared %d times in 20000 digits", digit, counts[digit])
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		token, err := Token()
+		if err != nil {
+			t.Fatalf("Token: %v", err)
+		}
+
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(raw) != tokenSize {
+			t.Fatalf("Token() = %q is not %d bytes of base64url", token, tokenSize)
+		}
+		if seen[token] {
+			t.Fatalf("Token() repeated %q", token)
+		}
+		seen[token] = true
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := RecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("RecoveryCodes: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Fatalf("got %d codes, want 10", len(codes))
+	}
+
+	format := regexp.MustCompile(`^[` + recoveryCodeAlphabet + `]{5}-[` + recoveryCodeAlphabet + `]{5}$`)
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Fatalf("recovery code %q has an unexpected format", code)
+		}
+		if seen[code] {
+			t.Fatalf("recovery code %q repeated", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"ABCDE-FGHJK", "ABCDE-FGHJK"},
+		{"abcde-fghjk", "ABCDE-FGHJK"},
+		{"abcdefghjk", "ABCDE-FGHJK"},
+		{" abcde fghjk ", "ABCDE-FGHJK"},
+		{"abc", "ABC"},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeRecoveryCode(test.input); got != test.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
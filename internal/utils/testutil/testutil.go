@@ -0,0 +1,66 @@
+// Package testutil berisi konfigurasi bersama untuk test. Hanya boleh di-import dari file _test.go,
+// fixture database SQLite dan miniredis berada di file _test.go package yang memakainya.
+package testutil
+
+import (
+	"io"
+	"testing"
+
+	"refina-auth/config/env"
+	"refina-auth/config/log"
+	"refina-auth/internal/utils/data"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config mengisi env.Cfg dengan konfigurasi test dan mengembalikan konfigurasi sebelumnya setelah test selesai.
+// Parameter argon2 diperkecil agar hashing password di test tetap cepat.
+func Config(t testing.TB) {
+	t.Helper()
+
+	previous := env.Cfg
+	t.Cleanup(func() { env.Cfg = previous })
+
+	if log.Log == nil {
+		log.Log = logrus.New()
+		log.Log.SetOutput(io.Discard)
+	}
+
+	env.Cfg = env.Config{
+		Server: env.Server{
+			Mode:          data.DEVELOPMENT_MODE,
+			JWTSecretKey:  "test-jwt-secret",
+			EncryptionKey: "test-encryption-key",
+			OTPSecretKey:  "test-otp-secret",
+		},
+		JWT: env.JWT{
+			Algorithm:           "HS256",
+			KeyRotationInterval: data.JWT_KEY_ROTATION_INTERVAL,
+			AccessTokenTTL:      data.ACCESS_TOKEN_TTL,
+			RefreshTokenTTL:     data.REFRESH_TOKEN_TTL,
+			TokenDelivery:       data.TOKEN_DELIVERY_BODY,
+		},
+		OTP: env.OTP{Length: data.OTP_LENGTH},
+		Password: env.Password{
+			Hasher:            data.PASSWORD_HASHER_ARGON2ID,
+			BcryptCost:        4,
+			Argon2Memory:      1024,
+			Argon2Iterations:  1,
+			Argon2Parallelism: 1,
+			MinLength:         data.PASSWORD_MIN_LENGTH,
+			MaxLength:         data.PASSWORD_MAX_LENGTH,
+			RequireLetter:     data.PASSWORD_REQUIRE_LETTER,
+			RequireDigit:      data.PASSWORD_REQUIRE_DIGIT,
+			BanPersonalInfo:   data.PASSWORD_BAN_PERSONAL_INFO,
+			MinStrength:       data.PASSWORD_MIN_STRENGTH,
+			HistoryDepth:      data.PASSWORD_HISTORY_DEPTH,
+			BreachedCheck:     data.PASSWORD_BREACHED_CHECK,
+		},
+		Client: env.Client{Url: "http://localhost:3000"},
+		WebAuthn: env.WebAuthn{
+			RPID:          "localhost",
+			RPDisplayName: data.WEBAUTHN_RP_NAME,
+			RPOrigins:     []string{"http://localhost:3000"},
+		},
+	}
+}
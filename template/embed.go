@@ -5,6 +5,38 @@ import _ "embed"
 //go:embed otp-email-template.html
 var otpEmailTemplate string
 
+//go:embed password-reset-email-template.html
+var passwordResetEmailTemplate string
+
+//go:embed email-change-confirm-email-template.html
+var emailChangeConfirmEmailTemplate string
+
+//go:embed email-change-notice-email-template.html
+var emailChangeNoticeEmailTemplate string
+
+//go:embed recovery-code-used-email-template.html
+var recoveryCodeUsedEmailTemplate string
+
+//go:embed magic-link-email-template.html
+var magicLinkEmailTemplate string
+
+//go:embed account-locked-email-template.html
+var accountLockedEmailTemplate string
+
+//go:embed new-device-login-email-template.html
+var newDeviceLoginEmailTemplate string
+
+//go:embed passkey-added-email-template.html
+var passkeyAddedEmailTemplate string
+
 var Template = map[string]string{
-	"otp-email-template.html": otpEmailTemplate,
+	"otp-email-template.html":                  otpEmailTemplate,
+	"password-reset-email-template.html":       passwordResetEmailTemplate,
+	"email-change-confirm-email-template.html": emailChangeConfirmEmailTemplate,
+	"email-change-notice-email-template.html":  emailChangeNoticeEmailTemplate,
+	"recovery-code-used-email-template.html":   recoveryCodeUsedEmailTemplate,
+	"magic-link-email-template.html":           magicLinkEmailTemplate,
+	"account-locked-email-template.html":       accountLockedEmailTemplate,
+	"new-device-login-email-template.html":     newDeviceLoginEmailTemplate,
+	"passkey-added-email-template.html":        passkeyAddedEmailTemplate,
 }